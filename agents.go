@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+	"github.com/pink-tools/pink-otel"
+	"gopkg.in/yaml.v3"
+)
+
+func cmdAgents(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "ERROR: agents subcommand required (apply, signed-url, serve)")
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "apply":
+		cmdAgentsApply(args[1:])
+	case "signed-url":
+		cmdAgentsSignedURL(args[1:])
+	case "serve":
+		cmdAgentsServe(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown agents command: %s\n", args[0])
+		os.Exit(1)
+	}
+}
+
+// agentFile is an agent definition kept under version control:
+//
+//	name: Support
+//	prompt_file: prompt.md
+//	first_message: Hi, how can I help?
+//	knowledge:
+//	  - docs/faq.md
+//	  - https://example.com/help
+//	tools:
+//	  - tools/lookup_order.yaml
+//
+// Paths are relative to the file.
+type agentFile struct {
+	AgentID      string `yaml:"agent_id"`
+	Name         string `yaml:"name"`
+	Prompt       string `yaml:"prompt"`
+	PromptFile   string `yaml:"prompt_file"`
+	FirstMessage string `yaml:"first_message"`
+	Language     string `yaml:"language"`
+	LLM          string `yaml:"llm"`
+	VoiceID      string `yaml:"voice_id"`
+	// Knowledge lists document files and URLs.
+	Knowledge []string `yaml:"knowledge"`
+	// Tools holds tool definitions, or paths of YAML or JSON files holding
+	// one definition or a list of them.
+	Tools []any `yaml:"tools"`
+
+	dir string
+}
+
+func loadAgentFile(path string) (*agentFile, error) {
+	b, err := readInputFile(path)
+	if err != nil {
+		return nil, err
+	}
+	a := &agentFile{dir: filepath.Dir(path)}
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(a); err != nil {
+		return nil, fmt.Errorf("invalid agent file %s: %w", path, err)
+	}
+	if a.Name == "" && a.AgentID == "" {
+		return nil, fmt.Errorf("%s: name or agent_id required", path)
+	}
+	if a.Prompt != "" && a.PromptFile != "" {
+		return nil, fmt.Errorf("%s: prompt and prompt_file are exclusive", path)
+	}
+	return a, nil
+}
+
+func (a *agentFile) path(p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(a.dir, p)
+}
+
+func (a *agentFile) prompt() (string, error) {
+	if a.PromptFile == "" {
+		return a.Prompt, nil
+	}
+	b, err := os.ReadFile(a.path(a.PromptFile))
+	if err != nil {
+		return "", fmt.Errorf("failed to read prompt: %w", err)
+	}
+	return strings.TrimSpace(string(decodeText(b, a.PromptFile))), nil
+}
+
+// tools reads the tool definitions, loading those given as paths.
+func (a *agentFile) tools() ([]map[string]any, error) {
+	if a.Tools == nil {
+		return nil, nil
+	}
+	tools := []map[string]any{}
+	for _, t := range a.Tools {
+		switch t := t.(type) {
+		case map[string]any:
+			tools = append(tools, t)
+		case string:
+			b, err := os.ReadFile(a.path(t))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read tool: %w", err)
+			}
+			b = decodeText(b, t)
+			// YAML is a superset of JSON, so this reads both.
+			var one map[string]any
+			if err := yaml.Unmarshal(b, &one); err == nil {
+				tools = append(tools, one)
+				continue
+			}
+			var many []map[string]any
+			if err := yaml.Unmarshal(b, &many); err != nil {
+				return nil, fmt.Errorf("invalid tool file %s: %w", t, err)
+			}
+			tools = append(tools, many...)
+		default:
+			return nil, fmt.Errorf("tool must be a mapping or a file path, not %T", t)
+		}
+	}
+	return tools, nil
+}
+
+// knowledgeName names a knowledge file by its base name and content hash,
+// so an unchanged file is found again and a changed one is uploaded anew.
+func knowledgeName(path string, content []byte) string {
+	sum := sha256.Sum256(content)
+	return fmt.Sprintf("%s (%s)", filepath.Base(path), hex.EncodeToString(sum[:4]))
+}
+
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://")
+}
+
+// knowledge returns the agent's knowledge documents, uploading new ones
+// unless client is nil.
+func (a *agentFile) knowledge(ctx context.Context, client *elevenlabs.Client) ([]elevenlabs.KnowledgeDocument, error) {
+	if a.Knowledge == nil {
+		return nil, nil
+	}
+	existing := map[string]elevenlabs.KnowledgeDocument{}
+	if client != nil {
+		docs, err := client.ListKnowledgeDocuments(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range docs {
+			existing[d.Type+"\x00"+d.Name] = d
+		}
+	}
+
+	docs := []elevenlabs.KnowledgeDocument{}
+	for _, k := range a.Knowledge {
+		var content []byte
+		d := elevenlabs.KnowledgeDocument{Type: elevenlabs.KnowledgeURL, Name: k}
+		if !isURL(k) {
+			b, err := os.ReadFile(a.path(k))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read knowledge document: %w", err)
+			}
+			content = b
+			d = elevenlabs.KnowledgeDocument{Type: elevenlabs.KnowledgeFile, Name: knowledgeName(k, b)}
+		}
+		if e, ok := existing[d.Type+"\x00"+d.Name]; ok {
+			d = e
+		} else if client != nil {
+			otel.Info("agent_knowledge_upload", map[string]any{"name": d.Name, "type": d.Type})
+			var up *elevenlabs.KnowledgeDocument
+			var err error
+			if d.Type == elevenlabs.KnowledgeURL {
+				up, err = client.AddKnowledgeURL(ctx, d.Name, k)
+			} else {
+				up, err = client.AddKnowledgeFile(ctx, d.Name, filepath.Base(k), bytes.NewReader(content))
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to add %s: %w", k, err)
+			}
+			d = *up
+			fmt.Fprintf(os.Stderr, "Uploaded %s\n", k)
+		}
+		docs = append(docs, d)
+	}
+	return docs, nil
+}
+
+// config builds the agent configuration, uploading knowledge documents
+// through client unless it is nil.
+func (a *agentFile) config(ctx context.Context, client *elevenlabs.Client) (*elevenlabs.AgentConfig, error) {
+	prompt, err := a.prompt()
+	if err != nil {
+		return nil, err
+	}
+	tools, err := a.tools()
+	if err != nil {
+		return nil, err
+	}
+	knowledge, err := a.knowledge(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	return &elevenlabs.AgentConfig{
+		Name:         a.Name,
+		Prompt:       prompt,
+		FirstMessage: a.FirstMessage,
+		Language:     a.Language,
+		LLM:          a.LLM,
+		VoiceID:      a.VoiceID,
+		Knowledge:    knowledge,
+		Tools:        tools,
+	}, nil
+}
+
+// findAgent returns the ID of the agent named name, or "" if there is none.
+func findAgent(ctx context.Context, client *elevenlabs.Client, name string) (string, error) {
+	agents, err := client.ListAgents(ctx)
+	if err != nil {
+		return "", err
+	}
+	var id string
+	for _, a := range agents {
+		if a.Name == name {
+			if id != "" {
+				return "", fmt.Errorf("several agents are named %q (%s, %s); set agent_id", name, id, a.AgentID)
+			}
+			id = a.AgentID
+		}
+	}
+	return id, nil
+}
+
+func cmdAgentsApply(args []string) {
+	fs := flag.NewFlagSet("agents apply", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "Print the configuration that would be sent, without uploading")
+	api := addClientFlags(fs)
+	parseArgs(fs, args)
+
+	if fs.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "ERROR: agent file argument required")
+		os.Exit(1)
+	}
+	a, err := loadAgentFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	if *dryRun {
+		cfg, err := a.config(ctx, nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		printJSON(cfg)
+		return
+	}
+
+	client := api.newClient()
+	id := a.AgentID
+	if id == "" {
+		if id, err = findAgent(ctx, client, a.Name); err != nil {
+			fail("agent_apply_failed", err)
+		}
+	}
+	cfg, err := a.config(ctx, client)
+	if err != nil {
+		fail("agent_apply_failed", err)
+	}
+
+	created := id == ""
+	if created {
+		otel.Info("agent_create_request", map[string]any{"name": a.Name})
+		id, err = client.CreateAgent(ctx, cfg)
+	} else {
+		otel.Info("agent_update_request", map[string]any{"agent_id": id})
+		err = client.UpdateAgent(ctx, id, cfg)
+	}
+	if err != nil {
+		fail("agent_apply_failed", err)
+	}
+
+	otel.Info("agent_applied", map[string]any{"agent_id": id, "created": created, "knowledge": len(cfg.Knowledge), "tools": len(cfg.Tools)})
+	fmt.Println(id)
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxArticleBytes caps how much of a page --from-url reads.
+const maxArticleBytes = 10 << 20
+
+var (
+	// Elements that never hold article text, removed before parsing since
+	// their content needn't be well-formed.
+	htmlNoise = []*regexp.Regexp{
+		regexp.MustCompile(`(?s)<!--.*?-->`),
+		regexp.MustCompile(`(?is)<script\b.*?</script\s*>`),
+		regexp.MustCompile(`(?is)<style\b.*?</style\s*>`),
+		regexp.MustCompile(`(?is)<noscript\b.*?</noscript\s*>`),
+		regexp.MustCompile(`(?is)<svg\b.*?</svg\s*>`),
+		regexp.MustCompile(`(?is)<template\b.*?</template\s*>`),
+		regexp.MustCompile(`(?is)<!doctype[^>]*>`),
+	}
+
+	// Text that reads badly aloud: citation marks like [12] and bare links.
+	htmlTag  = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9:-]*)((?:[^>"']|"[^"]*"|'[^']*')*)>`)
+	htmlAttr = regexp.MustCompile(`([a-zA-Z:-]+)(?:\s*=\s*("[^"]*"|'[^']*'|[^\s"'>]+))?`)
+
+	citationMark = regexp.MustCompile(`\[\d+\]|\[citation needed\]`)
+	bareURL      = regexp.MustCompile(`https?://\S*[^\s.,;:!?)\]]`)
+	strandedMark = regexp.MustCompile(`\s+([.,;:!?])`)
+)
+
+// Elements whose text is left out, such as navigation and page chrome.
+var skippedElements = map[string]bool{
+	"nav": true, "header": true, "footer": true, "aside": true,
+	"form": true, "button": true, "select": true, "figure": true, "iframe": true,
+	"menu": true, "dialog": true,
+}
+
+// Elements without content or end tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true,
+	"img": true, "input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// Elements that separate words when nested in a paragraph.
+var blockElements = map[string]bool{
+	"div": true, "section": true, "table": true, "tr": true, "td": true, "th": true,
+	"ul": true, "ol": true, "dl": true, "dt": true,
+}
+
+// Elements read as one paragraph each.
+var textBlocks = map[string]bool{
+	"p": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"li": true, "blockquote": true, "pre": true, "dd": true,
+}
+
+// article is the readable content of a web page.
+type article struct {
+	Title string
+	// Paragraphs holds the headings and paragraphs in page order.
+	Paragraphs []string
+}
+
+// Text returns the article as paragraphs, with the title first unless the
+// page starts with its own heading.
+func (a *article) Text() string {
+	paras := a.Paragraphs
+	if a.Title != "" && (len(paras) == 0 || !strings.Contains(a.Title, paras[0])) {
+		paras = append([]string{a.Title}, paras...)
+	}
+	out := make([]string, 0, len(paras))
+	for _, p := range paras {
+		p = citationMark.ReplaceAllString(p, "")
+		p = bareURL.ReplaceAllString(p, "")
+		p = strings.Join(strings.Fields(p), " ")
+		p = strandedMark.ReplaceAllString(p, "$1")
+		if p == "" {
+			continue
+		}
+		if r, _ := utf8.DecodeLastRuneInString(p); !strings.ContainsRune(".!?:;…\"'”’)", r) {
+			p += "."
+		}
+		out = append(out, p)
+	}
+	return strings.Join(out, "\n\n")
+}
+
+// fetchArticle downloads a page and extracts its article.
+func fetchArticle(ctx context.Context, pageURL string) (*article, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid URL: %s", pageURL)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", serviceName+"/"+version)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", pageURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", pageURL, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxArticleBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", pageURL, err)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "text/plain" {
+		return &article{Paragraphs: strings.Split(string(body), "\n\n")}, nil
+	}
+	if mediaType != "" && mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, fmt.Errorf("%s is %s, not a web page", pageURL, mediaType)
+	}
+	return extractArticle(string(body))
+}
+
+// block is a paragraph of a page with the elements enclosing it.
+type block struct {
+	text      string
+	ancestors []int
+}
+
+// extractArticle finds the main text of an HTML page, readability-style.
+func extractArticle(page string) (*article, error) {
+	for _, re := range htmlNoise {
+		page = re.ReplaceAllString(page, "")
+	}
+
+	type element struct {
+		id   int
+		name string
+	}
+	var (
+		stack    []element
+		blocks   []block
+		title    strings.Builder
+		text     strings.Builder
+		inTitle  bool
+		inBlock  int // depth of the open text block, 0 if none
+		skipping int // depth of the outermost skipped element, 0 if none
+		articles []int
+		mains    []int
+		nextID   int
+	)
+	// pop closes the elements from depth n up, as an end tag or an
+	// implicitly closed paragraph does.
+	pop := func(n int) {
+		if inBlock >= n {
+			if s := strings.TrimSpace(text.String()); s != "" {
+				ids := make([]int, inBlock-1)
+				for i, e := range stack[:inBlock-1] {
+					ids[i] = e.id
+				}
+				blocks = append(blocks, block{text: s, ancestors: ids})
+			}
+			inBlock = 0
+		}
+		if skipping >= n {
+			skipping = 0
+		}
+		stack = stack[:n-1]
+	}
+	chardata := func(s string) {
+		switch {
+		case skipping > 0:
+		case inTitle:
+			title.WriteString(s)
+		case inBlock > 0:
+			text.WriteString(s)
+		}
+	}
+
+	last := 0
+	for _, m := range htmlTag.FindAllStringSubmatchIndex(page, -1) {
+		chardata(page[last:m[0]])
+		last = m[1]
+		closing := m[3] > m[2]
+		name := strings.ToLower(page[m[4]:m[5]])
+		attrs := page[m[6]:m[7]]
+
+		if closing {
+			if name == "title" {
+				inTitle = false
+			}
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i].name == name {
+					pop(i + 1)
+					break
+				}
+			}
+			continue
+		}
+		if name == "title" && skipping == 0 {
+			inTitle = true
+			continue
+		}
+		if name == "br" {
+			chardata(" ")
+		}
+		if voidElements[name] {
+			continue
+		}
+		// A new paragraph or list item ends an open one.
+		if inBlock > 0 && textBlocks[name] && (stack[inBlock-1].name == "p" || stack[inBlock-1].name == name) {
+			pop(inBlock)
+		}
+		nextID++
+		stack = append(stack, element{nextID, name})
+		switch {
+		case skipping > 0:
+		case skippedElements[name] || hiddenElement(attrs):
+			skipping = len(stack)
+		case name == "article":
+			articles = append(articles, nextID)
+		case name == "main":
+			mains = append(mains, nextID)
+		case textBlocks[name] && inBlock == 0:
+			inBlock = len(stack)
+			text.Reset()
+		case inBlock > 0 && blockElements[name]:
+			text.WriteString(" ")
+		}
+	}
+	chardata(page[last:])
+	if len(stack) > 0 {
+		pop(1)
+	}
+
+	a := &article{Title: strings.Join(strings.Fields(html.UnescapeString(title.String())), " ")}
+	if len(articles) == 0 {
+		articles = mains
+	}
+	for _, b := range mainBlocks(blocks, articles) {
+		a.Paragraphs = append(a.Paragraphs, html.UnescapeString(b.text))
+	}
+	if len(a.Paragraphs) == 0 {
+		return nil, errors.New("no article text found on the page")
+	}
+	return a, nil
+}
+
+// hiddenElement reports whether a tag's attributes mark it as not
+// displayed or as page chrome.
+func hiddenElement(attrs string) bool {
+	for _, m := range htmlAttr.FindAllStringSubmatch(attrs, -1) {
+		name, value := strings.ToLower(m[1]), strings.Trim(m[2], `"'`)
+		switch {
+		case name == "hidden",
+			name == "aria-hidden" && value == "true",
+			name == "role" && (value == "navigation" || value == "banner" || value == "contentinfo"):
+			return true
+		}
+	}
+	return false
+}
+
+// mainBlocks picks the blocks of the article.
+func mainBlocks(blocks []block, articles []int) []block {
+	within := func(id int) []block {
+		var out []block
+		for _, b := range blocks {
+			for _, a := range b.ancestors {
+				if a == id {
+					out = append(out, b)
+					break
+				}
+			}
+		}
+		return out
+	}
+	length := func(bs []block) int {
+		n := 0
+		for _, b := range bs {
+			n += len(b.text)
+		}
+		return n
+	}
+
+	var best []block
+	for _, id := range articles {
+		if bs := within(id); length(bs) > length(best) {
+			best = bs
+		}
+	}
+	if len(best) > 0 {
+		return best
+	}
+
+	// Score each parent by the text of its paragraphs, ignoring one-liners
+	// such as bylines and captions.
+	scores := map[int]int{}
+	top, topScore := 0, 0
+	for _, b := range blocks {
+		if len(b.ancestors) == 0 || len(b.text) < 25 {
+			continue
+		}
+		parent := b.ancestors[len(b.ancestors)-1]
+		scores[parent] += len(b.text)
+		if scores[parent] > topScore {
+			top, topScore = parent, scores[parent]
+		}
+	}
+	if topScore == 0 {
+		return blocks
+	}
+	return within(top)
+}
+
+// inputName is the {input} of output names: the input file, or the page
+// read with --from-url.
+func inputName(input, pageURL string) string {
+	if pageURL != "" {
+		return articleName(pageURL)
+	}
+	return input
+}
+
+// articleName returns the last path element of a page URL, for naming
+// output files after it.
+func articleName(pageURL string) string {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+	name := path.Base(strings.TrimSuffix(u.Path, "/"))
+	if name == "." || name == "/" {
+		return u.Hostname()
+	}
+	return name
+}
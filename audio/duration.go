@@ -0,0 +1,84 @@
+package audio
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Duration returns the playing time of a complete stream in format f, as a
+// Joiner would play it.
+func Duration(f Format, r io.Reader) (time.Duration, error) {
+	switch f.Codec {
+	case PCM, ULaw, ALaw:
+		n, err := io.Copy(io.Discard, r)
+		if err != nil {
+			return 0, err
+		}
+		if f.Codec == PCM {
+			n /= 2
+		}
+		return samplesDuration(n, f.SampleRate), nil
+	case MP3:
+		return mp3Duration(r)
+	case Opus:
+		pr := newOggPacketReader(r)
+		var samples int64
+		for i := 0; ; i++ {
+			pkt, err := pr.next()
+			if errors.Is(err, io.EOF) {
+				return samplesDuration(samples, 48000), nil
+			}
+			if err != nil {
+				return 0, err
+			}
+			// The first two packets are OpusHead and OpusTags.
+			if i == 0 && !bytes.HasPrefix(pkt, []byte("OpusHead")) {
+				return 0, fmt.Errorf("not an Ogg Opus stream")
+			}
+			if i >= 2 {
+				samples += opusSamples(pkt)
+			}
+		}
+	default:
+		return 0, fmt.Errorf("cannot measure %s audio", f.Codec)
+	}
+}
+
+func samplesDuration(n int64, rate int) time.Duration {
+	if rate <= 0 {
+		return 0
+	}
+	return time.Duration(n) * time.Second / time.Duration(rate)
+}
+
+// mp3Duration walks the frames after any ID3v2 tag, stopping at the first
+// bytes that aren't a frame header, such as a trailing ID3v1 tag.
+func mp3Duration(r io.Reader) (time.Duration, error) {
+	br := bufio.NewReader(r)
+	if err := skipID3v2(br); err != nil {
+		return 0, err
+	}
+	var d time.Duration
+	for {
+		b, err := br.Peek(4)
+		if err != nil {
+			return d, nil
+		}
+		h, ok := parseMP3Header(b)
+		if !ok {
+			return d, nil
+		}
+		size := h.coef * h.bitrate * 1000 / h.sampleRate
+		if b[2]&0x02 != 0 {
+			size++
+		}
+		if _, err := br.Discard(size); err != nil {
+			return d, nil
+		}
+		d += samplesDuration(int64(h.frameSamples), h.sampleRate)
+	}
+}
@@ -0,0 +1,113 @@
+package audio
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDurationRoundTrip joins parts and silence, and measures the result.
+func TestDurationRoundTrip(t *testing.T) {
+	gaps := []time.Duration{300 * time.Millisecond, 1500 * time.Millisecond, 20 * time.Millisecond}
+	tests := []struct {
+		format string
+		// frame is the length silence is rounded up to.
+		frame time.Duration
+	}{
+		{"pcm_24000", time.Second / 24000},
+		{"ulaw_8000", time.Second / 8000},
+		{"alaw_8000", time.Second / 8000},
+		{"mp3_44100_128", 1152 * time.Second / 44100},
+		{"mp3_22050_32", 576 * time.Second / 22050},
+		{"opus_48000_64", 20 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		f, _ := ParseFormat(tt.format)
+		var part bytes.Buffer
+		if err := WriteSilence(&part, f, time.Second); err != nil {
+			t.Fatal(err)
+		}
+		partLen, err := Duration(f, bytes.NewReader(part.Bytes()))
+		if err != nil || partLen < time.Second || partLen >= time.Second+tt.frame {
+			t.Errorf("%s: one second of silence measures %v, %v", tt.format, partLen, err)
+			continue
+		}
+
+		var buf bytes.Buffer
+		j, err := NewJoiner(&buf, f, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := 2 * partLen
+		j.Append(bytes.NewReader(part.Bytes()))
+		for _, gap := range gaps {
+			j.AppendSilence(gap)
+			want += gap
+		}
+		j.Append(bytes.NewReader(part.Bytes()))
+		if err := j.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := Duration(f, bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.format, err)
+		}
+		if got < want || got > want+time.Duration(len(gaps))*tt.frame {
+			t.Errorf("%s: joined %v, want %v plus at most a frame per gap", tt.format, got, want)
+		}
+	}
+}
+
+func TestDurationMP3Framing(t *testing.T) {
+	frames := mp3Part(t, 10)
+	want := 10 * samplesDuration(1152, 44100)
+	tests := map[string][]byte{
+		"bare":          frames,
+		"ID3v2":         append(id3Tag(500, false), frames...),
+		"ID3v2 footer":  append(id3Tag(500, true), frames...),
+		"ID3v1 trailer": append(append([]byte(nil), frames...), append([]byte("TAG"), make([]byte, 125)...)...),
+		"junk trailer":  append(append([]byte(nil), frames...), "\xff\xff\xff\xff"...),
+	}
+	for name, b := range tests {
+		got, err := Duration(Format{Codec: MP3, SampleRate: 44100, Bitrate: 128}, bytes.NewReader(b))
+		if err != nil || got != want {
+			t.Errorf("%s: Duration = %v, %v; want %v", name, got, err, want)
+		}
+	}
+
+	// A cut-off last frame is not counted.
+	got, _ := Duration(Format{Codec: MP3}, bytes.NewReader(frames[:len(frames)-1]))
+	if got != 9*samplesDuration(1152, 44100) {
+		t.Errorf("truncated: Duration = %v", got)
+	}
+}
+
+func TestDurationErrors(t *testing.T) {
+	tests := []struct {
+		f    Format
+		in   []byte
+		want string
+	}{
+		{Format{Codec: Opus}, vorbisStream(), "not an Ogg Opus stream"},
+		{Format{Codec: Opus}, append([]byte("RIFF"), make([]byte, 40)...), "invalid ogg page signature"},
+		{Format{Codec: MP3}, id3Tag(500, false)[:50], "truncated ID3 tag"},
+		{Format{Codec: "flac"}, nil, "cannot measure flac audio"},
+	}
+	for _, tt := range tests {
+		_, err := Duration(tt.f, bytes.NewReader(tt.in))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.f.Codec, err, tt.want)
+		}
+	}
+}
+
+// vorbisStream returns an Ogg stream that does not hold Opus.
+func vorbisStream() []byte {
+	var buf bytes.Buffer
+	ow := newOggWriter(&buf, 1)
+	ow.writePacket([]byte("\x01vorbis"), 0, true)
+	ow.close()
+	return buf.Bytes()
+}
@@ -0,0 +1,44 @@
+package audio
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Format is a parsed API output format such as "mp3_44100_128".
+type Format struct {
+	Codec      Codec
+	SampleRate int
+	// Bitrate is in kbps; zero for uncompressed codecs.
+	Bitrate int
+}
+
+// ParseFormat parses an API output format string.
+func ParseFormat(s string) (Format, error) {
+	parts := strings.Split(s, "_")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Format{}, fmt.Errorf("invalid format: %s", s)
+	}
+	f := Format{Codec: Codec(parts[0])}
+	rate, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return Format{}, fmt.Errorf("invalid sample rate in format: %s", s)
+	}
+	f.SampleRate = rate
+	if len(parts) == 3 {
+		br, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return Format{}, fmt.Errorf("invalid bitrate in format: %s", s)
+		}
+		f.Bitrate = br
+	}
+	return f, nil
+}
+
+func (f Format) String() string {
+	if f.Bitrate > 0 {
+		return fmt.Sprintf("%s_%d_%d", f.Codec, f.SampleRate, f.Bitrate)
+	}
+	return fmt.Sprintf("%s_%d", f.Codec, f.SampleRate)
+}
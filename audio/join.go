@@ -0,0 +1,142 @@
+// Package audio stitches and generates audio in the formats the ElevenLabs
+// API returns, without decoding it.
+package audio
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Codec identifies the container/encoding of an audio stream.
+type Codec string
+
+const (
+	MP3  Codec = "mp3"
+	Opus Codec = "opus"
+	PCM  Codec = "pcm"
+	// ULaw and ALaw are headerless 8-bit G.711 samples, for telephony.
+	ULaw Codec = "ulaw"
+	ALaw Codec = "alaw"
+)
+
+// Joiner concatenates audio streams of one format into a single playable
+// stream. Append must be called with complete streams, in order.
+type Joiner interface {
+	Append(r io.Reader) error
+	// AppendSilence inserts d of silence encoded to match the stream.
+	AppendSilence(d time.Duration) error
+	Close() error
+}
+
+// NewJoiner returns a Joiner writing to w. Non-empty tags replace the
+// metadata of the first part; headerless PCM cannot carry any.
+func NewJoiner(w io.Writer, f Format, tags Tags) (Joiner, error) {
+	switch f.Codec {
+	case MP3:
+		return &mp3Joiner{w: w, format: f, tags: tags}, nil
+	case Opus:
+		return newOggOpusJoiner(w, tags), nil
+	case PCM, ULaw, ALaw:
+		if len(tags) > 0 {
+			return nil, fmt.Errorf("cannot tag %s audio", f.Codec)
+		}
+		return &rawJoiner{w: w, format: f}, nil
+	default:
+		return nil, fmt.Errorf("cannot join %s audio", f.Codec)
+	}
+}
+
+// rawJoiner appends headerless sample data.
+type rawJoiner struct {
+	w      io.Writer
+	format Format
+}
+
+func (j *rawJoiner) Append(r io.Reader) error {
+	_, err := io.Copy(j.w, r)
+	return err
+}
+
+func (j *rawJoiner) AppendSilence(d time.Duration) error {
+	return WriteSilence(j.w, j.format, d)
+}
+
+func (j *rawJoiner) Close() error { return nil }
+
+// mp3Joiner appends MPEG audio frames, keeping only the ID3v2 tag at the
+// start of the stream.
+type mp3Joiner struct {
+	w       io.Writer
+	format  Format
+	tags    Tags
+	started bool
+	// header is taken from the first frame seen so inserted silence
+	// matches the real audio's rate, bitrate and channel mode.
+	header *mp3Header
+}
+
+func (j *mp3Joiner) Append(r io.Reader) error {
+	br := bufio.NewReader(r)
+	if j.started || len(j.tags) > 0 {
+		if err := skipID3v2(br); err != nil {
+			return err
+		}
+	}
+	if err := j.start(); err != nil {
+		return err
+	}
+	if j.header == nil {
+		if b, err := br.Peek(4); err == nil {
+			j.header, _ = parseMP3Header(b)
+		}
+	}
+	_, err := io.Copy(j.w, br)
+	return err
+}
+
+// start writes the replacement tag before anything else.
+func (j *mp3Joiner) start() error {
+	if j.started {
+		return nil
+	}
+	j.started = true
+	if len(j.tags) == 0 {
+		return nil
+	}
+	_, err := j.w.Write(id3v2(j.tags))
+	return err
+}
+
+func (j *mp3Joiner) AppendSilence(d time.Duration) error {
+	if err := j.start(); err != nil {
+		return err
+	}
+	if j.header == nil {
+		h, err := newMP3Header(j.format.SampleRate, j.format.Bitrate, true)
+		if err != nil {
+			return err
+		}
+		j.header = h
+	}
+	return j.header.writeSilence(j.w, d)
+}
+
+func (j *mp3Joiner) Close() error { return nil }
+
+func skipID3v2(br *bufio.Reader) error {
+	hdr, err := br.Peek(10)
+	if err != nil || string(hdr[:3]) != "ID3" {
+		return nil
+	}
+	size := int64(hdr[6]&0x7f)<<21 | int64(hdr[7]&0x7f)<<14 | int64(hdr[8]&0x7f)<<7 | int64(hdr[9]&0x7f)
+	size += 10
+	if hdr[5]&0x10 != 0 {
+		size += 10
+	}
+	if _, err := io.CopyN(io.Discard, br, size); err != nil {
+		return fmt.Errorf("truncated ID3 tag: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package audio
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// id3Tag returns an ID3v2 tag with size bytes of body, and a footer if set.
+func id3Tag(size int, footer bool) []byte {
+	flags := byte(0)
+	if footer {
+		flags = 0x10
+	}
+	b := appendSynchsafe([]byte{'I', 'D', '3', 4, 0, flags}, size)
+	b = append(b, bytes.Repeat([]byte{0x55}, size)...)
+	if footer {
+		b = append(b, appendSynchsafe([]byte{'3', 'D', 'I', 4, 0, flags}, size)...)
+	}
+	return b
+}
+
+// mp3Frames walks the Layer III frames of b and returns their headers,
+// failing on anything that is not a frame.
+func mp3Frames(t *testing.T, b []byte) []*mp3Header {
+	t.Helper()
+	var frames []*mp3Header
+	for off := 0; off < len(b); {
+		h, ok := parseMP3Header(b[off:])
+		if !ok {
+			t.Fatalf("no frame header at byte %d of %d", off, len(b))
+		}
+		size := h.coef * h.bitrate * 1000 / h.sampleRate
+		if b[off+2]&0x02 != 0 {
+			size++
+		}
+		if off+size > len(b) {
+			t.Fatalf("frame at byte %d is truncated", off)
+		}
+		frames = append(frames, h)
+		off += size
+	}
+	return frames
+}
+
+// mp3Part returns n frames of 44.1 kHz 128 kbps audio.
+func mp3Part(t *testing.T, n int) []byte {
+	t.Helper()
+	h, err := newMP3Header(44100, 128, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := h.writeSilence(&buf, time.Duration(n)*1152*time.Second/44100); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestMP3Joiner(t *testing.T) {
+	first := append(id3Tag(20, false), mp3Part(t, 3)...)
+	parts := [][]byte{
+		first,
+		append(id3Tag(300, true), mp3Part(t, 5)...),
+		mp3Part(t, 2),
+	}
+	var buf bytes.Buffer
+	j, err := NewJoiner(&buf, Format{Codec: MP3, SampleRate: 44100, Bitrate: 128}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, part := range parts {
+		if err := j.Append(bytes.NewReader(part)); err != nil {
+			t.Fatalf("part %d: %v", i, err)
+		}
+	}
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.Bytes()
+	if !bytes.HasPrefix(out, first) {
+		t.Error("the first part and its tag are not kept as they were")
+	}
+	if n := bytes.Count(out, []byte("ID3")); n != 1 {
+		t.Errorf("found %d ID3 tags, want 1", n)
+	}
+	if n := len(mp3Frames(t, out[len(id3Tag(20, false)):])); n != 10 {
+		t.Errorf("got %d frames, want 10", n)
+	}
+}
+
+func TestMP3JoinerTruncatedTag(t *testing.T) {
+	j, _ := NewJoiner(&bytes.Buffer{}, Format{Codec: MP3, SampleRate: 44100, Bitrate: 128}, nil)
+	if err := j.Append(bytes.NewReader(mp3Part(t, 1))); err != nil {
+		t.Fatal(err)
+	}
+	err := j.Append(bytes.NewReader(id3Tag(300, false)[:100]))
+	if err == nil || !strings.Contains(err.Error(), "truncated ID3 tag") {
+		t.Errorf("err = %v, want a truncated tag error", err)
+	}
+}
+
+func TestRawJoiner(t *testing.T) {
+	for _, c := range []Codec{PCM, ULaw, ALaw} {
+		var buf bytes.Buffer
+		j, err := NewJoiner(&buf, Format{Codec: c, SampleRate: 8000}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		j.Append(strings.NewReader("abc"))
+		j.Append(strings.NewReader("ID3def"))
+		j.Close()
+		if buf.String() != "abcID3def" {
+			t.Errorf("%s: joined %q", c, buf.String())
+		}
+	}
+}
+
+func TestNewJoinerErrors(t *testing.T) {
+	if _, err := NewJoiner(&bytes.Buffer{}, Format{Codec: "flac", SampleRate: 44100}, nil); err == nil {
+		t.Error("joined an unknown codec")
+	}
+	if _, err := NewJoiner(&bytes.Buffer{}, Format{Codec: PCM, SampleRate: 16000}, Tags{"title": "x"}); err == nil {
+		t.Error("tagged headerless PCM")
+	}
+}
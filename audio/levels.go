@@ -0,0 +1,247 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"time"
+)
+
+const (
+	// MinLoudness is reported for audio too short or quiet to measure: the
+	// absolute gate of ITU-R BS.1770.
+	MinLoudness = -70.0
+	// MinPeak is reported for digital silence, the floor of 16-bit audio.
+	MinPeak = -96.0
+	// SilenceThreshold is the level, in dBFS, below which audio counts as
+	// silence.
+	SilenceThreshold = -50.0
+)
+
+// Levels is the measured loudness, peak and silence of some audio.
+type Levels struct {
+	Duration time.Duration
+	// Loudness is the integrated loudness in LUFS per ITU-R BS.1770.
+	Loudness float64
+	// Peak is the highest sample level in dBFS.
+	Peak float64
+	// Clipped counts samples at full scale.
+	Clipped int
+	// LongestSilence is the longest stretch below SilenceThreshold,
+	// including any at the start or end.
+	LongestSilence time.Duration
+}
+
+// biquad is one second-order IIR filter section.
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+func (f *biquad) filter(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, x
+	f.y2, f.y1 = f.y1, y
+	return y
+}
+
+// kWeighting returns the two stages of the BS.1770 K-weighting filter, a
+// high shelf and a high pass, designed for rate.
+func kWeighting(rate int) [2]biquad {
+	fs := float64(rate)
+
+	a := math.Pow(10, 4.0/40)
+	w := 2 * math.Pi * 1500 / fs
+	alpha := math.Sin(w) / (2 / math.Sqrt2)
+	cos, sq := math.Cos(w), 2*math.Sqrt(a)*alpha
+	a0 := (a + 1) - (a-1)*cos + sq
+	shelf := biquad{
+		b0: a * ((a + 1) + (a-1)*cos + sq) / a0,
+		b1: -2 * a * ((a - 1) + (a+1)*cos) / a0,
+		b2: a * ((a + 1) + (a-1)*cos - sq) / a0,
+		a1: 2 * ((a - 1) - (a+1)*cos) / a0,
+		a2: ((a + 1) - (a-1)*cos - sq) / a0,
+	}
+
+	w = 2 * math.Pi * 38 / fs
+	alpha = math.Sin(w) / (2 * 0.5)
+	cos = math.Cos(w)
+	a0 = 1 + alpha
+	highPass := biquad{
+		b0: (1 + cos) / 2 / a0,
+		b1: -(1 + cos) / a0,
+		b2: (1 + cos) / 2 / a0,
+		a1: -2 * cos / a0,
+		a2: (1 - alpha) / a0,
+	}
+	return [2]biquad{shelf, highPass}
+}
+
+// MeasurePCM measures interleaved 16-bit little-endian PCM.
+func MeasurePCM(r io.Reader, sampleRate, channels int) (*Levels, error) {
+	filters := make([][2]biquad, channels)
+	for c := range filters {
+		filters[c] = kWeighting(sampleRate)
+	}
+	// Loudness is gated over 400 ms blocks overlapping by 75%, built from
+	// 100 ms steps; silence is found in 10 ms windows.
+	step := max(sampleRate/10, 1)
+	window := max(sampleRate/100, 1)
+	silentBelow := math.Pow(10, SilenceThreshold/20) * 32768
+
+	var (
+		steps      []float64
+		stepSum    float64
+		frames     int64
+		peak       int
+		clipped    int
+		windowPeak int
+		silentRun  int64
+		longest    int64
+	)
+	br := bufio.NewReader(r)
+	frame := make([]byte, 2*channels)
+	for {
+		if _, err := io.ReadFull(br, frame); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return nil, err
+		}
+		for c := 0; c < channels; c++ {
+			s := int(int16(binary.LittleEndian.Uint16(frame[2*c:])))
+			mag := s
+			if mag < 0 {
+				mag = -mag
+			}
+			if mag >= 32767 {
+				clipped++
+			}
+			peak = max(peak, mag)
+			windowPeak = max(windowPeak, mag)
+			y := float64(s) / 32768
+			y = filters[c][0].filter(y)
+			y = filters[c][1].filter(y)
+			stepSum += y * y
+		}
+		frames++
+		if frames%int64(step) == 0 {
+			steps = append(steps, stepSum/float64(step))
+			stepSum = 0
+		}
+		if frames%int64(window) == 0 {
+			if float64(windowPeak) < silentBelow {
+				silentRun += int64(window)
+				longest = max(longest, silentRun)
+			} else {
+				silentRun = 0
+			}
+			windowPeak = 0
+		}
+	}
+
+	l := &Levels{
+		Duration:       samplesDuration(frames, sampleRate),
+		Loudness:       gatedLoudness(steps),
+		Peak:           MinPeak,
+		Clipped:        clipped,
+		LongestSilence: samplesDuration(longest, sampleRate),
+	}
+	if peak > 0 {
+		l.Peak = max(20*math.Log10(float64(peak)/32768), MinPeak)
+	}
+	return l, nil
+}
+
+// gatedLoudness computes integrated loudness from the mean square power of
+// consecutive 100 ms steps, summed over channels.
+func gatedLoudness(steps []float64) float64 {
+	loudness := func(power float64) float64 {
+		return -0.691 + 10*math.Log10(power)
+	}
+	var blocks []float64
+	for i := 0; i+4 <= len(steps); i++ {
+		blocks = append(blocks, (steps[i]+steps[i+1]+steps[i+2]+steps[i+3])/4)
+	}
+	mean := func(threshold float64) (float64, bool) {
+		var sum float64
+		n := 0
+		for _, p := range blocks {
+			if p > 0 && loudness(p) > threshold {
+				sum += p
+				n++
+			}
+		}
+		if n == 0 {
+			return 0, false
+		}
+		return sum / float64(n), true
+	}
+
+	p, ok := mean(MinLoudness)
+	if !ok {
+		return MinLoudness
+	}
+	if p, ok = mean(loudness(p) - 10); !ok {
+		return MinLoudness
+	}
+	return max(loudness(p), MinLoudness)
+}
+
+// G711Reader decodes 8-bit mu-law or A-law samples to 16-bit PCM.
+type G711Reader struct {
+	r     io.Reader
+	codec Codec
+	buf   []byte
+}
+
+// NewG711Reader returns a reader of the 16-bit PCM decoded from r, which
+// holds ULaw or ALaw samples.
+func NewG711Reader(r io.Reader, codec Codec) *G711Reader {
+	return &G711Reader{r: r, codec: codec}
+}
+
+func (g *G711Reader) Read(p []byte) (int, error) {
+	n := len(p) / 2
+	if n == 0 {
+		return 0, nil
+	}
+	if cap(g.buf) < n {
+		g.buf = make([]byte, n)
+	}
+	n, err := g.r.Read(g.buf[:n])
+	for i, b := range g.buf[:n] {
+		var s int16
+		if g.codec == ALaw {
+			s = alawSample(b)
+		} else {
+			s = ulawSample(b)
+		}
+		binary.LittleEndian.PutUint16(p[2*i:], uint16(s))
+	}
+	return 2 * n, err
+}
+
+func ulawSample(b byte) int16 {
+	b = ^b
+	mag := (int(b&0x0f)<<3 + 0x84) << (b >> 4 & 7)
+	if b&0x80 != 0 {
+		return int16(0x84 - mag)
+	}
+	return int16(mag - 0x84)
+}
+
+func alawSample(b byte) int16 {
+	b ^= 0x55
+	exp := int(b >> 4 & 7)
+	mag := int(b&0x0f)<<4 + 8
+	if exp > 0 {
+		mag = (mag + 0x100) << (exp - 1)
+	}
+	if b&0x80 != 0 {
+		return int16(mag)
+	}
+	return int16(-mag)
+}
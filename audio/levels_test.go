@@ -0,0 +1,125 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+	"time"
+)
+
+// tone returns d of a 1 kHz sine at peak dBFS, copied to every channel.
+// A peak of -200 gives digital silence.
+func tone(rate, channels int, peak float64, d time.Duration) []byte {
+	amp := math.Pow(10, peak/20) * 32768
+	var b []byte
+	for i := 0; i < int(d.Seconds()*float64(rate)); i++ {
+		s := int16(math.Round(amp * math.Sin(2*math.Pi*1000*float64(i)/float64(rate))))
+		for c := 0; c < channels; c++ {
+			b = binary.LittleEndian.AppendUint16(b, uint16(s))
+		}
+	}
+	return b
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func TestMeasurePCM(t *testing.T) {
+	tests := []struct {
+		name     string
+		pcm      []byte
+		rate     int
+		channels int
+		loudness float64
+		peak     float64
+		silence  time.Duration
+	}{
+		// A 1 kHz sine at 0 dBFS reads -3.01 LUFS per BS.1770.
+		{"sine", tone(48000, 1, -20, 5*time.Second), 48000, 1, -23.01, -20, 0},
+		{"sine at 24 kHz", tone(24000, 1, -20, 5*time.Second), 24000, 1, -23.01, -20, 0},
+		{"stereo sine", tone(44100, 2, -20, 5*time.Second), 44100, 2, -20.0, -20, 0},
+		// The relative gate drops the quiet half, leaving 47 loud blocks and
+		// 3 straddling the change at 3/4, 1/2 and 1/4 power: -0.13 LU.
+		{"loud and quiet", concat(tone(48000, 1, -20, 5*time.Second), tone(48000, 1, -45, 5*time.Second)), 48000, 1, -23.14, -20, 0},
+		// The absolute gate drops the gap, leaving 14 full blocks and 6
+		// straddling its edges, 17/20 of full power on average: -0.71 LU.
+		{"gap", concat(tone(16000, 1, -10, time.Second), tone(16000, 1, -200, 2*time.Second), tone(16000, 1, -10, time.Second)), 16000, 1, -13.72, -10, 2 * time.Second},
+		{"digital silence", tone(16000, 1, -200, 2*time.Second), 16000, 1, MinLoudness, MinPeak, 2 * time.Second},
+		{"too short to gate", tone(48000, 1, -20, 300*time.Millisecond), 48000, 1, MinLoudness, -20, 0},
+	}
+	for _, tt := range tests {
+		l, err := MeasurePCM(bytes.NewReader(tt.pcm), tt.rate, tt.channels)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if want := time.Duration(len(tt.pcm)/2/tt.channels) * time.Second / time.Duration(tt.rate); l.Duration != want {
+			t.Errorf("%s: duration %v, want %v", tt.name, l.Duration, want)
+		}
+		if math.Abs(l.Loudness-tt.loudness) > 0.1 {
+			t.Errorf("%s: loudness %.2f LUFS, want %.2f", tt.name, l.Loudness, tt.loudness)
+		}
+		if math.Abs(l.Peak-tt.peak) > 0.01 {
+			t.Errorf("%s: peak %.2f dBFS, want %.2f", tt.name, l.Peak, tt.peak)
+		}
+		if d := l.LongestSilence - tt.silence; d < -10*time.Millisecond || d > 10*time.Millisecond {
+			t.Errorf("%s: longest silence %v, want %v", tt.name, l.LongestSilence, tt.silence)
+		}
+		if l.Clipped != 0 {
+			t.Errorf("%s: %d samples clipped", tt.name, l.Clipped)
+		}
+	}
+}
+
+func TestMeasurePCMClipping(t *testing.T) {
+	pcm := pcmSamples(32767, -32768, -32767, 32766, 0, 100)
+	l, err := MeasurePCM(bytes.NewReader(append(pcm, 1)), 8000, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Clipped != 3 || l.Peak != 0 || l.Duration != 3*time.Second/8000 {
+		t.Errorf("levels = %+v, want 3 clipped at 0 dBFS in 3 frames", *l)
+	}
+}
+
+func TestG711Samples(t *testing.T) {
+	tests := []struct {
+		codec Codec
+		in    byte
+		want  int16
+	}{
+		{ULaw, 0xff, 0},
+		{ULaw, 0x7f, 0},
+		{ULaw, 0x80, 32124},
+		{ULaw, 0x00, -32124},
+		{ULaw, 0xef, 132},
+		{ALaw, 0xd5, 8},
+		{ALaw, 0x55, -8},
+		{ALaw, 0xaa, 32256},
+		{ALaw, 0x2a, -32256},
+	}
+	for _, tt := range tests {
+		got, _ := io.ReadAll(NewG711Reader(bytes.NewReader([]byte{tt.in, tt.in}), tt.codec))
+		if want := pcmSamples(tt.want, tt.want); !bytes.Equal(got, want) {
+			t.Errorf("%s %#x: decoded %v, want %d", tt.codec, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestG711Silence(t *testing.T) {
+	for _, c := range []Codec{ULaw, ALaw} {
+		var buf bytes.Buffer
+		if err := WriteSilence(&buf, Format{Codec: c, SampleRate: 8000}, time.Second); err != nil {
+			t.Fatal(err)
+		}
+		l, err := MeasurePCM(NewG711Reader(&buf, c), 8000, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l.Duration != time.Second || l.LongestSilence != time.Second || l.Loudness != MinLoudness {
+			t.Errorf("%s: silence measures %+v", c, *l)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	oggHeaderContinued = 0x01
+	oggHeaderBOS       = 0x02
+	oggHeaderEOS       = 0x04
+
+	// oggMaxPageData keeps pages near the size libogg produces.
+	oggMaxPageData = 4096
+)
+
+var oggCRCTable = func() [256]uint32 {
+	var t [256]uint32
+	for i := range t {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = r<<1 ^ 0x04c11db7
+			} else {
+				r <<= 1
+			}
+		}
+		t[i] = r
+	}
+	return t
+}()
+
+func oggCRC(b []byte) uint32 {
+	var crc uint32
+	for _, v := range b {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^v]
+	}
+	return crc
+}
+
+// oggPacketReader reassembles packets from a single logical Ogg stream.
+type oggPacketReader struct {
+	r       io.Reader
+	pending [][]byte
+	partial []byte
+}
+
+func newOggPacketReader(r io.Reader) *oggPacketReader {
+	return &oggPacketReader{r: r}
+}
+
+func (p *oggPacketReader) next() ([]byte, error) {
+	for len(p.pending) == 0 {
+		if err := p.readPage(); err != nil {
+			return nil, err
+		}
+	}
+	pkt := p.pending[0]
+	p.pending = p.pending[1:]
+	return pkt, nil
+}
+
+func (p *oggPacketReader) readPage() error {
+	var hdr [27]byte
+	if _, err := io.ReadFull(p.r, hdr[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("truncated ogg page")
+		}
+		return err
+	}
+	if string(hdr[:4]) != "OggS" {
+		return fmt.Errorf("invalid ogg page signature")
+	}
+
+	lacing := make([]byte, hdr[26])
+	if _, err := io.ReadFull(p.r, lacing); err != nil {
+		return fmt.Errorf("truncated ogg page: %w", err)
+	}
+	size := 0
+	for _, l := range lacing {
+		size += int(l)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(p.r, data); err != nil {
+		return fmt.Errorf("truncated ogg page: %w", err)
+	}
+
+	if hdr[5]&oggHeaderContinued == 0 {
+		p.partial = nil
+	}
+	off := 0
+	for _, l := range lacing {
+		p.partial = append(p.partial, data[off:off+int(l)]...)
+		off += int(l)
+		if l < 255 {
+			p.pending = append(p.pending, p.partial)
+			p.partial = nil
+		}
+	}
+	return nil
+}
+
+// oggWriter writes packets into a single logical Ogg stream.
+type oggWriter struct {
+	w       io.Writer
+	serial  uint32
+	seq     uint32
+	started bool
+
+	lacing  []byte
+	data    []byte
+	granule int64
+	last    int64
+	// continued is set when the pending page opens with the tail of a packet.
+	continued bool
+}
+
+func newOggWriter(w io.Writer, serial uint32) *oggWriter {
+	return &oggWriter{w: w, serial: serial, granule: -1}
+}
+
+// writePacket queues pkt, whose last sample is at granule. If flush is set
+// the page is written out right after pkt, as required for header packets.
+func (o *oggWriter) writePacket(pkt []byte, granule int64, flush bool) error {
+	if len(o.data) > 0 && len(o.data)+len(pkt) > oggMaxPageData {
+		if err := o.flush(false); err != nil {
+			return err
+		}
+	}
+	for {
+		n := min(len(pkt), 255*(255-len(o.lacing)))
+		for i := 0; i < n/255; i++ {
+			o.lacing = append(o.lacing, 255)
+		}
+		o.data = append(o.data, pkt[:n]...)
+		pkt = pkt[n:]
+
+		if len(pkt) == 0 && len(o.lacing) < 255 {
+			o.lacing = append(o.lacing, byte(n%255))
+			o.granule = granule
+			o.last = granule
+			break
+		}
+		if err := o.flush(false); err != nil {
+			return err
+		}
+		o.continued = true
+	}
+	if flush {
+		return o.flush(false)
+	}
+	return nil
+}
+
+// close writes the final page with the end-of-stream flag set.
+func (o *oggWriter) close() error {
+	if len(o.lacing) == 0 {
+		o.granule = o.last
+	}
+	return o.flush(true)
+}
+
+func (o *oggWriter) flush(eos bool) error {
+	if len(o.lacing) == 0 && !eos {
+		return nil
+	}
+
+	var flags byte
+	if o.continued {
+		flags |= oggHeaderContinued
+	}
+	if !o.started {
+		flags |= oggHeaderBOS
+	}
+	if eos {
+		flags |= oggHeaderEOS
+	}
+
+	var page bytes.Buffer
+	page.WriteString("OggS")
+	page.WriteByte(0)
+	page.WriteByte(flags)
+	binary.Write(&page, binary.LittleEndian, o.granule)
+	binary.Write(&page, binary.LittleEndian, o.serial)
+	binary.Write(&page, binary.LittleEndian, o.seq)
+	binary.Write(&page, binary.LittleEndian, uint32(0))
+	page.WriteByte(byte(len(o.lacing)))
+	page.Write(o.lacing)
+	page.Write(o.data)
+
+	b := page.Bytes()
+	binary.LittleEndian.PutUint32(b[22:26], oggCRC(b))
+	if _, err := o.w.Write(b); err != nil {
+		return err
+	}
+
+	o.started = true
+	o.continued = false
+	o.seq++
+	o.granule = -1
+	o.lacing = o.lacing[:0]
+	o.data = o.data[:0]
+	return nil
+}
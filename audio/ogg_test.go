@@ -0,0 +1,154 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+)
+
+// oggPage is a parsed Ogg page.
+type oggPage struct {
+	flags   byte
+	granule int64
+	serial  uint32
+	seq     uint32
+	lacing  []byte
+}
+
+// readOggPages splits b into pages, checking each one's CRC.
+func readOggPages(t *testing.T, b []byte) []oggPage {
+	t.Helper()
+	var pages []oggPage
+	for len(b) > 0 {
+		if len(b) < 27 || string(b[:4]) != "OggS" || b[4] != 0 {
+			t.Fatalf("page %d: invalid header %q", len(pages), b[:min(len(b), 27)])
+		}
+		n := int(b[26])
+		size := 27 + n
+		for _, l := range b[27 : 27+n] {
+			size += int(l)
+		}
+		if size > len(b) {
+			t.Fatalf("page %d: truncated", len(pages))
+		}
+		page := append([]byte(nil), b[:size]...)
+		crc := binary.LittleEndian.Uint32(page[22:])
+		binary.LittleEndian.PutUint32(page[22:], 0)
+		if want := oggCRC(page); crc != want {
+			t.Errorf("page %d: CRC %08x, want %08x", len(pages), crc, want)
+		}
+		pages = append(pages, oggPage{
+			flags:   b[5],
+			granule: int64(binary.LittleEndian.Uint64(b[6:])),
+			serial:  binary.LittleEndian.Uint32(b[14:]),
+			seq:     binary.LittleEndian.Uint32(b[18:]),
+			lacing:  page[27 : 27+n],
+		})
+		b = b[size:]
+	}
+	return pages
+}
+
+// checkOggStream checks that pages form one complete logical stream.
+func checkOggStream(t *testing.T, pages []oggPage) {
+	t.Helper()
+	if len(pages) == 0 {
+		t.Fatal("no pages")
+	}
+	for i, p := range pages {
+		if p.serial != pages[0].serial {
+			t.Errorf("page %d: serial %x, want %x", i, p.serial, pages[0].serial)
+		}
+		if p.seq != uint32(i) {
+			t.Errorf("page %d: sequence number %d", i, p.seq)
+		}
+		if bos := p.flags&oggHeaderBOS != 0; bos != (i == 0) {
+			t.Errorf("page %d: BOS %v", i, bos)
+		}
+		if eos := p.flags&oggHeaderEOS != 0; eos != (i == len(pages)-1) {
+			t.Errorf("page %d: EOS %v", i, eos)
+		}
+		continued := i > 0 && len(pages[i-1].lacing) > 0 && pages[i-1].lacing[len(pages[i-1].lacing)-1] == 255
+		if got := p.flags&oggHeaderContinued != 0; got != continued {
+			t.Errorf("page %d: continued flag %v, want %v", i, got, continued)
+		}
+		ends := false
+		for _, l := range p.lacing {
+			ends = ends || l < 255
+		}
+		if !ends && p.granule != -1 {
+			t.Errorf("page %d: no packet ends on it but granule is %d", i, p.granule)
+		}
+	}
+}
+
+func TestOggCRC(t *testing.T) {
+	if got := oggCRC([]byte("123456789")); got != 0x89a1897f {
+		t.Errorf("oggCRC = %08x, want 89a1897f", got)
+	}
+}
+
+func TestOggWriterRoundTrip(t *testing.T) {
+	var packets [][]byte
+	for i, size := range []int{0, 1, 254, 255, 256, 510, 4000, 4096, 70000, 3} {
+		packets = append(packets, bytes.Repeat([]byte{byte(i + 1)}, size))
+	}
+	var buf bytes.Buffer
+	ow := newOggWriter(&buf, 42)
+	for i, pkt := range packets {
+		if err := ow.writePacket(pkt, int64(i+1)*100, i < 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ow.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	pages := readOggPages(t, buf.Bytes())
+	checkOggStream(t, pages)
+	if pages[0].serial != 42 {
+		t.Errorf("serial = %d, want 42", pages[0].serial)
+	}
+	if last := pages[len(pages)-1]; last.granule != int64(len(packets))*100 {
+		t.Errorf("last granule = %d, want %d", last.granule, len(packets)*100)
+	}
+	// Header packets are flushed onto pages of their own.
+	if len(pages[0].lacing) != 1 || len(pages[1].lacing) != 1 {
+		t.Errorf("first pages have lacing %v and %v, want one packet each", pages[0].lacing, pages[1].lacing)
+	}
+
+	pr := newOggPacketReader(&buf)
+	for i, want := range packets {
+		got, err := pr.next()
+		if err != nil {
+			t.Fatalf("packet %d: %v", i, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("packet %d: %d bytes, want %d", i, len(got), len(want))
+		}
+	}
+	if _, err := pr.next(); !errors.Is(err, io.EOF) {
+		t.Errorf("after the last packet: %v, want EOF", err)
+	}
+}
+
+func TestOggPacketReaderErrors(t *testing.T) {
+	var buf bytes.Buffer
+	ow := newOggWriter(&buf, 1)
+	ow.writePacket(make([]byte, 300), 1, false)
+	ow.close()
+	stream := buf.Bytes()
+
+	tests := map[string][]byte{
+		"bad signature":    append([]byte("OggX"), stream[4:]...),
+		"truncated header": stream[:20],
+		"truncated data":   stream[:len(stream)-10],
+	}
+	for name, b := range tests {
+		if _, err := newOggPacketReader(bytes.NewReader(b)).next(); err == nil || errors.Is(err, io.EOF) {
+			t.Errorf("%s: err = %v, want a format error", name, err)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package audio
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// opusSamples returns the duration of an Opus packet in 48 kHz samples.
+func opusSamples(pkt []byte) int64 {
+	if len(pkt) == 0 {
+		return 0
+	}
+	config := pkt[0] >> 3
+
+	var frame int64
+	switch {
+	case config < 12:
+		frame = [4]int64{480, 960, 1920, 2880}[config%4]
+	case config < 16:
+		frame = [2]int64{480, 960}[config%2]
+	default:
+		frame = [4]int64{120, 240, 480, 960}[config%4]
+	}
+
+	switch pkt[0] & 3 {
+	case 0:
+		return frame
+	case 1, 2:
+		return 2 * frame
+	default:
+		if len(pkt) < 2 {
+			return 0
+		}
+		return int64(pkt[1]&0x3f) * frame
+	}
+}
+
+// oggOpusJoiner remuxes Ogg Opus files into one logical stream, keeping the
+// headers of the first part.
+type oggOpusJoiner struct {
+	ow       *oggWriter
+	tags     Tags
+	granule  int64
+	channels byte
+	started  bool
+}
+
+func newOggOpusJoiner(w io.Writer, tags Tags) *oggOpusJoiner {
+	return &oggOpusJoiner{ow: newOggWriter(w, 0x70696e6b), tags: tags}
+}
+
+func (j *oggOpusJoiner) Append(r io.Reader) error {
+	pr := newOggPacketReader(r)
+
+	head, err := pr.next()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("empty opus stream")
+		}
+		return err
+	}
+	if len(head) < 19 || !bytes.HasPrefix(head, []byte("OpusHead")) {
+		return fmt.Errorf("not an ogg opus stream")
+	}
+	tags, err := pr.next()
+	if err != nil {
+		return fmt.Errorf("missing opus tags: %w", err)
+	}
+
+	if !j.started {
+		j.channels = head[9]
+		if err := j.ow.writePacket(head, 0, true); err != nil {
+			return err
+		}
+		if len(j.tags) > 0 {
+			tags = opusTags(j.tags)
+		}
+		if err := j.ow.writePacket(tags, 0, true); err != nil {
+			return err
+		}
+		j.started = true
+	} else if head[9] != j.channels {
+		return fmt.Errorf("cannot join opus streams with %d and %d channels", j.channels, head[9])
+	}
+
+	for {
+		pkt, err := pr.next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		j.granule += opusSamples(pkt)
+		if err := j.ow.writePacket(pkt, j.granule, false); err != nil {
+			return err
+		}
+	}
+}
+
+func (j *oggOpusJoiner) AppendSilence(d time.Duration) error {
+	if !j.started {
+		j.channels = 1
+		if err := writeOpusHeaders(j.ow, j.channels, j.tags); err != nil {
+			return err
+		}
+		j.started = true
+	}
+	frame := opusSilentFrame
+	if j.channels == 2 {
+		frame = []byte{opusSilentFrame[0] | 0x04, opusSilentFrame[1], opusSilentFrame[2]}
+	}
+	for n := frameCount(d, 48000, opusFrameSamples); n > 0; n-- {
+		j.granule += opusFrameSamples
+		if err := j.ow.writePacket(frame, j.granule, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (j *oggOpusJoiner) Close() error {
+	if !j.started {
+		return nil
+	}
+	return j.ow.close()
+}
@@ -0,0 +1,180 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+func TestOpusSamples(t *testing.T) {
+	tests := []struct {
+		name string
+		pkt  []byte
+		want int64
+	}{
+		{"empty", nil, 0},
+		{"SILK 10ms", []byte{0x00}, 480},
+		{"SILK 60ms", []byte{0x18}, 2880},
+		{"hybrid 10ms", []byte{0x60}, 480},
+		{"hybrid 20ms", []byte{0x68}, 960},
+		{"CELT 2.5ms", []byte{0x80}, 120},
+		{"CELT 20ms", []byte{0xf8}, 960},
+		{"two equal frames", []byte{0xf9}, 1920},
+		{"two frames", []byte{0xfa}, 1920},
+		{"frame count", []byte{0xfb, 0x03}, 2880},
+		{"frame count with flags", []byte{0xfb, 0xc3}, 2880},
+		{"missing frame count", []byte{0xfb}, 0},
+	}
+	for _, tt := range tests {
+		if got := opusSamples(tt.pkt); got != tt.want {
+			t.Errorf("%s: opusSamples = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+// opusHead returns an OpusHead packet for channels.
+func opusHead(channels byte) []byte {
+	head := append([]byte("OpusHead"), 1, channels)
+	head = binary.LittleEndian.AppendUint16(head, 312)
+	head = binary.LittleEndian.AppendUint32(head, 24000)
+	return append(head, 0, 0, 0)
+}
+
+// opusStream returns an Ogg Opus file holding packets. Its granule positions
+// start far from zero, as in a stream cut from a longer one.
+func opusStream(t *testing.T, channels byte, vendor string, packets ...[]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	ow := newOggWriter(&buf, 7)
+	tags := binary.LittleEndian.AppendUint32([]byte("OpusTags"), uint32(len(vendor)))
+	tags = binary.LittleEndian.AppendUint32(append(tags, vendor...), 0)
+	if err := ow.writePacket(opusHead(channels), 0, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := ow.writePacket(tags, 0, true); err != nil {
+		t.Fatal(err)
+	}
+	g := int64(1 << 20)
+	for _, pkt := range packets {
+		g += opusSamples(pkt)
+		if err := ow.writePacket(pkt, g, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ow.close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// opusPacket returns a packet with TOC byte toc, large enough to fill a page
+// on its own.
+func opusPacket(toc byte) []byte {
+	return append([]byte{toc}, bytes.Repeat([]byte{toc}, 2999)...)
+}
+
+// readPackets returns every packet in an Ogg stream.
+func readPackets(t *testing.T, b []byte) [][]byte {
+	t.Helper()
+	pr := newOggPacketReader(bytes.NewReader(b))
+	var packets [][]byte
+	for {
+		pkt, err := pr.next()
+		if err != nil {
+			return packets
+		}
+		packets = append(packets, pkt)
+	}
+}
+
+func TestOggOpusJoiner(t *testing.T) {
+	parts := [][][]byte{
+		{opusPacket(0xf8), opusPacket(0x00), opusPacket(0xf9)},
+		{opusPacket(0x18)},
+		{opusPacket(0xfb), opusPacket(0x80)},
+	}
+	var buf bytes.Buffer
+	j, err := NewJoiner(&buf, Format{Codec: Opus, SampleRate: 48000, Bitrate: 64}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var first []byte
+	for i, packets := range parts {
+		part := opusStream(t, 1, "part"+string(rune('A'+i)), packets...)
+		if first == nil {
+			first = part
+		}
+		if err := j.Append(bytes.NewReader(part)); err != nil {
+			t.Fatalf("part %d: %v", i, err)
+		}
+	}
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	pages := readOggPages(t, buf.Bytes())
+	checkOggStream(t, pages)
+	if pages[0].serial != 0x70696e6b {
+		t.Errorf("serial = %x", pages[0].serial)
+	}
+
+	got := readPackets(t, buf.Bytes())
+	want := readPackets(t, first)[:2]
+	for _, packets := range parts {
+		want = append(want, packets...)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d packets, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !bytes.Equal(got[i], want[i]) {
+			t.Errorf("packet %d differs", i)
+		}
+	}
+
+	// Each audio packet fills a page, so every page after the headers
+	// carries the running sample count.
+	var g int64
+	for i, pkt := range want[2:] {
+		g += opusSamples(pkt)
+		if p := pages[2+i]; p.granule != g {
+			t.Errorf("page %d: granule %d, want %d", 2+i, p.granule, g)
+		}
+	}
+}
+
+func TestOggOpusJoinerErrors(t *testing.T) {
+	mono := opusStream(t, 1, "a", opusPacket(0xf8))
+	var vorbis bytes.Buffer
+	ow := newOggWriter(&vorbis, 1)
+	ow.writePacket([]byte("\x01vorbis000000000000000000"), 0, true)
+	ow.close()
+	var headOnly bytes.Buffer
+	ow = newOggWriter(&headOnly, 1)
+	ow.writePacket(opusHead(1), 0, true)
+	ow.close()
+
+	tests := []struct {
+		name  string
+		parts [][]byte
+		want  string
+	}{
+		{"empty", [][]byte{nil}, "empty opus stream"},
+		{"not opus", [][]byte{vorbis.Bytes()}, "not an ogg opus stream"},
+		{"missing tags", [][]byte{headOnly.Bytes()}, "missing opus tags"},
+		{"channels", [][]byte{mono, opusStream(t, 2, "b", opusPacket(0xfc))}, "1 and 2 channels"},
+	}
+	for _, tt := range tests {
+		j := newOggOpusJoiner(&bytes.Buffer{}, nil)
+		var err error
+		for _, part := range tt.parts {
+			if err = j.Append(bytes.NewReader(part)); err != nil {
+				break
+			}
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
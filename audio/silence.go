@@ -0,0 +1,197 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// opusSilentFrame is a 20 ms CELT frame with the silence flag set.
+var opusSilentFrame = []byte{0xf8, 0xff, 0xfe}
+
+const opusFrameSamples = 960
+
+// WriteSilence writes a standalone stream of d of silence in format f.
+func WriteSilence(w io.Writer, f Format, d time.Duration) error {
+	switch f.Codec {
+	case PCM:
+		return writeZeros(w, pcmBytes(f.SampleRate, d))
+	case ULaw, ALaw:
+		// Silence is 0xff in mu-law and 0xd5 in A-law, one byte per sample.
+		b := byte(0xff)
+		if f.Codec == ALaw {
+			b = 0xd5
+		}
+		n := int64(math.Round(d.Seconds() * float64(f.SampleRate)))
+		_, err := io.CopyN(w, byteReader(b), n)
+		return err
+	case MP3:
+		h, err := newMP3Header(f.SampleRate, f.Bitrate, true)
+		if err != nil {
+			return err
+		}
+		return h.writeSilence(w, d)
+	case Opus:
+		ow := newOggWriter(w, 0x73696c65)
+		if err := writeOpusHeaders(ow, 1, nil); err != nil {
+			return err
+		}
+		samples := int64(math.Round(d.Seconds() * 48000))
+		var g int64
+		for g < samples {
+			g += opusFrameSamples
+			if err := ow.writePacket(opusSilentFrame, min(g, samples), false); err != nil {
+				return err
+			}
+		}
+		return ow.close()
+	default:
+		return fmt.Errorf("cannot generate %s silence", f.Codec)
+	}
+}
+
+func writeOpusHeaders(ow *oggWriter, channels byte, tags Tags) error {
+	head := []byte("OpusHead")
+	head = append(head, 1, channels)
+	head = binary.LittleEndian.AppendUint16(head, 0)     // pre-skip
+	head = binary.LittleEndian.AppendUint32(head, 48000) // input rate
+	head = append(head, 0, 0, 0)                         // gain, mapping family
+	if err := ow.writePacket(head, 0, true); err != nil {
+		return err
+	}
+
+	return ow.writePacket(opusTags(tags), 0, true)
+}
+
+func pcmBytes(rate int, d time.Duration) int64 {
+	return int64(math.Round(d.Seconds()*float64(rate))) * 2
+}
+
+// frameCount returns how many frames of frameSamples cover d, rounding d to
+// whole samples first so that exact multiples don't gain a frame.
+func frameCount(d time.Duration, rate, frameSamples int) int64 {
+	samples := int64(math.Round(d.Seconds() * float64(rate)))
+	return (samples + int64(frameSamples) - 1) / int64(frameSamples)
+}
+
+func writeZeros(w io.Writer, n int64) error {
+	_, err := io.CopyN(w, byteReader(0), n)
+	return err
+}
+
+// byteReader is an endless stream of one byte value.
+type byteReader byte
+
+func (r byteReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(r)
+	}
+	return len(p), nil
+}
+
+// mp3Header describes the Layer III frames to emit. A frame consisting of a
+// header followed by zeros has empty side info and decodes to silence.
+type mp3Header struct {
+	b          [4]byte
+	sampleRate int
+	bitrate    int
+	// coef is the frame size multiplier: 144 for MPEG-1, 72 otherwise.
+	coef         int
+	frameSamples int
+}
+
+var (
+	mp3Rates = map[int]struct{ version, index byte }{
+		44100: {3, 0}, 48000: {3, 1}, 32000: {3, 2},
+		22050: {2, 0}, 24000: {2, 1}, 16000: {2, 2},
+		11025: {0, 0}, 12000: {0, 1}, 8000: {0, 2},
+	}
+	mp3BitratesV1 = []int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320}
+	mp3BitratesV2 = []int{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160}
+)
+
+func newMP3Header(sampleRate, bitrate int, mono bool) (*mp3Header, error) {
+	rate, ok := mp3Rates[sampleRate]
+	if !ok {
+		return nil, fmt.Errorf("unsupported mp3 sample rate: %d", sampleRate)
+	}
+	table := mp3BitratesV2
+	h := &mp3Header{sampleRate: sampleRate, bitrate: bitrate, coef: 72, frameSamples: 576}
+	if rate.version == 3 {
+		table = mp3BitratesV1
+		h.coef = 144
+		h.frameSamples = 1152
+	}
+	brIndex := -1
+	for i, br := range table {
+		if br == bitrate && i > 0 {
+			brIndex = i
+		}
+	}
+	if brIndex < 0 {
+		return nil, fmt.Errorf("unsupported mp3 bitrate %d kbps at %d Hz", bitrate, sampleRate)
+	}
+
+	h.b[0] = 0xff
+	h.b[1] = 0xe0 | rate.version<<3 | 1<<1 | 1 // layer III, no CRC
+	h.b[2] = byte(brIndex)<<4 | rate.index<<2
+	if mono {
+		h.b[3] = 0xc0
+	}
+	return h, nil
+}
+
+// parseMP3Header reads the parameters of a Layer III frame header.
+func parseMP3Header(b []byte) (*mp3Header, bool) {
+	if len(b) < 4 || b[0] != 0xff || b[1]&0xe0 != 0xe0 || (b[1]>>1)&3 != 1 {
+		return nil, false
+	}
+	version := (b[1] >> 3) & 3
+	srIndex := (b[2] >> 2) & 3
+	brIndex := int(b[2] >> 4)
+	if version == 1 || srIndex == 3 || brIndex == 0 || brIndex == 15 {
+		return nil, false
+	}
+	for rate, v := range mp3Rates {
+		if v.version == version && v.index == srIndex {
+			table := mp3BitratesV2
+			if version == 3 {
+				table = mp3BitratesV1
+			}
+			h, err := newMP3Header(rate, table[brIndex], b[3]>>6 == 3)
+			if err != nil {
+				return nil, false
+			}
+			h.b[3] = b[3] &^ 0x0f // keep channel mode, drop copyright/emphasis
+			return h, true
+		}
+	}
+	return nil, false
+}
+
+func (h *mp3Header) writeSilence(w io.Writer, d time.Duration) error {
+	frames := int(frameCount(d, h.sampleRate, h.frameSamples))
+	// Spread padding bytes like an encoder would, so the stream keeps its
+	// nominal bitrate.
+	num := h.coef * h.bitrate * 1000
+	size := num / h.sampleRate
+	rem, acc := num%h.sampleRate, 0
+
+	frame := make([]byte, size+1)
+	for i := 0; i < frames; i++ {
+		copy(frame, h.b[:])
+		n := size
+		acc += rem
+		if acc >= h.sampleRate {
+			acc -= h.sampleRate
+			frame[2] |= 0x02
+			n++
+		}
+		if _, err := w.Write(frame[:n]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package audio
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteSilenceSamples(t *testing.T) {
+	tests := []struct {
+		format string
+		d      time.Duration
+		size   int
+		value  byte
+	}{
+		{"pcm_16000", time.Second, 32000, 0},
+		{"pcm_44100", 10 * time.Millisecond, 882, 0},
+		{"ulaw_8000", 250 * time.Millisecond, 2000, 0xff},
+		{"alaw_8000", 250 * time.Millisecond, 2000, 0xd5},
+		{"pcm_24000", 0, 0, 0},
+	}
+	for _, tt := range tests {
+		f, _ := ParseFormat(tt.format)
+		var buf bytes.Buffer
+		if err := WriteSilence(&buf, f, tt.d); err != nil {
+			t.Fatalf("%s: %v", tt.format, err)
+		}
+		if buf.Len() != tt.size {
+			t.Errorf("%s: %d bytes, want %d", tt.format, buf.Len(), tt.size)
+		}
+		if strings.Trim(buf.String(), string([]byte{tt.value})) != "" {
+			t.Errorf("%s: silence holds bytes other than %#x", tt.format, tt.value)
+		}
+	}
+}
+
+func TestWriteSilenceMP3(t *testing.T) {
+	tests := []struct {
+		format string
+		d      time.Duration
+		frames int
+	}{
+		{"mp3_44100_128", time.Second, 39},
+		{"mp3_44100_32", 26 * time.Millisecond, 1},
+		{"mp3_48000_192", 2400 * time.Millisecond, 100},
+		{"mp3_22050_32", time.Second, 39},
+		{"mp3_24000_48", 24 * time.Millisecond, 1},
+		{"mp3_8000_8", 500 * time.Millisecond, 7},
+	}
+	for _, tt := range tests {
+		f, _ := ParseFormat(tt.format)
+		var buf bytes.Buffer
+		if err := WriteSilence(&buf, f, tt.d); err != nil {
+			t.Fatalf("%s: %v", tt.format, err)
+		}
+		frames := mp3Frames(t, buf.Bytes())
+		if len(frames) != tt.frames {
+			t.Errorf("%s: %d frames, want %d", tt.format, len(frames), tt.frames)
+			continue
+		}
+		h := frames[0]
+		if h.sampleRate != f.SampleRate || h.bitrate != f.Bitrate || h.b[3]>>6 != 3 {
+			t.Errorf("%s: frame is %d Hz %d kbps mode %d, want mono at the format's rate", tt.format, h.sampleRate, h.bitrate, h.b[3]>>6)
+		}
+		// Padding is spread so the stream keeps its nominal bitrate.
+		nominal := float64(len(frames)*h.coef*h.bitrate*1000) / float64(h.sampleRate)
+		if math.Abs(float64(buf.Len())-nominal) >= 1 {
+			t.Errorf("%s: %d bytes, want %.1f at the nominal bitrate", tt.format, buf.Len(), nominal)
+		}
+	}
+}
+
+func TestWriteSilenceOpus(t *testing.T) {
+	for _, d := range []time.Duration{20 * time.Millisecond, 250 * time.Millisecond, time.Second, 1234 * time.Millisecond} {
+		var buf bytes.Buffer
+		if err := WriteSilence(&buf, Format{Codec: Opus, SampleRate: 48000, Bitrate: 64}, d); err != nil {
+			t.Fatal(err)
+		}
+		pages := readOggPages(t, buf.Bytes())
+		checkOggStream(t, pages)
+		if want := int64(math.Round(d.Seconds() * 48000)); pages[len(pages)-1].granule != want {
+			t.Errorf("%v: last granule %d, want %d", d, pages[len(pages)-1].granule, want)
+		}
+
+		packets := readPackets(t, buf.Bytes())
+		if !bytes.HasPrefix(packets[0], []byte("OpusHead")) || packets[0][9] != 1 || !bytes.HasPrefix(packets[1], []byte("OpusTags")) {
+			t.Errorf("%v: headers %q %q", d, packets[0], packets[1])
+		}
+		if n, want := len(packets)-2, int(frameCount(d, 48000, opusFrameSamples)); n != want {
+			t.Errorf("%v: %d frames, want %d", d, n, want)
+		}
+		for _, pkt := range packets[2:] {
+			if !bytes.Equal(pkt, opusSilentFrame) {
+				t.Fatalf("%v: frame %x is not silent", d, pkt)
+			}
+		}
+	}
+}
+
+func TestWriteSilenceErrors(t *testing.T) {
+	for _, format := range []string{"mp3_44100_100", "mp3_11000_32", "mp3_22050_320", "flac_44100"} {
+		f, _ := ParseFormat(format)
+		if err := WriteSilence(&bytes.Buffer{}, f, time.Second); err == nil {
+			t.Errorf("%s: wrote silence in an unsupported format", format)
+		}
+	}
+}
+
+func TestMP3HeaderRoundTrip(t *testing.T) {
+	for rate := range mp3Rates {
+		table := mp3BitratesV2
+		if rate >= 32000 {
+			table = mp3BitratesV1
+		}
+		for _, br := range table[1:] {
+			for _, mono := range []bool{true, false} {
+				h, err := newMP3Header(rate, br, mono)
+				if err != nil {
+					t.Fatalf("%d Hz %d kbps: %v", rate, br, err)
+				}
+				got, ok := parseMP3Header(h.b[:])
+				if !ok || got.b != h.b || got.sampleRate != rate || got.bitrate != br {
+					t.Errorf("%d Hz %d kbps mono=%v: %x parsed as %+v", rate, br, mono, h.b, got)
+				}
+			}
+		}
+	}
+}
+
+func TestParseMP3HeaderInvalid(t *testing.T) {
+	for name, b := range map[string][]byte{
+		"short":             {0xff, 0xfb, 0x90},
+		"no sync":           {0xfe, 0xfb, 0x90, 0xc0},
+		"layer II":          {0xff, 0xfd, 0x90, 0xc0},
+		"reserved version":  {0xff, 0xeb, 0x90, 0xc0},
+		"reserved rate":     {0xff, 0xfb, 0x9c, 0xc0},
+		"free bitrate":      {0xff, 0xfb, 0x00, 0xc0},
+		"bad bitrate":       {0xff, 0xfb, 0xf0, 0xc0},
+		"ID3v1 tag trailer": []byte("TAG!"),
+	} {
+		if h, ok := parseMP3Header(b); ok {
+			t.Errorf("%s: parsed %x as %+v", name, b, h)
+		}
+	}
+}
+
+func TestJoinerAppendSilence(t *testing.T) {
+	// Silence after real audio copies its header, here 24 kHz joint stereo.
+	part := []byte{0xff, 0xf3, 0x64, 0x44}
+	part = append(part, make([]byte, 72*48000/24000-4)...)
+	var buf bytes.Buffer
+	j, _ := NewJoiner(&buf, Format{Codec: MP3, SampleRate: 44100, Bitrate: 128}, nil)
+	if err := j.Append(bytes.NewReader(part)); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.AppendSilence(100 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	frames := mp3Frames(t, buf.Bytes())
+	if len(frames) != 6 {
+		t.Fatalf("got %d frames, want 1 + 5 of silence", len(frames))
+	}
+	for _, h := range frames[1:] {
+		if h.b != [4]byte{0xff, 0xf3, 0x64, 0x40} {
+			t.Errorf("silence header %x does not match the audio", h.b)
+		}
+	}
+
+	// Silence before any audio starts an Opus stream of its own.
+	buf.Reset()
+	j, _ = NewJoiner(&buf, Format{Codec: Opus, SampleRate: 48000, Bitrate: 64}, nil)
+	if err := j.AppendSilence(60 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+	checkOggStream(t, readOggPages(t, buf.Bytes()))
+	if packets := readPackets(t, buf.Bytes()); len(packets) != 5 || packets[0][9] != 1 {
+		t.Errorf("got %d packets, want mono headers and 3 frames", len(packets))
+	}
+
+	// Stereo silence sets the stereo bit of the TOC byte.
+	buf.Reset()
+	j, _ = NewJoiner(&buf, Format{Codec: Opus, SampleRate: 48000, Bitrate: 64}, nil)
+	if err := j.Append(bytes.NewReader(opusStream(t, 2, "a", opusPacket(0xfc)))); err != nil {
+		t.Fatal(err)
+	}
+	j.AppendSilence(20 * time.Millisecond)
+	j.Close()
+	packets := readPackets(t, buf.Bytes())
+	if last := packets[len(packets)-1]; !bytes.Equal(last, []byte{0xfc, 0xff, 0xfe}) {
+		t.Errorf("stereo silence frame %x", last)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Format
+	}{
+		{"mp3_44100_128", Format{MP3, 44100, 128}},
+		{"opus_48000_64", Format{Opus, 48000, 64}},
+		{"pcm_24000", Format{PCM, 24000, 0}},
+		{"ulaw_8000", Format{ULaw, 8000, 0}},
+	}
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseFormat(%q) = %+v, %v; want %+v", tt.in, got, err, tt.want)
+		}
+		if got.String() != tt.in {
+			t.Errorf("%+v.String() = %q, want %q", got, got.String(), tt.in)
+		}
+	}
+	for _, in := range []string{"", "mp3", "mp3_x_128", "mp3_44100_x", "mp3_44100_128_1"} {
+		if f, err := ParseFormat(in); err == nil {
+			t.Errorf("ParseFormat(%q) = %+v, want an error", in, f)
+		}
+	}
+}
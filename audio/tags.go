@@ -0,0 +1,91 @@
+package audio
+
+import (
+	"encoding/binary"
+	"sort"
+	"strings"
+)
+
+// Tags is file metadata such as title and artist, with case-insensitive keys.
+type Tags map[string]string
+
+// vendor identifies this package in Opus headers it writes.
+const vendor = "pink-elevenlabs"
+
+var vorbisNames = map[string]string{
+	"year":  "DATE",
+	"track": "TRACKNUMBER",
+}
+
+var id3Frames = map[string]string{
+	"title":   "TIT2",
+	"artist":  "TPE1",
+	"album":   "TALB",
+	"date":    "TDRC",
+	"year":    "TDRC",
+	"genre":   "TCON",
+	"track":   "TRCK",
+	"comment": "COMM",
+}
+
+func (t Tags) keys() []string {
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// opusTags builds an OpusTags header packet.
+func opusTags(t Tags) []byte {
+	pkt := []byte("OpusTags")
+	pkt = binary.LittleEndian.AppendUint32(pkt, uint32(len(vendor)))
+	pkt = append(pkt, vendor...)
+	pkt = binary.LittleEndian.AppendUint32(pkt, uint32(len(t)))
+	for _, k := range t.keys() {
+		name, ok := vorbisNames[strings.ToLower(k)]
+		if !ok {
+			name = strings.ToUpper(k)
+		}
+		comment := name + "=" + t[k]
+		pkt = binary.LittleEndian.AppendUint32(pkt, uint32(len(comment)))
+		pkt = append(pkt, comment...)
+	}
+	return pkt
+}
+
+// id3v2 builds an ID3v2.4 tag with UTF-8 text frames.
+func id3v2(t Tags) []byte {
+	var frames []byte
+	for _, k := range t.keys() {
+		id, ok := id3Frames[strings.ToLower(k)]
+		var body []byte
+		switch {
+		case !ok:
+			// TXXX: encoding, description, value.
+			id = "TXXX"
+			body = append([]byte{3}, k...)
+			body = append(body, 0)
+			body = append(body, t[k]...)
+		case id == "COMM":
+			// COMM: encoding, language, empty description, text.
+			body = append([]byte{3}, "eng\x00"...)
+			body = append(body, t[k]...)
+		default:
+			body = append([]byte{3}, t[k]...)
+		}
+		frames = append(frames, id...)
+		frames = appendSynchsafe(frames, len(body))
+		frames = append(frames, 0, 0)
+		frames = append(frames, body...)
+	}
+
+	tag := []byte{'I', 'D', '3', 4, 0, 0}
+	tag = appendSynchsafe(tag, len(frames))
+	return append(tag, frames...)
+}
+
+func appendSynchsafe(b []byte, n int) []byte {
+	return append(b, byte(n>>21)&0x7f, byte(n>>14)&0x7f, byte(n>>7)&0x7f, byte(n)&0x7f)
+}
@@ -0,0 +1,73 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestID3v2(t *testing.T) {
+	got := id3v2(Tags{"Title": "Hi", "comment": "c", "mood": "calm"})
+	frames := []byte("TIT2\x00\x00\x00\x03\x00\x00\x03Hi")
+	frames = append(frames, "COMM\x00\x00\x00\x06\x00\x00\x03eng\x00c"...)
+	frames = append(frames, "TXXX\x00\x00\x00\x0a\x00\x00\x03mood\x00calm"...)
+	want := append([]byte("ID3\x04\x00\x00\x00\x00\x00"), byte(len(frames)))
+	want = append(want, frames...)
+	if !bytes.Equal(got, want) {
+		t.Errorf("id3v2 =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestID3v2Synchsafe(t *testing.T) {
+	tag := id3v2(Tags{"title": string(bytes.Repeat([]byte("a"), 300))})
+	size := int(tag[6])<<21 | int(tag[7])<<14 | int(tag[8])<<7 | int(tag[9])
+	if size != len(tag)-10 {
+		t.Errorf("tag size %d, want %d", size, len(tag)-10)
+	}
+	// 311 bytes of frame is 2<<7 | 55.
+	if tag[8] != 2 || tag[9] != 55 {
+		t.Errorf("size bytes %x are not synchsafe", tag[6:10])
+	}
+}
+
+func TestOpusTags(t *testing.T) {
+	got := opusTags(Tags{"title": "Hi", "year": "2026"})
+	want := binary.LittleEndian.AppendUint32([]byte("OpusTags"), uint32(len(vendor)))
+	want = append(want, vendor...)
+	want = binary.LittleEndian.AppendUint32(want, 2)
+	for _, c := range []string{"TITLE=Hi", "DATE=2026"} {
+		want = binary.LittleEndian.AppendUint32(want, uint32(len(c)))
+		want = append(want, c...)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("opusTags =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestJoinerTags(t *testing.T) {
+	tags := Tags{"title": "Joined"}
+
+	var buf bytes.Buffer
+	j, _ := NewJoiner(&buf, Format{Codec: MP3, SampleRate: 44100, Bitrate: 128}, tags)
+	for _, part := range [][]byte{append(id3Tag(20, false), mp3Part(t, 2)...), append(id3Tag(40, true), mp3Part(t, 1)...)} {
+		if err := j.Append(bytes.NewReader(part)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := append(id3v2(tags), mp3Part(t, 2)...)
+	want = append(want, mp3Part(t, 1)...)
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Error("mp3: the parts' tags were not replaced by one tag at the start")
+	}
+
+	buf.Reset()
+	j, _ = NewJoiner(&buf, Format{Codec: Opus, SampleRate: 48000, Bitrate: 64}, tags)
+	if err := j.Append(bytes.NewReader(opusStream(t, 1, "api", opusPacket(0xf8)))); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+	checkOggStream(t, readOggPages(t, buf.Bytes()))
+	if packets := readPackets(t, buf.Bytes()); !bytes.Equal(packets[1], opusTags(tags)) {
+		t.Errorf("opus: tags packet %q", packets[1])
+	}
+}
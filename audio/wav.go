@@ -0,0 +1,149 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// WAV describes the 16-bit PCM sample data of a WAV file.
+type WAV struct {
+	Channels   int
+	SampleRate int
+	// DataOffset and DataSize locate the interleaved samples in the file.
+	DataOffset int64
+	DataSize   int64
+}
+
+// FrameBytes is the size of one sample for every channel.
+func (w *WAV) FrameBytes() int {
+	return w.Channels * 2
+}
+
+// Duration is the playing time of the sample data.
+func (w *WAV) Duration() time.Duration {
+	return samplesDuration(w.DataSize/int64(w.FrameBytes()), w.SampleRate)
+}
+
+// ReadWAVHeader reads the chunks of a WAV file up to the start of its
+// sample data, which must be 16-bit PCM.
+func ReadWAVHeader(r io.Reader) (*WAV, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil || string(riff[:4]) != "RIFF" || string(riff[8:]) != "WAVE" {
+		return nil, fmt.Errorf("not a WAV file")
+	}
+	offset := int64(len(riff))
+
+	var w *WAV
+	for {
+		var hdr [8]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil, fmt.Errorf("WAV file has no data chunk")
+			}
+			return nil, err
+		}
+		offset += int64(len(hdr))
+		id, size := string(hdr[:4]), int64(binary.LittleEndian.Uint32(hdr[4:]))
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, fmt.Errorf("invalid WAV format chunk")
+			}
+			b := make([]byte, size)
+			if _, err := io.ReadFull(r, b); err != nil {
+				return nil, fmt.Errorf("truncated WAV format chunk")
+			}
+			tag := binary.LittleEndian.Uint16(b)
+			// WAVE_FORMAT_EXTENSIBLE carries the real tag in its sub-format.
+			if tag == 0xfffe && size >= 26 {
+				tag = binary.LittleEndian.Uint16(b[24:])
+			}
+			bits := binary.LittleEndian.Uint16(b[14:])
+			if tag != 1 || bits != 16 {
+				return nil, fmt.Errorf("unsupported WAV encoding (need 16-bit PCM, have format %d with %d bits)", tag, bits)
+			}
+			w = &WAV{
+				Channels:   int(binary.LittleEndian.Uint16(b[2:])),
+				SampleRate: int(binary.LittleEndian.Uint32(b[4:])),
+			}
+			if w.Channels == 0 {
+				return nil, fmt.Errorf("invalid WAV channel count")
+			}
+		case "data":
+			if w == nil {
+				return nil, fmt.Errorf("WAV data chunk before format chunk")
+			}
+			w.DataOffset, w.DataSize = offset, size
+			return w, nil
+		default:
+			if _, err := io.CopyN(io.Discard, r, size); err != nil {
+				return nil, fmt.Errorf("truncated WAV chunk %q", id)
+			}
+		}
+		offset += size
+		// Chunks are padded to an even size.
+		if size%2 == 1 {
+			if _, err := io.CopyN(io.Discard, r, 1); err != nil {
+				return nil, fmt.Errorf("truncated WAV chunk %q", id)
+			}
+			offset++
+		}
+	}
+}
+
+// WriteWAVHeader writes the header of a 16-bit PCM WAV file whose
+// dataSize bytes of samples follow.
+func WriteWAVHeader(w io.Writer, channels, sampleRate int, dataSize int64) error {
+	b := make([]byte, 0, 44)
+	b = append(b, "RIFF"...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(36+dataSize))
+	b = append(b, "WAVEfmt "...)
+	b = binary.LittleEndian.AppendUint32(b, 16)
+	b = binary.LittleEndian.AppendUint16(b, 1)
+	b = binary.LittleEndian.AppendUint16(b, uint16(channels))
+	b = binary.LittleEndian.AppendUint32(b, uint32(sampleRate))
+	b = binary.LittleEndian.AppendUint32(b, uint32(sampleRate*channels*2))
+	b = binary.LittleEndian.AppendUint16(b, uint16(channels*2))
+	b = binary.LittleEndian.AppendUint16(b, 16)
+	b = append(b, "data"...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(dataSize))
+	_, err := w.Write(b)
+	return err
+}
+
+// ResampleMono converts mono 16-bit PCM from one sample rate to another by
+// linear interpolation and copies it to every one of channels.
+func ResampleMono(pcm []byte, fromRate, toRate, channels int) []byte {
+	in := len(pcm) / 2
+	sample := func(i int) float64 {
+		return float64(int16(binary.LittleEndian.Uint16(pcm[2*i:])))
+	}
+	n := in
+	if fromRate != toRate && fromRate > 0 {
+		n = int(int64(in) * int64(toRate) / int64(fromRate))
+	}
+
+	out := make([]byte, 0, n*channels*2)
+	for i := 0; i < n; i++ {
+		var v float64
+		if n == in {
+			v = sample(i)
+		} else {
+			pos := float64(i) * float64(fromRate) / float64(toRate)
+			j := int(pos)
+			v = sample(j)
+			if j+1 < in {
+				v += (sample(j+1) - v) * (pos - float64(j))
+			}
+		}
+		s := uint16(int16(v))
+		for c := 0; c < channels; c++ {
+			out = binary.LittleEndian.AppendUint16(out, s)
+		}
+	}
+	return out
+}
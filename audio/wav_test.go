@@ -0,0 +1,164 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+)
+
+// chunk returns a RIFF chunk, padded to an even size.
+func chunk(id string, body []byte) []byte {
+	b := binary.LittleEndian.AppendUint32([]byte(id), uint32(len(body)))
+	b = append(b, body...)
+	if len(body)%2 == 1 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// fmtChunk returns the body of a format chunk, in the WAVE_FORMAT_EXTENSIBLE
+// layout if extensible is set.
+func fmtChunk(tag uint16, channels, rate int, bits uint16, extensible bool) []byte {
+	b := binary.LittleEndian.AppendUint16(nil, tag)
+	b = binary.LittleEndian.AppendUint16(b, uint16(channels))
+	b = binary.LittleEndian.AppendUint32(b, uint32(rate))
+	b = binary.LittleEndian.AppendUint32(b, uint32(rate*channels*int(bits)/8))
+	b = binary.LittleEndian.AppendUint16(b, uint16(channels*int(bits)/8))
+	b = binary.LittleEndian.AppendUint16(b, bits)
+	if extensible {
+		b = binary.LittleEndian.AppendUint16(b, 22) // extension size
+		b = binary.LittleEndian.AppendUint16(b, bits)
+		b = binary.LittleEndian.AppendUint32(b, 3) // channel mask
+		b = binary.LittleEndian.AppendUint16(b, 1) // KSDATAFORMAT_SUBTYPE_PCM
+		b = append(b, "\x00\x00\x00\x00\x10\x00\x80\x00\x00\xaa\x00\x38\x9b\x71"...)
+	}
+	return b
+}
+
+func riff(chunks ...[]byte) []byte {
+	body := []byte("WAVE")
+	for _, c := range chunks {
+		body = append(body, c...)
+	}
+	return chunk("RIFF", body)
+}
+
+func TestWAVHeaderRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteWAVHeader(&buf, 2, 22050, 88200); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 44 {
+		t.Fatalf("header is %d bytes, want 44", buf.Len())
+	}
+	want := riff(chunk("fmt ", fmtChunk(1, 2, 22050, 16, false)))
+	want = append(want, binary.LittleEndian.AppendUint32([]byte("data"), 88200)...)
+	binary.LittleEndian.PutUint32(want[4:], 36+88200)
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("WriteWAVHeader =\n%x\nwant\n%x", buf.Bytes(), want)
+	}
+
+	w, err := ReadWAVHeader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *w != (WAV{Channels: 2, SampleRate: 22050, DataOffset: 44, DataSize: 88200}) {
+		t.Errorf("ReadWAVHeader = %+v", *w)
+	}
+	if w.FrameBytes() != 4 || w.Duration() != time.Second {
+		t.Errorf("frame %d bytes, duration %v", w.FrameBytes(), w.Duration())
+	}
+}
+
+func TestReadWAVHeader(t *testing.T) {
+	data := chunk("data", make([]byte, 3200))
+	tests := []struct {
+		name string
+		in   []byte
+		want WAV
+	}{
+		{
+			"odd chunk before data",
+			riff(chunk("LIST", []byte("INFOx")), chunk("fmt ", fmtChunk(1, 1, 16000, 16, false)), data),
+			WAV{Channels: 1, SampleRate: 16000, DataOffset: 12 + 14 + 24 + 8, DataSize: 3200},
+		},
+		{
+			"extensible",
+			riff(chunk("fmt ", fmtChunk(0xfffe, 2, 48000, 16, true)), data),
+			WAV{Channels: 2, SampleRate: 48000, DataOffset: 12 + 48 + 8, DataSize: 3200},
+		},
+	}
+	for _, tt := range tests {
+		w, err := ReadWAVHeader(bytes.NewReader(tt.in))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if *w != tt.want {
+			t.Errorf("%s: ReadWAVHeader = %+v, want %+v", tt.name, *w, tt.want)
+		}
+		if got := string(tt.in[w.DataOffset-8 : w.DataOffset-4]); got != "data" {
+			t.Errorf("%s: data offset %d points after %q", tt.name, w.DataOffset, got)
+		}
+	}
+}
+
+func TestReadWAVHeaderErrors(t *testing.T) {
+	pcm := chunk("fmt ", fmtChunk(1, 1, 16000, 16, false))
+	data := chunk("data", make([]byte, 10))
+	tests := []struct {
+		name string
+		in   []byte
+		want string
+	}{
+		{"not RIFF", []byte("OggS0000WAVE"), "not a WAV file"},
+		{"not WAVE", chunk("RIFF", []byte("AVI ")), "not a WAV file"},
+		{"float", riff(chunk("fmt ", fmtChunk(3, 1, 16000, 32, false)), data), "format 3 with 32 bits"},
+		{"8-bit", riff(chunk("fmt ", fmtChunk(1, 1, 16000, 8, false)), data), "format 1 with 8 bits"},
+		{"extensible float", riff(chunk("fmt ", append(fmtChunk(0xfffe, 1, 16000, 16, true)[:24], 3, 0)), data), "format 3"},
+		{"short fmt", riff(chunk("fmt ", make([]byte, 14)), data), "invalid WAV format chunk"},
+		{"no channels", riff(chunk("fmt ", fmtChunk(1, 0, 16000, 16, false)), data), "invalid WAV channel count"},
+		{"data first", riff(data, pcm), "data chunk before format chunk"},
+		{"no data", riff(pcm), "no data chunk"},
+		{"truncated chunk", riff(pcm, chunk("LIST", make([]byte, 20)))[:50], `truncated WAV chunk "LIST"`},
+	}
+	for _, tt := range tests {
+		_, err := ReadWAVHeader(bytes.NewReader(tt.in))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func pcmSamples(v ...int16) []byte {
+	var b []byte
+	for _, s := range v {
+		b = binary.LittleEndian.AppendUint16(b, uint16(s))
+	}
+	return b
+}
+
+func TestResampleMono(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       []byte
+		from, to int
+		channels int
+		want     []byte
+	}{
+		{"same rate", pcmSamples(1, -2, 3), 16000, 16000, 1, pcmSamples(1, -2, 3)},
+		{"to stereo", pcmSamples(1, -2), 16000, 16000, 2, pcmSamples(1, 1, -2, -2)},
+		{"upsample", pcmSamples(0, 100, -100), 8000, 16000, 1, pcmSamples(0, 50, 100, 0, -100, -100)},
+		{"downsample", pcmSamples(0, 10, 20, 30, 40, 50), 24000, 16000, 1, pcmSamples(0, 15, 30, 45)},
+		{"odd byte", append(pcmSamples(7), 1), 16000, 16000, 1, pcmSamples(7)},
+		{"empty", nil, 16000, 48000, 2, nil},
+	}
+	for _, tt := range tests {
+		got := ResampleMono(tt.in, tt.from, tt.to, tt.channels)
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("%s: ResampleMono = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
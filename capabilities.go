@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+	"github.com/pink-tools/pink-otel"
+)
+
+// capabilitiesVersion is the version of the capabilities document. It
+// changes only when fields are removed or change meaning.
+const capabilitiesVersion = 1
+
+// commandCapabilities are this build's commands and their subcommands.
+var commandCapabilities = []commandCapability{
+	{Name: "tts"},
+	{Name: "voice"},
+	{Name: "feed"},
+	{Name: "dub", Subcommands: []string{"create", "status", "download", "subtitles"}},
+	{Name: "silence"},
+	{Name: "voices", Subcommands: []string{"list", "add", "edit", "delete"}},
+	{Name: "stats"},
+	{Name: "usage"},
+	{Name: "report", Subcommands: []string{"voices"}},
+	{Name: "clean"},
+	{Name: "devices"},
+	{Name: "transcribe"},
+	{Name: "dict", Subcommands: []string{"list", "apply", "pls"}},
+	{Name: "conversations", Subcommands: []string{"list", "export"}},
+	{Name: "agents", Subcommands: []string{"apply", "signed-url", "serve"}},
+	{Name: "capabilities"},
+}
+
+// featureCapabilities are the features of this build that aren't commands.
+var featureCapabilities = []string{
+	"tts.clipboard",
+	"tts.from_url",
+	"tts.segments",
+	"tts.stems",
+	"tts.realtime",
+	"tts.reuse_history",
+	"tts.session",
+	"tts.keep_partial",
+	"tts.progress",
+	"voice.range",
+	"voice.monitor",
+	"dub.batch",
+	"output.template",
+	"output.fifo",
+	"output.json",
+	"output.tags",
+	"output.qc",
+	"output.format_fallback",
+	"output.ttl_cleanup",
+	"playback.device",
+	"auth.bearer",
+	"api.base_url_failover",
+	"api.retries",
+	"config.profiles",
+	"config.job_files",
+	"warnings",
+}
+
+type commandCapability struct {
+	Name        string   `json:"name"`
+	Subcommands []string `json:"subcommands,omitempty"`
+}
+
+type formatCapability struct {
+	Format string `json:"format"`
+	Codec  string `json:"codec"`
+	// Tier is the lowest subscription tier that includes the format.
+	Tier string `json:"tier"`
+	// Allowed is whether the account's tier includes the format; it is
+	// absent when the account wasn't checked or its tier is unknown.
+	Allowed *bool `json:"allowed,omitempty"`
+}
+
+// toolCapability is an external program a feature relies on.
+type toolCapability struct {
+	Name      string   `json:"name"`
+	Available bool     `json:"available"`
+	Features  []string `json:"features"`
+}
+
+type accountCapability struct {
+	Tier                string `json:"tier"`
+	Status              string `json:"status"`
+	CharactersRemaining int    `json:"characters_remaining"`
+	InstantVoiceCloning bool   `json:"instant_voice_cloning"`
+}
+
+type capabilities struct {
+	Version  int                 `json:"capabilities_version"`
+	Build    string              `json:"build"`
+	OS       string              `json:"os"`
+	Arch     string              `json:"arch"`
+	Commands []commandCapability `json:"commands"`
+	Features []string            `json:"features"`
+	// FormatNames are the short format names and the formats they mean.
+	FormatNames map[string]string      `json:"format_names"`
+	Formats     []formatCapability     `json:"formats"`
+	Models      []elevenlabs.ModelInfo `json:"models"`
+	Tools       []toolCapability       `json:"tools"`
+	// Account is absent with --offline or when the API can't be reached.
+	Account *accountCapability `json:"account,omitempty"`
+}
+
+// toolCapabilities checks which of the external programs features rely on
+// can be found.
+func toolCapabilities() []toolCapability {
+	found := func(name string) bool {
+		_, err := exec.LookPath(name)
+		return err == nil
+	}
+	_, playerErr := playerCommand("-", "mp3_44100_128", "")
+	_, recorderErr := recorderCommand(context.Background(), "default")
+	_, clipboardErr := clipboardCommand()
+	return []toolCapability{
+		{Name: "player", Available: playerErr == nil, Features: []string{"--play"}},
+		{Name: "recorder", Available: recorderErr == nil, Features: []string{"voice --monitor"}},
+		{Name: "clipboard", Available: clipboardErr == nil, Features: []string{"tts --clipboard"}},
+		{Name: "ffmpeg", Available: found("ffmpeg"), Features: []string{"--qc"}},
+		{Name: "ffprobe", Available: found("ffprobe"), Features: []string{"transcribe cost preflight"}},
+		{Name: "mpv", Available: found("mpv"), Features: []string{"devices", "--device"}},
+		{Name: "pactl", Available: found("pactl"), Features: []string{"devices without mpv", "capture devices"}},
+		{Name: "arecord", Available: found("arecord"), Features: []string{"voice --monitor without ffmpeg", "capture devices without pactl"}},
+	}
+}
+
+func cmdCapabilities(args []string) {
+	fs := flag.NewFlagSet("capabilities", flag.ExitOnError)
+	jsonOut := fs.Bool("json", false, "Print capabilities as JSON")
+	offline := fs.Bool("offline", false, "Don't ask the API what the account's tier permits")
+	api := addClientFlags(fs)
+	parseArgs(fs, args)
+
+	c := capabilities{
+		Version:     capabilitiesVersion,
+		Build:       version,
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		Commands:    commandCapabilities,
+		Features:    featureCapabilities,
+		FormatNames: outputFormats,
+		Models:      elevenlabs.KnownModels(),
+		Tools:       toolCapabilities(),
+	}
+
+	var tier string
+	if key, _ := lookupAuth(); !*offline && key != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		sub, err := api.newClient().Subscription(ctx)
+		cancel()
+		if err != nil {
+			warn("capabilities_account_unavailable", map[string]any{"error": err.Error()},
+				"failed to check the account, so what its tier permits is missing: %v", err)
+		} else {
+			tier = sub.Tier
+			c.Account = &accountCapability{
+				Tier:                sub.Tier,
+				Status:              sub.Status,
+				CharactersRemaining: max(sub.CharacterLimit-sub.CharacterCount, 0),
+				InstantVoiceCloning: sub.CanUseInstantCloning,
+			}
+		}
+	}
+	for _, f := range elevenlabs.OutputFormats("") {
+		fc := formatCapability{Format: f, Codec: string(formatCodec(f)), Tier: elevenlabs.FormatTier(f)}
+		if allowed, known := elevenlabs.FormatAllowed(f, tier); tier != "" && known {
+			fc.Allowed = &allowed
+		}
+		c.Formats = append(c.Formats, fc)
+	}
+	otel.Info("capabilities", map[string]any{"tier": tier})
+
+	if *jsonOut {
+		printJSON(c)
+		return
+	}
+
+	fmt.Printf("pink-elevenlabs v%s (%s/%s)\n", c.Build, c.OS, c.Arch)
+	fmt.Println("\nCommands:")
+	for _, cmd := range c.Commands {
+		if len(cmd.Subcommands) > 0 {
+			fmt.Printf("  %s (%s)\n", cmd.Name, strings.Join(cmd.Subcommands, ", "))
+		} else {
+			fmt.Printf("  %s\n", cmd.Name)
+		}
+	}
+	fmt.Printf("\nFeatures:\n  %s\n", strings.Join(c.Features, "\n  "))
+	fmt.Println("\nFormats:")
+	for _, f := range c.Formats {
+		note := ""
+		if f.Allowed != nil && !*f.Allowed {
+			note = "  (not on this plan)"
+		} else if f.Tier != "free" {
+			note = "  (" + f.Tier + " and above)"
+		}
+		fmt.Printf("  %s%s\n", f.Format, note)
+	}
+	fmt.Println("\nModels:")
+	for _, m := range c.Models {
+		fmt.Printf("  %s\n", m.ID)
+	}
+	fmt.Println("\nTools:")
+	for _, t := range c.Tools {
+		state := "missing"
+		if t.Available {
+			state = "found"
+		}
+		fmt.Printf("  %-10s %-8s %s\n", t.Name, state, strings.Join(t.Features, ", "))
+	}
+	if a := c.Account; a != nil {
+		fmt.Printf("\nAccount: %s (%s), %d characters remaining\n", a.Tier, a.Status, a.CharactersRemaining)
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pink-tools/pink-otel"
+)
+
+const (
+	// defaultOutputTTL is how old generated files in the temp directory get
+	// before they are removed, unless ELEVENLABS_OUTPUT_TTL says otherwise.
+	defaultOutputTTL = 7 * 24 * time.Hour
+	// autoCleanInterval is how often the automatic cleanup runs at most; the
+	// time of the last run is kept in autoCleanMarker in the directory.
+	autoCleanInterval = time.Hour
+	autoCleanMarker   = ".pink-elevenlabs-cleaned"
+	// outputManifest lists, in each directory, the outputs named from a
+	// template there, whatever the template.
+	outputManifest = ".pink-elevenlabs-outputs"
+)
+
+// generatedName matches the names defaultTemplate gives outputs.
+var generatedName = regexp.MustCompile(`^(speech|voice|silence|transcript|dub-[A-Za-z-]+)-\d{8}-\d{6}-[0-9a-f]{8}(-[^.]+)?\.[A-Za-z0-9]+$`)
+
+// parseAge parses a duration, which may be in days (7d).
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q (use a duration like 12h or 7d)", s)
+	}
+	return d, nil
+}
+
+// formatAge formats d in days if it is a whole number of them.
+func formatAge(d time.Duration) string {
+	if day := 24 * time.Hour; d >= day && d%day == 0 {
+		return fmt.Sprintf("%dd", d/day)
+	}
+	return d.String()
+}
+
+// outputTTL returns ELEVENLABS_OUTPUT_TTL, or defaultOutputTTL if it isn't
+// set. set reports whether it was.
+func outputTTL() (ttl time.Duration, set bool, err error) {
+	loadEnv()
+	v := os.Getenv("ELEVENLABS_OUTPUT_TTL")
+	if v == "" {
+		return defaultOutputTTL, false, nil
+	}
+	ttl, err = parseAge(v)
+	if err != nil {
+		return 0, true, fmt.Errorf("invalid ELEVENLABS_OUTPUT_TTL: %w", err)
+	}
+	return ttl, true, nil
+}
+
+// recordOutput adds a generated output to its directory's manifest.
+func recordOutput(path string) {
+	f, err := os.OpenFile(filepath.Join(filepath.Dir(path), outputManifest), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(f, filepath.Base(path))
+	f.Close()
+}
+
+// recordedOutputs returns the names in dir's manifest.
+func recordedOutputs(dir string) map[string]bool {
+	b, err := os.ReadFile(filepath.Join(dir, outputManifest))
+	if err != nil {
+		return nil
+	}
+	names := map[string]bool{}
+	for _, name := range strings.Split(string(b), "\n") {
+		if name = strings.TrimSpace(name); name != "" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// pruneManifest rewrites dir's manifest without the outputs that are gone.
+func pruneManifest(dir string, recorded map[string]bool) error {
+	var kept strings.Builder
+	for name := range recorded {
+		if _, err := os.Lstat(filepath.Join(dir, name)); err == nil {
+			fmt.Fprintln(&kept, name)
+		}
+	}
+	path := filepath.Join(dir, outputManifest)
+	if kept.Len() == 0 {
+		return os.Remove(path)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(kept.String()), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// cleanedFile is a generated output removed by clean.
+type cleanedFile struct {
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// cleanOutputs removes the generated outputs in dir last modified more than
+// ttl ago and returns them. With dryRun they are only returned.
+func cleanOutputs(dir string, ttl time.Duration, dryRun bool) ([]cleanedFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output directory: %w", err)
+	}
+	recorded := recordedOutputs(dir)
+	cutoff := time.Now().Add(-ttl)
+	var cleaned []cleanedFile
+	var errs []error
+	for _, e := range entries {
+		if !e.Type().IsRegular() || !generatedName.MatchString(e.Name()) && !recorded[e.Name()] {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil || !fi.ModTime().Before(cutoff) {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		if !dryRun {
+			if err := os.Remove(path); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		cleaned = append(cleaned, cleanedFile{Path: path, Size: fi.Size(), Modified: fi.ModTime()})
+	}
+	if !dryRun && len(recorded) > 0 {
+		if err := pruneManifest(dir, recorded); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update %s: %w", outputManifest, err))
+		}
+	}
+	return cleaned, errors.Join(errs...)
+}
+
+// autoClean removes expired outputs from dir at most every
+// autoCleanInterval, if dir is the temp dir or, with ELEVENLABS_OUTPUT_TTL
+// set, outputDir. Failures are only logged.
+func autoClean(dir, outputDir string) {
+	ttl, set, err := outputTTL()
+	if err != nil || ttl == 0 || !samePath(dir, os.TempDir()) && !(set && samePath(dir, outputDir)) {
+		return
+	}
+	marker := filepath.Join(dir, autoCleanMarker)
+	if fi, err := os.Stat(marker); err == nil && time.Since(fi.ModTime()) < autoCleanInterval {
+		return
+	}
+	if err := os.WriteFile(marker, nil, 0644); err != nil {
+		return
+	}
+
+	cleaned, err := cleanOutputs(dir, ttl, false)
+	fields := map[string]any{"dir": dir, "ttl": ttl.String(), "removed": len(cleaned)}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+	otel.Info("output_auto_clean", fields)
+}
+
+// samePath reports whether a and b name the same directory, ignoring case
+// where the file system does.
+func samePath(a, b string) bool {
+	a, b = filepath.Clean(a), filepath.Clean(b)
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+func cmdClean(args []string) {
+	fs := flag.NewFlagSet("clean", flag.ExitOnError)
+	outputDir := fs.String("output-dir", "", "Directory to clean (default: the output directory)")
+	fs.StringVar(outputDir, "d", "", "Directory to clean")
+	olderThan := fs.String("older-than", "", "Remove outputs older than this, e.g. 12h or 7d (default: ELEVENLABS_OUTPUT_TTL or 7d)")
+	dryRun := fs.Bool("dry-run", false, "List what would be removed without removing it")
+	jsonOut := fs.Bool("json", false, "Print the removed files as JSON")
+	parseArgs(fs, args)
+
+	ttl, _, err := outputTTL()
+	if *olderThan != "" {
+		ttl, err = parseAge(*olderThan)
+	} else if ttl == 0 {
+		// 0 turns the automatic cleanup off; it doesn't mean everything.
+		ttl = defaultOutputTTL
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	dir := getOutputDir(*outputDir)
+	cleaned, err := cleanOutputs(dir, ttl, *dryRun)
+	var size int64
+	for _, c := range cleaned {
+		size += c.Size
+	}
+	otel.Info("output_clean", map[string]any{"dir": dir, "ttl": ttl.String(), "removed": len(cleaned), "bytes": size, "dry_run": *dryRun})
+	if err != nil {
+		fail("clean_failed", err)
+	}
+
+	if *jsonOut {
+		if cleaned == nil {
+			cleaned = []cleanedFile{}
+		}
+		printJSON(cleaned)
+		return
+	}
+	for _, c := range cleaned {
+		fmt.Println(c.Path)
+	}
+	verb := "Removed"
+	if *dryRun {
+		verb = "Would remove"
+	}
+	fmt.Fprintf(os.Stderr, "%s %d files (%.1f MB) older than %s from %s\n", verb, len(cleaned), float64(size)/1e6, formatAge(ttl), dir)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestSamePath(t *testing.T) {
+	foldsCase := runtime.GOOS == "windows" || runtime.GOOS == "darwin"
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"/tmp", "/tmp", true},
+		{"/tmp/", "/tmp", true},
+		{"/tmp/./out/..", "/tmp", true},
+		{"/tmp", "/var/tmp", false},
+		{"/Tmp", "/tmp", foldsCase},
+		{`/Users/Me/AppData/Local/Temp`, `/users/me/appdata/local/temp/`, foldsCase},
+	}
+	for _, tt := range tests {
+		if got := samePath(tt.a, tt.b); got != tt.want {
+			t.Errorf("samePath(%q, %q) = %v, want %v on %s", tt.a, tt.b, got, tt.want, runtime.GOOS)
+		}
+	}
+}
+
+func TestCleanOutputs(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour)
+	files := map[string]bool{
+		"speech-20260101-120000-1a2b3c4d.mp3": true,
+		"2026-01-01-episode.mp3":              true,
+		"2026-01-01-episode-Rachel.mp3":       true,
+		"2026-01-01-fresh.mp3":                false,
+		"notes.mp3":                           false,
+		"feed-state.json":                     false,
+	}
+	for name := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if name != "2026-01-01-fresh.mp3" {
+			os.Chtimes(path, old, old)
+		}
+		if name != "notes.mp3" && name != "feed-state.json" && name != "speech-20260101-120000-1a2b3c4d.mp3" {
+			recordOutput(path)
+		}
+	}
+
+	cleaned, err := cleanOutputs(dir, 24*time.Hour, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var removed, want []string
+	for _, c := range cleaned {
+		removed = append(removed, filepath.Base(c.Path))
+	}
+	for name, clean := range files {
+		if _, err := os.Stat(filepath.Join(dir, name)); os.IsNotExist(err) != clean {
+			t.Errorf("%s removed: %v, want %v", name, !clean, clean)
+		}
+		if clean {
+			want = append(want, name)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(want)
+	if len(removed) != len(want) {
+		t.Errorf("cleaned %q, want %q", removed, want)
+	}
+	if recorded := recordedOutputs(dir); len(recorded) != 1 || !recorded["2026-01-01-fresh.mp3"] {
+		t.Errorf("manifest after clean = %v, want only the fresh output", recorded)
+	}
+}
+
+func TestAutoCleanOnlyOutputDir(t *testing.T) {
+	t.Setenv("ELEVENLABS_OUTPUT_TTL", "1h")
+	old := time.Now().Add(-2 * time.Hour)
+	outputDir, other := t.TempDir(), t.TempDir()
+	for _, dir := range []string{outputDir, other} {
+		path := filepath.Join(dir, "speech-20260101-120000-1a2b3c4d.mp3")
+		os.WriteFile(path, []byte("x"), 0644)
+		os.Chtimes(path, old, old)
+		autoClean(dir, outputDir)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "speech-20260101-120000-1a2b3c4d.mp3")); !os.IsNotExist(err) {
+		t.Error("expired output in the output dir wasn't removed")
+	}
+	if _, err := os.Stat(filepath.Join(other, "speech-20260101-120000-1a2b3c4d.mp3")); err != nil {
+		t.Error("output in another dir was removed")
+	}
+}
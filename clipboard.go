@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardCandidates lists the commands that print the clipboard, tried in
+// order on each platform.
+var clipboardCandidates = map[string][][]string{
+	"darwin":  {{"pbpaste"}},
+	"windows": {{"powershell", "-NoProfile", "-Command", "Get-Clipboard -Raw"}},
+	"linux": {
+		{"wl-paste", "--no-newline"},
+		{"xclip", "-selection", "clipboard", "-out"},
+		{"xsel", "--clipboard", "--output"},
+		{"termux-clipboard-get"},
+	},
+}
+
+// clipboardCommand builds the command that prints the clipboard text.
+// ELEVENLABS_CLIPBOARD overrides the search.
+func clipboardCommand() (*exec.Cmd, error) {
+	loadEnv()
+	if custom := splitCommand(os.Getenv("ELEVENLABS_CLIPBOARD")); len(custom) > 0 {
+		return exec.Command(custom[0], custom[1:]...), nil
+	}
+
+	candidates, ok := clipboardCandidates[runtime.GOOS]
+	if !ok {
+		candidates = clipboardCandidates["linux"]
+	}
+	for _, c := range candidates {
+		// wl-paste only works in a Wayland session.
+		if c[0] == "wl-paste" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			continue
+		}
+		if path, err := exec.LookPath(c[0]); err == nil {
+			return exec.Command(path, c[1:]...), nil
+		}
+	}
+	var names []string
+	for _, c := range candidates {
+		names = append(names, c[0])
+	}
+	return nil, fmt.Errorf("no clipboard tool found (install %s, or set ELEVENLABS_CLIPBOARD)", strings.Join(names, ", "))
+}
+
+// readClipboard returns the text on the system clipboard.
+func readClipboard() (string, error) {
+	cmd, err := clipboardCommand()
+	if err != nil {
+		return "", err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("failed to read clipboard: %s", msg)
+		}
+		return "", fmt.Errorf("failed to read clipboard: %w", err)
+	}
+	text := strings.ReplaceAll(string(decodeText(out, "the clipboard")), "\r\n", "\n")
+	if strings.TrimSpace(text) == "" {
+		return "", errors.New("clipboard is empty or holds no text")
+	}
+	return text, nil
+}
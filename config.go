@@ -0,0 +1,181 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// config is the optional config file with named profiles:
+//
+//	default_profile: work
+//	profiles:
+//	  work:
+//	    api_key: ...
+//	    tts_voice: ...
+//	    format: mp3
+type config struct {
+	DefaultProfile string              `yaml:"default_profile"`
+	Profiles       map[string]*profile `yaml:"profiles"`
+}
+
+// profile holds defaults for the flags of every command. Unset fields leave
+// the flag to its environment variable or built-in default.
+type profile struct {
+	APIKey           string   `yaml:"api_key"`
+	TTSVoice         string   `yaml:"tts_voice"`
+	VoiceChangeVoice string   `yaml:"voice_change_voice"`
+	TTSModel         string   `yaml:"tts_model"`
+	STSModel         string   `yaml:"sts_model"`
+	Format           string   `yaml:"format"`
+	OutputDir        string   `yaml:"output_dir"`
+	Stability        *float64 `yaml:"stability"`
+	SimilarityBoost  *float64 `yaml:"similarity_boost"`
+	Style            *float64 `yaml:"style"`
+	Speed            *float64 `yaml:"speed"`
+	SpeakerBoost     *bool    `yaml:"speaker_boost"`
+	// BearerToken, if set, is sent as "Authorization: Bearer" instead of
+	// the API key, for gateways that issue their own tokens.
+	BearerToken string `yaml:"bearer_token"`
+	// BaseURLs are the API base URLs in failover order.
+	BaseURLs []string `yaml:"base_urls"`
+	// OtelAttributes are added to the otel resource attributes.
+	OtelAttributes map[string]string `yaml:"otel_attributes"`
+}
+
+// activeProfile is the profile selected by the last parseArgs, if any.
+var activeProfile *profile
+
+func configPath() string {
+	loadEnv()
+	if p := os.Getenv("ELEVENLABS_CONFIG"); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, serviceName, "config.yaml")
+}
+
+// loadProfile returns the named profile, or the default one if name is "".
+func loadProfile(name string) (*profile, error) {
+	path := configPath()
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) || path == "" {
+		if name != "" {
+			return nil, fmt.Errorf("profile %q requested but no config file at %s", name, path)
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var cfg config
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	if name == "" {
+		name = cfg.DefaultProfile
+	}
+	if name == "" {
+		return nil, nil
+	}
+	p, ok := cfg.Profiles[name]
+	if !ok || p == nil {
+		names := make([]string, 0, len(cfg.Profiles))
+		for n := range cfg.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown profile %q (have: %s)", name, strings.Join(names, ", "))
+	}
+	return p, nil
+}
+
+// flagValues maps the profile onto the flag names of command cmd.
+func (p *profile) flagValues(cmd string) map[string]string {
+	v := map[string]string{
+		"output-dir": p.OutputDir,
+		"base-url":   strings.Join(p.BaseURLs, ","),
+	}
+	// format is an audio format; dub subtitles' --format is srt or vtt.
+	switch cmd {
+	case "tts", "voice", "silence", "feed":
+		v["format"] = p.Format
+	}
+	switch cmd {
+	case "tts", "feed":
+		v["voice"] = p.TTSVoice
+		v["model"] = p.TTSModel
+	case "voice":
+		v["voice"] = p.VoiceChangeVoice
+		v["model"] = p.STSModel
+	}
+	for name, f := range map[string]*float64{
+		"stability":        p.Stability,
+		"similarity-boost": p.SimilarityBoost,
+		"style":            p.Style,
+		"speed":            p.Speed,
+	} {
+		if f != nil {
+			v[name] = strconv.FormatFloat(*f, 'f', -1, 64)
+		}
+	}
+	if p.SpeakerBoost != nil {
+		v["no-speaker-boost"] = strconv.FormatBool(!*p.SpeakerBoost)
+	}
+	return v
+}
+
+// shortFlags maps the short aliases of profile-settable flags to their long
+// names, so that e.g. -v counts as setting --voice.
+var shortFlags = map[string]string{
+	"d": "output-dir",
+	"f": "format",
+	"m": "model",
+	"v": "voice",
+}
+
+// applyProfile fills in every flag of fs not given on the command line from
+// the selected profile.
+func applyProfile(fs *flag.FlagSet, name string) {
+	if name == "" {
+		loadEnv()
+		name = os.Getenv("ELEVENLABS_PROFILE")
+	}
+	p, err := loadProfile(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	activeProfile = p
+	if p == nil {
+		return
+	}
+
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+		if long, ok := shortFlags[f.Name]; ok {
+			set[long] = true
+		}
+	})
+	for name, value := range p.flagValues(fs.Name()) {
+		if value == "" || set[name] || fs.Lookup(name) == nil {
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: invalid %s in profile: %v\n", name, err)
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigPath(t *testing.T) {
+	t.Setenv("ELEVENLABS_CONFIG", "")
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		t.Skip("no user config directory:", err)
+	}
+	if got, want := configPath(), filepath.Join(dir, "pink-elevenlabs", "config.yaml"); got != want {
+		t.Errorf("default = %q, want %q", got, want)
+	}
+
+	custom := filepath.Join(t.TempDir(), "My Config", "elevenlabs.yaml")
+	t.Setenv("ELEVENLABS_CONFIG", custom)
+	if got := configPath(); got != custom {
+		t.Errorf("with ELEVENLABS_CONFIG = %q, want %q", got, custom)
+	}
+}
+
+func TestDubSubtitlesIgnoresProfileFormat(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/dubbing/dub1/transcript/es" || r.URL.Query().Get("format_type") != "srt" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("1\n00:00:00,000 --> 00:00:01,000\nHola\n"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.yaml")
+	os.WriteFile(config, []byte("default_profile: work\nprofiles:\n  work:\n    format: mp3\n"), 0644)
+	out := filepath.Join(dir, "dub.srt")
+	_, stderr, code := runMain(t, []string{
+		"ELEVENLABS_API_KEY=key",
+		"ELEVENLABS_BASE_URL=" + srv.URL,
+		"ELEVENLABS_CONFIG=" + config,
+	}, "dub", "subtitles", "-l", "es", "-o", out, "dub1")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if b, _ := os.ReadFile(out); !strings.Contains(string(b), "Hola") {
+		t.Errorf("subtitles = %q", b)
+	}
+}
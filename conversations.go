@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+	"github.com/pink-tools/pink-otel"
+)
+
+func cmdConversations(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "ERROR: conversations subcommand required (list, export)")
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "list":
+		cmdConversationsList(args[1:])
+	case "export":
+		cmdConversationsExport(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown conversations command: %s\n", args[0])
+		os.Exit(1)
+	}
+}
+
+// conversationFilter holds the flags selecting conversations.
+type conversationFilter struct {
+	agent  *string
+	since  *string
+	until  *string
+	limit  *int
+	status *string
+}
+
+func addConversationFilter(fs *flag.FlagSet) *conversationFilter {
+	f := &conversationFilter{
+		agent:  fs.String("agent", "", "Only conversations with this agent ID"),
+		since:  fs.String("since", "", "Only conversations started after this date, time or duration ago (e.g. 2026-10-01, 7d)"),
+		until:  fs.String("until", "", "Only conversations started before this date, time or duration ago"),
+		limit:  fs.Int("limit", 0, "Return at most this many, newest first (0: all)"),
+		status: fs.String("status", "", "Only conversations evaluated as success, failure or unknown"),
+	}
+	fs.StringVar(f.agent, "a", "", "Only conversations with this agent ID")
+	return f
+}
+
+// parseSince parses a date (2006-01-02), an RFC 3339 time or a duration
+// before now, which may be in days (7d).
+func parseSince(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return time.Now().AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (use 2006-01-02, RFC 3339 or a duration like 24h or 7d)", s)
+}
+
+func (f *conversationFilter) list(ctx context.Context, client *elevenlabs.Client) ([]elevenlabs.Conversation, error) {
+	after, err := parseSince(*f.since)
+	if err != nil {
+		return nil, err
+	}
+	before, err := parseSince(*f.until)
+	if err != nil {
+		return nil, err
+	}
+	q := elevenlabs.ConversationQuery{AgentID: *f.agent, After: after, Before: before}
+	// The evaluation filter is applied here, so the limit must be too.
+	if *f.status == "" {
+		q.Limit = *f.limit
+	}
+	convs, err := client.ListConversations(ctx, q)
+	if err != nil || *f.status == "" {
+		return convs, err
+	}
+	var out []elevenlabs.Conversation
+	for _, c := range convs {
+		if c.CallSuccessful == *f.status {
+			out = append(out, c)
+			if *f.limit > 0 && len(out) == *f.limit {
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+func cmdConversationsList(args []string) {
+	fs := flag.NewFlagSet("conversations list", flag.ExitOnError)
+	filter := addConversationFilter(fs)
+	jsonOut := fs.Bool("json", false, "Print conversations as JSON")
+	api := addClientFlags(fs)
+	parseArgs(fs, args)
+
+	convs, err := filter.list(context.Background(), api.newClient())
+	if err != nil {
+		fail("conversations_list_failed", err)
+	}
+
+	if *jsonOut {
+		printJSON(convs)
+		return
+	}
+	for _, c := range convs {
+		fmt.Printf("%s\t%s\t%s\t%s\t%d\t%s\n", c.ConversationID, c.AgentID,
+			c.StartTime().Local().Format("2006-01-02 15:04"),
+			time.Duration(c.CallDurationSecs)*time.Second, c.MessageCount, c.CallSuccessful)
+	}
+}
+
+func cmdConversationsExport(args []string) {
+	fs := flag.NewFlagSet("conversations export", flag.ExitOnError)
+	filter := addConversationFilter(fs)
+	outputDir := fs.String("output-dir", "", "Directory to export into")
+	fs.StringVar(outputDir, "d", "", "Directory to export into")
+	withAudio := fs.Bool("audio", false, "Also download each conversation's recording")
+	api := addClientFlags(fs)
+	parseArgs(fs, args)
+
+	ctx := context.Background()
+	client := api.newClient()
+
+	// Conversation IDs may be given; otherwise the filter selects them.
+	ids := fs.Args()
+	if len(ids) == 0 {
+		convs, err := filter.list(ctx, client)
+		if err != nil {
+			fail("conversations_export_failed", err)
+		}
+		for _, c := range convs {
+			ids = append(ids, c.ConversationID)
+		}
+	}
+	if len(ids) == 0 {
+		fmt.Fprintln(os.Stderr, "No conversations to export")
+		return
+	}
+
+	dir := getOutputDir(*outputDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: failed to create output directory: %v\n", err)
+		os.Exit(1)
+	}
+	for i, id := range ids {
+		paths, err := exportConversation(ctx, client, id, dir, *withAudio)
+		if err != nil {
+			fail("conversations_export_failed", fmt.Errorf("conversation %s: %w", id, err))
+		}
+		fmt.Fprintf(os.Stderr, "Exported %d/%d: %s\n", i+1, len(ids), id)
+		for _, p := range paths {
+			printPath(p)
+		}
+	}
+	otel.Info("conversations_exported", map[string]any{"count": len(ids), "dir": dir})
+}
+
+// exportConversation writes <id>.json with the full transcript, <id>.txt
+// for reading and, with audio, the recording, returning their paths.
+func exportConversation(ctx context.Context, client *elevenlabs.Client, id, dir string, audio bool) ([]string, error) {
+	d, err := client.GetConversation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	base := filepath.Join(dir, id)
+
+	b, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeOutput(base+".json", bytes.NewReader(append(b, '\n'))); err != nil {
+		return nil, err
+	}
+	if err := writeOutput(base+".txt", strings.NewReader(formatTranscript(d))); err != nil {
+		return nil, err
+	}
+	paths := []string{base + ".json", base + ".txt"}
+
+	if audio && d.HasAudio {
+		a, err := client.ConversationAudio(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download audio: %w", err)
+		}
+		defer a.Close()
+		path := base + mediaExtension(a.ContentType)
+		if err := writeOutput(path, a); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// formatTranscript renders a conversation as one line per message, with
+// the time into the call.
+func formatTranscript(d *elevenlabs.ConversationDetail) string {
+	var b strings.Builder
+	start := time.Unix(d.Metadata.StartTimeUnix, 0).UTC()
+	fmt.Fprintf(&b, "Conversation %s with agent %s\n", d.ConversationID, d.AgentID)
+	fmt.Fprintf(&b, "Started %s, %s, %s\n\n", start.Format("2006-01-02 15:04:05 MST"),
+		time.Duration(d.Metadata.CallDurationSecs)*time.Second, d.Status)
+	for _, t := range d.Transcript {
+		secs := int(t.TimeInCallSecs)
+		msg := strings.TrimSpace(t.Message)
+		if msg == "" && len(t.ToolCalls) > 0 && string(t.ToolCalls) != "null" && string(t.ToolCalls) != "[]" {
+			msg = "(tool call)"
+		}
+		if msg == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "[%02d:%02d] %s: %s\n", secs/60, secs%60, t.Role, msg)
+	}
+	return b.String()
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/pink-tools/pink-otel"
+)
+
+// audioDevice is a playback device as mpv names it or a capture device as
+// --mic takes it.
+type audioDevice struct {
+	Kind        string `json:"kind"`
+	Index       int    `json:"index"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+var (
+	// mpvDevice matches a line of mpv --audio-device=help.
+	mpvDevice = regexp.MustCompile(`^\s*'([^']+)'\s+\((.*)\)\s*$`)
+	// arecordDevice matches a line of arecord -l.
+	arecordDevice = regexp.MustCompile(`^card (\d+): \S+ \[(.*)\], device (\d+): .*\[(.*)\]`)
+	// avfoundationDevice and dshowDevice match the devices ffmpeg lists with
+	// -list_devices.
+	avfoundationDevice = regexp.MustCompile(`\] \[(\d+)\] (.+)$`)
+	dshowDevice        = regexp.MustCompile(`\]\s+"(.+)"(?: \((audio|video)\))?\s*$`)
+)
+
+// listAudioDevices lists the playback devices, with mpv if installed (which
+// covers PulseAudio, PipeWire, ALSA, CoreAudio and WASAPI) or else pactl.
+func listAudioDevices() ([]audioDevice, error) {
+	var devices []audioDevice
+	if mpv, err := exec.LookPath("mpv"); err == nil {
+		out, err := exec.Command(mpv, "--audio-device=help").Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list devices with mpv: %w", err)
+		}
+		for _, line := range strings.Split(string(out), "\n") {
+			m := mpvDevice.FindStringSubmatch(line)
+			if m == nil || m[1] == "auto" {
+				continue
+			}
+			devices = append(devices, audioDevice{Kind: "playback", Index: len(devices), Name: m[1], Description: m[2]})
+		}
+		return devices, nil
+	}
+
+	if pactl, err := exec.LookPath("pactl"); err == nil {
+		out, err := exec.Command(pactl, "list", "short", "sinks").Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list devices with pactl: %w", err)
+		}
+		sc := bufio.NewScanner(bytes.NewReader(out))
+		for sc.Scan() {
+			fields := strings.Fields(sc.Text())
+			if len(fields) < 2 {
+				continue
+			}
+			devices = append(devices, audioDevice{Kind: "playback", Index: len(devices), Name: "pulse/" + fields[1]})
+		}
+		return devices, nil
+	}
+	return nil, errors.New("listing devices needs mpv or pactl")
+}
+
+// listCaptureDevices lists the capture devices recorderCommand can use.
+func listCaptureDevices() ([]audioDevice, error) {
+	ffmpeg, ffmpegErr := exec.LookPath("ffmpeg")
+	switch runtime.GOOS {
+	case "darwin", "windows":
+		if ffmpegErr != nil {
+			return nil, errors.New("listing capture devices needs ffmpeg")
+		}
+		format, input, parse := "avfoundation", "", parseAVFoundationDevices
+		if runtime.GOOS == "windows" {
+			format, input, parse = "dshow", "dummy", parseDShowDevices
+		}
+		// ffmpeg lists the devices, then fails for want of a real input.
+		out, _ := exec.Command(ffmpeg, "-hide_banner", "-list_devices", "true", "-f", format, "-i", input).CombinedOutput()
+		return parse(string(out)), nil
+	}
+
+	if pactl, err := exec.LookPath("pactl"); err == nil && ffmpegErr == nil {
+		out, err := exec.Command(pactl, "list", "short", "sources").Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list capture devices with pactl: %w", err)
+		}
+		var devices []audioDevice
+		sc := bufio.NewScanner(bytes.NewReader(out))
+		for sc.Scan() {
+			fields := strings.Fields(sc.Text())
+			if len(fields) < 2 {
+				continue
+			}
+			devices = append(devices, audioDevice{Kind: "capture", Index: len(devices), Name: "pulse/" + fields[1]})
+		}
+		return devices, nil
+	}
+	if arecord, err := exec.LookPath("arecord"); err == nil {
+		out, err := exec.Command(arecord, "-l").Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list capture devices with arecord: %w", err)
+		}
+		return parseArecordDevices(string(out)), nil
+	}
+	return nil, errors.New("listing capture devices needs pactl and ffmpeg, or arecord")
+}
+
+func parseArecordDevices(out string) []audioDevice {
+	var devices []audioDevice
+	for _, line := range strings.Split(out, "\n") {
+		if m := arecordDevice.FindStringSubmatch(line); m != nil {
+			devices = append(devices, audioDevice{
+				Kind:        "capture",
+				Index:       len(devices),
+				Name:        fmt.Sprintf("alsa/hw:%s,%s", m[1], m[3]),
+				Description: m[2] + ": " + m[4],
+			})
+		}
+	}
+	return devices
+}
+
+// parseAVFoundationDevices reads the audio devices from ffmpeg -f
+// avfoundation -list_devices true, which lists video devices first.
+func parseAVFoundationDevices(out string) []audioDevice {
+	var devices []audioDevice
+	audio := false
+	for _, line := range strings.Split(out, "\n") {
+		if strings.Contains(line, "AVFoundation audio devices") {
+			audio = true
+		} else if strings.Contains(line, "AVFoundation video devices") {
+			audio = false
+		} else if m := avfoundationDevice.FindStringSubmatch(line); m != nil && audio {
+			devices = append(devices, audioDevice{Kind: "capture", Index: len(devices), Name: ":" + m[1], Description: m[2]})
+		}
+	}
+	return devices
+}
+
+// parseDShowDevices reads the audio devices from ffmpeg -f dshow
+// -list_devices true.
+func parseDShowDevices(out string) []audioDevice {
+	var devices []audioDevice
+	audio := false
+	for _, line := range strings.Split(strings.ReplaceAll(out, "\r", ""), "\n") {
+		if strings.Contains(line, "DirectShow audio devices") {
+			audio = true
+		} else if strings.Contains(line, "DirectShow video devices") {
+			audio = false
+		} else if m := dshowDevice.FindStringSubmatch(line); m != nil && (m[2] == "audio" || m[2] == "" && audio) {
+			devices = append(devices, audioDevice{Kind: "capture", Index: len(devices), Name: "audio=" + m[1], Description: m[1]})
+		}
+	}
+	return devices
+}
+
+// resolveAudioDevice turns a --device index or name into a device name.
+func resolveAudioDevice(spec string) (string, error) {
+	return resolveDevice(spec, listAudioDevices)
+}
+
+// resolveCaptureDevice turns a --mic value into a capture device name the
+// same way.
+func resolveCaptureDevice(spec string) (string, error) {
+	return resolveDevice(spec, listCaptureDevices)
+}
+
+func resolveDevice(spec string, list func() ([]audioDevice, error)) (string, error) {
+	if spec == "" {
+		return "", nil
+	}
+	devices, err := list()
+	if err != nil {
+		if _, nerr := strconv.Atoi(spec); nerr == nil {
+			return "", fmt.Errorf("can't select device %s by index: %w", spec, err)
+		}
+		return spec, nil
+	}
+
+	if i, err := strconv.Atoi(spec); err == nil {
+		if i < 0 || i >= len(devices) {
+			return "", fmt.Errorf("no device with index %d (see devices)", i)
+		}
+		return devices[i].Name, nil
+	}
+	var matches []string
+	lower := strings.ToLower(spec)
+	for _, d := range devices {
+		if d.Name == spec {
+			return d.Name, nil
+		}
+		if strings.Contains(strings.ToLower(d.Name), lower) || strings.Contains(strings.ToLower(d.Description), lower) {
+			matches = append(matches, d.Name)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return spec, nil
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("device %q matches %d devices: %s", spec, len(matches), strings.Join(matches, ", "))
+	}
+}
+
+// ffplayDeviceEnv returns the environment that points ffplay at device.
+func ffplayDeviceEnv(device string) ([]string, bool) {
+	driver, name, _ := strings.Cut(device, "/")
+	switch driver {
+	case "pulse", "pipewire":
+		return []string{"SDL_AUDIODRIVER=pulseaudio", "PULSE_SINK=" + name}, name != ""
+	case "alsa":
+		return []string{"SDL_AUDIODRIVER=alsa", "AUDIODEV=" + name}, name != ""
+	}
+	return nil, false
+}
+
+func cmdDevices(args []string) {
+	fs := flag.NewFlagSet("devices", flag.ExitOnError)
+	jsonOut := fs.Bool("json", false, "Print devices as JSON")
+	parseArgs(fs, args)
+
+	playback, playbackErr := listAudioDevices()
+	capture, captureErr := listCaptureDevices()
+	if playbackErr != nil && captureErr != nil {
+		fail("devices_failed", errors.Join(playbackErr, captureErr))
+	}
+	for _, err := range []error{playbackErr, captureErr} {
+		if err != nil {
+			warn("devices_incomplete", map[string]any{"error": err.Error()}, "%v", err)
+		}
+	}
+	otel.Info("devices_listed", map[string]any{"playback": len(playback), "capture": len(capture)})
+
+	if *jsonOut {
+		printJSON(append(append([]audioDevice{}, playback...), capture...))
+		return
+	}
+	for _, list := range []struct {
+		title   string
+		devices []audioDevice
+		err     error
+	}{
+		{"Playback devices (--device)", playback, playbackErr},
+		{"Capture devices (--mic)", capture, captureErr},
+	} {
+		if list.err != nil {
+			continue
+		}
+		fmt.Printf("%s:\n", list.title)
+		if len(list.devices) == 0 {
+			fmt.Println("  none found")
+		}
+		for _, d := range list.devices {
+			fmt.Printf("%d\t%s\t%s\n", d.Index, d.Name, d.Description)
+		}
+	}
+}
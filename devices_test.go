@@ -0,0 +1,94 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseArecordDevices(t *testing.T) {
+	out := `**** List of CAPTURE Hardware Devices ****
+card 0: PCH [HDA Intel PCH], device 0: ALC892 Analog [ALC892 Analog]
+  Subdevices: 1/1
+  Subdevice #0: subdevice #0
+card 2: Yeti [Yeti Stereo Microphone], device 0: USB Audio [USB Audio]
+  Subdevices: 1/1
+`
+	want := []audioDevice{
+		{Kind: "capture", Index: 0, Name: "alsa/hw:0,0", Description: "HDA Intel PCH: ALC892 Analog"},
+		{Kind: "capture", Index: 1, Name: "alsa/hw:2,0", Description: "Yeti Stereo Microphone: USB Audio"},
+	}
+	if got := parseArecordDevices(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseAVFoundationDevices(t *testing.T) {
+	out := `[AVFoundation indev @ 0x7f8] AVFoundation video devices:
+[AVFoundation indev @ 0x7f8] [0] FaceTime HD Camera
+[AVFoundation indev @ 0x7f8] [1] Capture screen 0
+[AVFoundation indev @ 0x7f8] AVFoundation audio devices:
+[AVFoundation indev @ 0x7f8] [0] MacBook Pro Microphone
+[AVFoundation indev @ 0x7f8] [1] Scarlett 2i2 USB
+: Input/output error
+`
+	want := []audioDevice{
+		{Kind: "capture", Index: 0, Name: ":0", Description: "MacBook Pro Microphone"},
+		{Kind: "capture", Index: 1, Name: ":1", Description: "Scarlett 2i2 USB"},
+	}
+	if got := parseAVFoundationDevices(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseDShowDevices(t *testing.T) {
+	want := []audioDevice{
+		{Kind: "capture", Index: 0, Name: "audio=Microphone (Realtek(R) Audio)", Description: "Microphone (Realtek(R) Audio)"},
+		{Kind: "capture", Index: 1, Name: "audio=Line In (USB Audio)", Description: "Line In (USB Audio)"},
+	}
+	tests := map[string]string{
+		"marked": "[dshow @ 000001] \"Integrated Camera\" (video)\r\n" +
+			"[dshow @ 000001]   Alternative name \"@device_pnp_\\\\?\\usb#vid\"\r\n" +
+			"[dshow @ 000001] \"Microphone (Realtek(R) Audio)\" (audio)\r\n" +
+			"[dshow @ 000001]   Alternative name \"@device_cm_{33D9A762}\\wave_{1}\"\r\n" +
+			"[dshow @ 000001] \"Line In (USB Audio)\" (audio)\r\n" +
+			"dummy: Immediate exit requested\r\n",
+		"headings": "[dshow @ 000001] DirectShow video devices (some may be both video and audio devices)\r\n" +
+			"[dshow @ 000001]  \"Integrated Camera\"\r\n" +
+			"[dshow @ 000001] DirectShow audio devices\r\n" +
+			"[dshow @ 000001]  \"Microphone (Realtek(R) Audio)\"\r\n" +
+			"[dshow @ 000001]     Alternative name \"@device_cm_{33D9A762}\\wave_{1}\"\r\n" +
+			"[dshow @ 000001]  \"Line In (USB Audio)\"\r\n",
+	}
+	for name, out := range tests {
+		if got := parseDShowDevices(out); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %+v, want %+v", name, got, want)
+		}
+	}
+}
+
+func TestResolveDevice(t *testing.T) {
+	list := func() ([]audioDevice, error) {
+		return []audioDevice{
+			{Index: 0, Name: "pulse/alsa_input.pci.analog-stereo", Description: "Built-in Audio"},
+			{Index: 1, Name: "pulse/alsa_input.usb-Blue_Yeti-00.analog-stereo", Description: "Yeti Stereo Microphone"},
+		}, nil
+	}
+	tests := []struct {
+		spec, want string
+		err        bool
+	}{
+		{"", "", false},
+		{"1", "pulse/alsa_input.usb-Blue_Yeti-00.analog-stereo", false},
+		{"2", "", true},
+		{"yeti", "pulse/alsa_input.usb-Blue_Yeti-00.analog-stereo", false},
+		{"built-in", "pulse/alsa_input.pci.analog-stereo", false},
+		{"analog-stereo", "", true},
+		{"alsa/hw:3,0", "alsa/hw:3,0", false},
+	}
+	for _, tt := range tests {
+		got, err := resolveDevice(tt.spec, list)
+		if got != tt.want || (err != nil) != tt.err {
+			t.Errorf("resolveDevice(%q) = %q, %v, want %q, error %v", tt.spec, got, err, tt.want, tt.err)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+	"github.com/pink-tools/pink-otel"
+)
+
+func cmdDict(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "ERROR: dict subcommand required (list, apply, pls)")
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "list":
+		cmdDictList(args[1:])
+	case "apply":
+		cmdDictApply(args[1:])
+	case "pls":
+		cmdDictPLS(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown dict command: %s\n", args[0])
+		os.Exit(1)
+	}
+}
+
+// readRules reads pronunciation rules from a CSV file.
+func readRules(path string) ([]elevenlabs.PronunciationRule, error) {
+	b, err := readInputFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r := csv.NewReader(bytes.NewReader(b))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+
+	cols := map[string]int{"word": 0, "alias": 1, "phoneme": -1, "alphabet": -1}
+	if len(records) > 0 {
+		header := map[string]int{}
+		for i, name := range records[0] {
+			header[strings.ToLower(strings.TrimSpace(name))] = i
+		}
+		if _, ok := header["word"]; ok {
+			for name := range cols {
+				cols[name] = -1
+				if i, ok := header[name]; ok {
+					cols[name] = i
+				}
+			}
+			records = records[1:]
+		}
+	}
+	field := func(rec []string, name string) string {
+		if i := cols[name]; i >= 0 && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+
+	var rules []elevenlabs.PronunciationRule
+	for n, rec := range records {
+		word := field(rec, "word")
+		if word == "" {
+			continue
+		}
+		rule := elevenlabs.PronunciationRule{StringToReplace: word}
+		if ph := field(rec, "phoneme"); ph != "" {
+			rule.Type = elevenlabs.RulePhoneme
+			rule.Phoneme = ph
+			rule.Alphabet = field(rec, "alphabet")
+			if rule.Alphabet == "" {
+				rule.Alphabet = "ipa"
+			}
+		} else if alias := field(rec, "alias"); alias != "" {
+			rule.Type = elevenlabs.RuleAlias
+			rule.Alias = alias
+		} else {
+			return nil, fmt.Errorf("row %d: %q has neither an alias nor a phoneme", n+1, word)
+		}
+		rules = append(rules, rule)
+	}
+	if len(rules) == 0 {
+		return nil, errors.New("no rules in " + path)
+	}
+	return rules, nil
+}
+
+func cmdDictList(args []string) {
+	fs := flag.NewFlagSet("dict list", flag.ExitOnError)
+	jsonOut := fs.Bool("json", false, "Print dictionaries as JSON")
+	api := addClientFlags(fs)
+	parseArgs(fs, args)
+
+	dicts, err := api.newClient().ListPronunciationDictionaries(context.Background())
+	if err != nil {
+		fail("dict_list_failed", err)
+	}
+
+	if *jsonOut {
+		printJSON(dicts)
+		return
+	}
+	for _, d := range dicts {
+		fmt.Printf("%s\t%s\t%s\n", d.ID, d.LatestVersionID, d.Name)
+	}
+}
+
+func cmdDictApply(args []string) {
+	fs := flag.NewFlagSet("dict apply", flag.ExitOnError)
+	name := fs.String("name", "", "Dictionary name; an existing dictionary of this name is updated")
+	description := fs.String("description", "", "Description of a new dictionary")
+	lang := fs.String("lang", "en-US", "Language of a new dictionary's lexicon")
+	api := addClientFlags(fs)
+	parseArgs(fs, args)
+
+	if fs.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "ERROR: CSV file argument required (- for stdin)")
+		os.Exit(1)
+	}
+	if *name == "" {
+		fmt.Fprintln(os.Stderr, "ERROR: --name required")
+		os.Exit(1)
+	}
+	rules, err := readRules(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	client := api.newClient()
+	dicts, err := client.ListPronunciationDictionaries(ctx)
+	if err != nil {
+		fail("dict_apply_failed", err)
+	}
+	var existing *elevenlabs.PronunciationDictionary
+	for i, d := range dicts {
+		if d.Name == *name {
+			if existing != nil {
+				fmt.Fprintf(os.Stderr, "ERROR: several dictionaries are named %q (%s, %s)\n", *name, existing.ID, d.ID)
+				os.Exit(1)
+			}
+			existing = &dicts[i]
+		}
+	}
+
+	var v *elevenlabs.DictionaryVersion
+	if existing != nil {
+		otel.Info("dict_update_request", map[string]any{"dictionary_id": existing.ID, "rules": len(rules)})
+		v, err = client.AddPronunciationRules(ctx, existing.ID, rules)
+	} else {
+		var pls bytes.Buffer
+		if err := elevenlabs.WritePLS(&pls, rules, *lang); err != nil {
+			fail("dict_apply_failed", err)
+		}
+		otel.Info("dict_create_request", map[string]any{"name": *name, "rules": len(rules)})
+		v, err = client.AddPronunciationDictionary(ctx, *name, *description, &pls)
+	}
+	if err != nil {
+		fail("dict_apply_failed", err)
+	}
+
+	otel.Info("dict_applied", map[string]any{"dictionary_id": v.ID, "version_id": v.VersionID, "created": existing == nil})
+	fmt.Printf("%s\t%s\n", v.ID, v.VersionID)
+}
+
+func cmdDictPLS(args []string) {
+	fs := flag.NewFlagSet("dict pls", flag.ExitOnError)
+	output := fs.String("output", "-", "Output file")
+	fs.StringVar(output, "o", "-", "Output file")
+	lang := fs.String("lang", "en-US", "Language of the lexicon")
+	parseArgs(fs, args)
+
+	if fs.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "ERROR: CSV file argument required (- for stdin)")
+		os.Exit(1)
+	}
+	rules, err := readRules(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	var pls bytes.Buffer
+	if err := elevenlabs.WritePLS(&pls, rules, *lang); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	if err := writeOutput(*output, &pls); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	printPath(*output)
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"mime"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+	"github.com/pink-tools/pink-otel"
+)
+
+const defaultPollInterval = 10 * time.Second
+
+func cmdDub(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "ERROR: dub subcommand required (create, status, download, subtitles)")
+		os.Exit(1)
+	}
+
+	// Flags without a subcommand, as in `dub --dir episodes -t es`, create.
+	if strings.HasPrefix(args[0], "-") {
+		args = append([]string{"create"}, args...)
+	}
+
+	switch args[0] {
+	case "create":
+		cmdDubCreate(args[1:])
+	case "status":
+		cmdDubStatus(args[1:])
+	case "download":
+		cmdDubDownload(args[1:])
+	case "subtitles":
+		cmdDubSubtitles(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown dub command: %s\n", args[0])
+		os.Exit(1)
+	}
+}
+
+func cmdDubCreate(args []string) {
+	fs := flag.NewFlagSet("dub create", flag.ExitOnError)
+
+	targetLang := fs.String("target-lang", "", "Target language code (e.g. es)")
+	fs.StringVar(targetLang, "t", "", "Target language code")
+	sourceLang := fs.String("source-lang", "auto", "Source language code")
+	name := fs.String("name", "", "Project name")
+	numSpeakers := fs.Int("num-speakers", 0, "Number of speakers (0: detect)")
+	watermark := fs.Bool("watermark", false, "Add a watermark (video only)")
+
+	wait := fs.Bool("wait", false, "Block until dubbing finishes")
+	pollInterval := fs.Duration("poll-interval", defaultPollInterval, "Status poll interval with --wait")
+
+	output := fs.String("output", "", "Download the result here (implies --wait)")
+	fs.StringVar(output, "o", "", "Download the result here")
+	outputDir := fs.String("output-dir", "", "Directory for default output names")
+	fs.StringVar(outputDir, "d", "", "Directory for default output names")
+
+	dir := fs.String("dir", "", "Dub every media file in this directory")
+	concurrency := fs.Int("concurrency", defaultDubConcurrency, "Files dubbed at once with --dir")
+	jsonOut := fs.Bool("json", false, "Print the --dir report as JSON")
+	subtitles := fs.String("subtitles", "", "Also save subtitles (srt or vtt) next to the download")
+
+	api := addClientFlags(fs)
+
+	parseArgs(fs, args)
+
+	if fs.NArg() < 1 && *dir == "" {
+		fmt.Fprintln(os.Stderr, "ERROR: Input file or URL argument required")
+		os.Exit(1)
+	}
+	if *targetLang == "" {
+		fmt.Fprintln(os.Stderr, "ERROR: --target-lang required")
+		os.Exit(1)
+	}
+	if *jsonOut && *dir == "" {
+		fmt.Fprintln(os.Stderr, "ERROR: --json is only for the --dir report")
+		os.Exit(1)
+	}
+
+	req := elevenlabs.DubbingRequest{
+		Name:        *name,
+		SourceLang:  *sourceLang,
+		TargetLang:  *targetLang,
+		NumSpeakers: *numSpeakers,
+		Watermark:   *watermark,
+	}
+	if *subtitles != "" {
+		checkSubtitleFormat(*subtitles)
+	}
+	if *dir != "" {
+		if fs.NArg() > 0 || *output != "" || *name != "" {
+			fmt.Fprintln(os.Stderr, "ERROR: --dir takes no input argument, --output or --name")
+			os.Exit(1)
+		}
+		if *concurrency < 1 {
+			fmt.Fprintln(os.Stderr, "ERROR: --concurrency must be at least 1")
+			os.Exit(1)
+		}
+		dubDir(&dubBatch{
+			client:      api.newClient(),
+			req:         req,
+			outputDir:   *outputDir,
+			concurrency: *concurrency,
+			interval:    *pollInterval,
+			subtitles:   *subtitles,
+		}, *dir, *jsonOut)
+		return
+	}
+
+	input := fs.Arg(0)
+	if strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://") {
+		req.SourceURL = input
+	} else {
+		f, err := os.Open(input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Input file not found: %s\n", input)
+			os.Exit(1)
+		}
+		defer f.Close()
+		req.File = f
+		req.Filename = filepath.Base(input)
+	}
+	if req.Name == "" {
+		req.Name = filepath.Base(input)
+	}
+
+	ctx := context.Background()
+	client := api.newClient()
+
+	otel.Info("dub_create_request", map[string]any{
+		"input":       input,
+		"target_lang": *targetLang,
+	})
+
+	job, err := client.CreateDubbing(ctx, req)
+	if err != nil {
+		fail("dub_create_failed", err)
+	}
+	otel.Info("dub_created", map[string]any{"dubbing_id": job.DubbingID})
+
+	download := *output != "" || *outputDir != "" || *subtitles != ""
+	if !*wait && !download {
+		fmt.Println(job.DubbingID)
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Dubbing %s started (expected %.0fs)\n", job.DubbingID, job.ExpectedDuration)
+	if _, err := waitDubbing(ctx, client, job.DubbingID, *pollInterval); err != nil {
+		fail("dub_failed", err)
+	}
+
+	if !download {
+		fmt.Println(job.DubbingID)
+		return
+	}
+	path, err := downloadDub(ctx, client, job.DubbingID, *targetLang, *output, *outputDir)
+	if err != nil {
+		fail("dub_download_failed", err)
+	}
+	printPath(path)
+	if *subtitles != "" {
+		path, err := saveSubtitles(ctx, client, job.DubbingID, *targetLang, *subtitles, path)
+		if err != nil {
+			fail("dub_subtitles_failed", err)
+		}
+		printPath(path)
+	}
+}
+
+// dubDir dubs every media file in dir into b.outputDir (default: dir) and
+// prints a report, exiting non-zero if any file failed.
+func dubDir(b *dubBatch, dir string, jsonOut bool) {
+	inputs, err := dubBatchInputs(dir, b.req.TargetLang)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	if len(inputs) == 0 {
+		fmt.Fprintf(os.Stderr, "ERROR: no media files in %s\n", dir)
+		os.Exit(1)
+	}
+	if b.outputDir == "" {
+		b.outputDir = dir
+	}
+	if err := os.MkdirAll(b.outputDir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: failed to create output directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Cancelling stops waiting; jobs already started keep running on the
+	// server and can be fetched with dub download.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	otel.Info("dub_batch_start", map[string]any{"dir": dir, "files": len(inputs), "target_lang": b.req.TargetLang, "concurrency": b.concurrency})
+	start := time.Now()
+	results := b.run(ctx, inputs)
+	if err := printDubBatchReport(results, jsonOut, time.Since(start)); err != nil {
+		fail("dub_batch_failed", err)
+	}
+}
+
+func cmdDubStatus(args []string) {
+	fs := flag.NewFlagSet("dub status", flag.ExitOnError)
+
+	wait := fs.Bool("wait", false, "Block until dubbing finishes")
+	pollInterval := fs.Duration("poll-interval", defaultPollInterval, "Status poll interval with --wait")
+	jsonOut := fs.Bool("json", false, "Print the full status as JSON")
+
+	api := addClientFlags(fs)
+
+	parseArgs(fs, args)
+
+	if fs.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "ERROR: Dubbing ID argument required")
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	client := api.newClient()
+	id := fs.Arg(0)
+
+	var d *elevenlabs.Dubbing
+	var err error
+	if *wait {
+		d, err = waitDubbing(ctx, client, id, *pollInterval)
+	} else {
+		d, err = client.GetDubbing(ctx, id)
+	}
+	if err != nil && d == nil {
+		fail("dub_status_failed", err)
+	}
+
+	if *jsonOut {
+		printJSON(d)
+	} else {
+		fmt.Printf("%s\t%s\t%s\n", d.DubbingID, d.Status, strings.Join(d.TargetLanguages, ","))
+	}
+	if err != nil {
+		fail("dub_failed", err)
+	}
+}
+
+func cmdDubDownload(args []string) {
+	fs := flag.NewFlagSet("dub download", flag.ExitOnError)
+
+	lang := fs.String("lang", "", "Language to download (default: the job's only target language)")
+	fs.StringVar(lang, "l", "", "Language to download")
+
+	output := fs.String("output", "", "Output file path")
+	fs.StringVar(output, "o", "", "Output file path")
+	outputDir := fs.String("output-dir", "", "Directory for default output names")
+	fs.StringVar(outputDir, "d", "", "Directory for default output names")
+	subtitles := fs.String("subtitles", "", "Also save subtitles (srt or vtt) next to the download")
+
+	api := addClientFlags(fs)
+
+	parseArgs(fs, args)
+
+	if fs.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "ERROR: Dubbing ID argument required")
+		os.Exit(1)
+	}
+	if *subtitles != "" {
+		checkSubtitleFormat(*subtitles)
+	}
+
+	ctx := context.Background()
+	client := api.newClient()
+	id := fs.Arg(0)
+	language := dubLanguage(ctx, client, id, *lang, "dub_download_failed")
+
+	path, err := downloadDub(ctx, client, id, language, *output, *outputDir)
+	if err != nil {
+		fail("dub_download_failed", err)
+	}
+	printPath(path)
+	if *subtitles != "" {
+		path, err := saveSubtitles(ctx, client, id, language, *subtitles, path)
+		if err != nil {
+			fail("dub_subtitles_failed", err)
+		}
+		printPath(path)
+	}
+}
+
+func cmdDubSubtitles(args []string) {
+	fs := flag.NewFlagSet("dub subtitles", flag.ExitOnError)
+
+	lang := fs.String("lang", "", "Language, source or target (default: the job's only target language)")
+	fs.StringVar(lang, "l", "", "Language")
+	format := fs.String("format", "srt", "Subtitle format: srt or vtt")
+	fs.StringVar(format, "f", "srt", "Subtitle format")
+
+	output := fs.String("output", "", "Output file path, - for stdout")
+	fs.StringVar(output, "o", "", "Output file path")
+	outputDir := fs.String("output-dir", "", "Directory for default output names")
+	fs.StringVar(outputDir, "d", "", "Directory for default output names")
+
+	api := addClientFlags(fs)
+
+	parseArgs(fs, args)
+
+	if fs.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "ERROR: Dubbing ID argument required")
+		os.Exit(1)
+	}
+	apiFormat, ext := checkSubtitleFormat(*format)
+
+	ctx := context.Background()
+	client := api.newClient()
+	id := fs.Arg(0)
+	language := dubLanguage(ctx, client, id, *lang, "dub_subtitles_failed")
+
+	b, err := client.DubbingTranscript(ctx, id, language, apiFormat)
+	if err != nil {
+		fail("dub_subtitles_failed", err)
+	}
+	path, generated := resolveOutput(*output, *outputDir, outputName{Prefix: "dub-" + language, Ext: ext, Seed: id})
+	if err := writeOutput(path, bytes.NewReader(b)); err != nil {
+		if generated {
+			os.Remove(path)
+		}
+		fail("dub_subtitles_failed", err)
+	}
+	otel.Info("dub_subtitles_complete", map[string]any{"dubbing_id": id, "lang": language, "output": path})
+	printPath(path)
+}
+
+// dubLanguage returns lang, or the only target language of the job if lang
+// is empty.
+func dubLanguage(ctx context.Context, client *elevenlabs.Client, id, lang, event string) string {
+	if lang != "" {
+		return lang
+	}
+	d, err := client.GetDubbing(ctx, id)
+	if err != nil {
+		fail(event, err)
+	}
+	if len(d.TargetLanguages) != 1 {
+		fmt.Fprintf(os.Stderr, "ERROR: --lang required, dubbing has languages: %s\n", strings.Join(d.TargetLanguages, ", "))
+		os.Exit(1)
+	}
+	return d.TargetLanguages[0]
+}
+
+// checkSubtitleFormat returns the API format and file extension for srt or
+// vtt, exiting on anything else.
+func checkSubtitleFormat(format string) (apiFormat, ext string) {
+	switch strings.ToLower(format) {
+	case "srt":
+		return elevenlabs.SubtitlesSRT, ".srt"
+	case "vtt", "webvtt":
+		return elevenlabs.SubtitlesVTT, ".vtt"
+	}
+	fmt.Fprintf(os.Stderr, "ERROR: unsupported subtitle format: %s (use srt or vtt)\n", format)
+	os.Exit(1)
+	return "", ""
+}
+
+// saveSubtitles writes the subtitles of lang next to the dubbed media at
+// mediaPath, with the subtitle format's extension.
+func saveSubtitles(ctx context.Context, client *elevenlabs.Client, id, lang, format, mediaPath string) (string, error) {
+	apiFormat, ext := checkSubtitleFormat(format)
+	b, err := client.DubbingTranscript(ctx, id, lang, apiFormat)
+	if err != nil {
+		return "", err
+	}
+	path := strings.TrimSuffix(mediaPath, filepath.Ext(mediaPath)) + ext
+	if mediaPath == "-" {
+		path = "dub-" + safeName(lang, maxNamePart) + "-" + safeName(id, maxNamePart) + ext
+	}
+	if err := writeOutput(path, bytes.NewReader(b)); err != nil {
+		return "", err
+	}
+	otel.Info("dub_subtitles_complete", map[string]any{"dubbing_id": id, "lang": lang, "output": path})
+	return path, nil
+}
+
+// waitDubbing polls until the job finishes, reporting status changes on stderr.
+func waitDubbing(ctx context.Context, client *elevenlabs.Client, id string, interval time.Duration) (*elevenlabs.Dubbing, error) {
+	last := ""
+	return client.WaitDubbing(ctx, id, interval, func(d *elevenlabs.Dubbing) {
+		if d.Status != last {
+			fmt.Fprintf(os.Stderr, "Dubbing %s: %s\n", id, d.Status)
+			last = d.Status
+		}
+	})
+}
+
+func downloadDub(ctx context.Context, client *elevenlabs.Client, id, lang, output, outputDir string) (string, error) {
+	media, err := client.DubbedAudio(ctx, id, lang)
+	if err != nil {
+		return "", err
+	}
+	defer media.Close()
+
+	path, generated := resolveOutput(output, outputDir, outputName{Prefix: "dub-" + lang, Ext: mediaExtension(media.ContentType), Seed: id})
+	if err := writeOutput(path, media); err != nil {
+		if generated {
+			os.Remove(path)
+		}
+		return "", err
+	}
+	otel.Info("dub_download_complete", map[string]any{"dubbing_id": id, "output": path})
+	return path, nil
+}
+
+func mediaExtension(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "audio/mpeg":
+		return ".mp3"
+	case "video/mp4":
+		return ".mp4"
+	}
+	if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+)
+
+func TestDubCreateJSONNeedsDir(t *testing.T) {
+	stdout, stderr, code := runMain(t, []string{"ELEVENLABS_API_KEY=key"}, "dub", "create", "--json", "-t", "es", "episode.mp4")
+	if code != 1 || len(stdout) > 0 || !bytes.Contains(stderr, []byte("--json is only for the --dir report")) {
+		t.Errorf("exit %d, stdout %q, stderr %q; want a usage error", code, stdout, stderr)
+	}
+}
+
+func TestSaveSubtitlesSafeName(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("WEBVTT\n"))
+	}))
+	defer srv.Close()
+	t.Chdir(t.TempDir())
+
+	client := elevenlabs.NewClient("key", elevenlabs.WithBaseURL(srv.URL))
+	path, err := saveSubtitles(context.Background(), client, "../a/b", "es:MX", "vtt", "-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "dub-es-MX--a-b.vtt"; path != want {
+		t.Errorf("path = %q, want %q", path, want)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+	"github.com/pink-tools/pink-otel"
+)
+
+const defaultDubConcurrency = 3
+
+// dubMediaExtensions are the files dub --dir picks up.
+var dubMediaExtensions = map[string]bool{
+	".mp4": true, ".mov": true, ".mkv": true, ".webm": true, ".avi": true,
+	".mp3": true, ".wav": true, ".m4a": true, ".aac": true, ".flac": true, ".ogg": true, ".opus": true,
+}
+
+// Outcomes of a file in a batch besides the API's dubbing states.
+const dubSkipped = "skipped"
+
+// dubBatchResult is one line of the report of dub --dir.
+type dubBatchResult struct {
+	Input      string  `json:"input"`
+	Status     string  `json:"status"`
+	DubbingID  string  `json:"dubbing_id,omitempty"`
+	Output     string  `json:"output,omitempty"`
+	Subtitles  string  `json:"subtitles,omitempty"`
+	Error      string  `json:"error,omitempty"`
+	ElapsedSec float64 `json:"elapsed_sec"`
+
+	err error
+}
+
+// dubBatchOutput returns the existing dub of input into lang in dir, or "".
+// The directory is listed since glob escapes don't work on Windows.
+func dubBatchOutput(dir, input, lang string) string {
+	prefix := strings.TrimSuffix(filepath.Base(input), filepath.Ext(input)) + "." + lang + "."
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), prefix) {
+			return filepath.Join(dir, e.Name())
+		}
+	}
+	return ""
+}
+
+// dubBatchInputs lists the media files directly in dir, leaving out earlier
+// dubs into lang.
+func dubBatchInputs(dir, lang string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+	var inputs []string
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if e.IsDir() || !dubMediaExtensions[ext] {
+			continue
+		}
+		if strings.HasSuffix(strings.TrimSuffix(e.Name(), filepath.Ext(e.Name())), "."+lang) {
+			continue
+		}
+		inputs = append(inputs, filepath.Join(dir, e.Name()))
+	}
+	sort.Strings(inputs)
+	return inputs, nil
+}
+
+// dubBatch dubs many files into one language.
+type dubBatch struct {
+	client      *elevenlabs.Client
+	req         elevenlabs.DubbingRequest
+	outputDir   string
+	concurrency int
+	interval    time.Duration
+	// subtitles is the subtitle format to save next to each dub, if any.
+	subtitles string
+}
+
+// run uploads, waits for and downloads every input, at most concurrency at
+// a time. Inputs already dubbed into outputDir are skipped.
+func (b *dubBatch) run(ctx context.Context, inputs []string) []dubBatchResult {
+	results := make([]dubBatchResult, len(inputs))
+	sem := make(chan struct{}, b.concurrency)
+	var wg sync.WaitGroup
+	for i, input := range inputs {
+		results[i].Input = input
+		if out := dubBatchOutput(b.outputDir, input, b.req.TargetLang); out != "" {
+			results[i].Status = dubSkipped
+			results[i].Output = out
+			continue
+		}
+		wg.Add(1)
+		go func(r *dubBatchResult) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				r.Status, r.err = elevenlabs.DubbingFailed, ctx.Err()
+				r.Error = r.err.Error()
+				return
+			}
+			defer func() { <-sem }()
+
+			start := time.Now()
+			r.err = b.file(ctx, r)
+			r.ElapsedSec = time.Since(start).Round(time.Second).Seconds()
+			r.Status = elevenlabs.DubbingDone
+			if r.err != nil {
+				r.Status, r.Error = elevenlabs.DubbingFailed, r.err.Error()
+				fmt.Fprintf(os.Stderr, "Failed %s: %v\n", filepath.Base(r.Input), r.err)
+				return
+			}
+			fmt.Fprintf(os.Stderr, "Finished %s: %s\n", filepath.Base(r.Input), r.Output)
+		}(&results[i])
+	}
+	wg.Wait()
+	return results
+}
+
+func (b *dubBatch) file(ctx context.Context, r *dubBatchResult) error {
+	f, err := os.Open(r.Input)
+	if err != nil {
+		return err
+	}
+	req := b.req
+	req.File = f
+	req.Filename = filepath.Base(r.Input)
+	if req.Name == "" {
+		req.Name = req.Filename
+	}
+	otel.Info("dub_create_request", map[string]any{"input": r.Input, "target_lang": req.TargetLang})
+	job, err := b.client.CreateDubbing(ctx, req)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	r.DubbingID = job.DubbingID
+	otel.Info("dub_created", map[string]any{"dubbing_id": job.DubbingID, "input": r.Input})
+	fmt.Fprintf(os.Stderr, "Dubbing %s: started for %s\n", job.DubbingID, filepath.Base(r.Input))
+
+	if _, err := waitDubbing(ctx, b.client, job.DubbingID, b.interval); err != nil {
+		return err
+	}
+	stem := strings.TrimSuffix(filepath.Base(r.Input), filepath.Ext(r.Input))
+	r.Output, err = downloadDub(ctx, b.client, job.DubbingID, req.TargetLang, filepath.Join(b.outputDir, stem+"."+req.TargetLang+"{ext}"), "")
+	if err != nil || b.subtitles == "" {
+		return err
+	}
+	r.Subtitles, err = saveSubtitles(ctx, b.client, job.DubbingID, req.TargetLang, b.subtitles, r.Output)
+	return err
+}
+
+// printDubBatchReport prints one line per input and returns the first error.
+func printDubBatchReport(results []dubBatchResult, jsonOut bool, elapsed time.Duration) error {
+	counts := map[string]int{}
+	var first error
+	for _, r := range results {
+		counts[r.Status]++
+		if first == nil {
+			first = r.err
+		}
+	}
+
+	if jsonOut {
+		printJSON(results)
+	} else {
+		for _, r := range results {
+			detail := r.Output
+			if r.err != nil {
+				detail = r.Error
+			}
+			fmt.Printf("%s\t%s\t%s\t%s\n", r.Input, r.Status, r.DubbingID, detail)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Dubbed %d, skipped %d, failed %d in %s\n",
+		counts[elevenlabs.DubbingDone], counts[dubSkipped], counts[elevenlabs.DubbingFailed], elapsed.Round(time.Second))
+	otel.Info("dub_batch_complete", map[string]any{
+		"dubbed":  counts[elevenlabs.DubbingDone],
+		"skipped": counts[dubSkipped],
+		"failed":  counts[elevenlabs.DubbingFailed],
+	})
+
+	if first != nil {
+		return fmt.Errorf("%d of %d files failed, first: %w", counts[elevenlabs.DubbingFailed], len(results), first)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package elevenlabs
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	paragraphBreak = regexp.MustCompile(`\n\s*\n`)
+	sentenceEnd    = regexp.MustCompile(`[.!?…。！？]+["'”’)\]]*\s+`)
+
+	invisibleChars = regexp.MustCompile(`[\x{200b}-\x{200d}\x{2060}\x{feff}\x{00ad}\x00-\x08\x0b\x0c\x0e-\x1f\x7f]`)
+	inlineSpace    = regexp.MustCompile(`[ \t\x{00a0}]+`)
+	blankLines     = regexp.MustCompile(`\n{3,}`)
+)
+
+// NormalizeText prepares text for synthesis. The result is what the API
+// bills for.
+func NormalizeText(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	text = invisibleChars.ReplaceAllString(text, "")
+	text = inlineSpace.ReplaceAllString(text, " ")
+
+	lines := strings.Split(text, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(l)
+	}
+	text = strings.Join(lines, "\n")
+	text = blankLines.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
+
+// SplitText normalizes text and splits it into chunks of at most maxChars
+// characters, preferring paragraph, sentence and word breaks.
+func SplitText(text string, maxChars int) []string {
+	text = NormalizeText(text)
+	if text == "" {
+		return nil
+	}
+	if maxChars <= 0 {
+		maxChars = defaultModelInfo.MaxChars
+	}
+
+	var pieces []string
+	for _, para := range paragraphBreak.Split(text, -1) {
+		para = strings.TrimSpace(para)
+		if para != "" {
+			pieces = append(pieces, splitPiece(para, maxChars)...)
+		}
+	}
+	return pack(pieces, maxChars)
+}
+
+// splitPiece breaks a single paragraph into parts no longer than maxChars.
+// Only the first part of a paragraph is marked with a leading "\n\n" by pack.
+func splitPiece(para string, maxChars int) []string {
+	if utf8.RuneCountInString(para) <= maxChars {
+		return []string{"\n\n" + para}
+	}
+
+	var sentences []string
+	last := 0
+	for _, loc := range sentenceEnd.FindAllStringIndex(para, -1) {
+		sentences = append(sentences, strings.TrimSpace(para[last:loc[1]]))
+		last = loc[1]
+	}
+	if last < len(para) {
+		sentences = append(sentences, strings.TrimSpace(para[last:]))
+	}
+
+	var parts []string
+	for _, s := range sentences {
+		if utf8.RuneCountInString(s) <= maxChars {
+			parts = append(parts, s)
+			continue
+		}
+		for _, word := range strings.Fields(s) {
+			for utf8.RuneCountInString(word) > maxChars {
+				r := []rune(word)
+				parts = append(parts, string(r[:maxChars]))
+				word = string(r[maxChars:])
+			}
+			parts = append(parts, word)
+		}
+	}
+	parts[0] = "\n\n" + parts[0]
+	return parts
+}
+
+// pack greedily merges pieces into chunks. Pieces starting with "\n\n" begin
+// a new paragraph; the rest are joined with a space.
+func pack(pieces []string, maxChars int) []string {
+	var chunks []string
+	var cur strings.Builder
+	curLen := 0
+	for _, p := range pieces {
+		sep := " "
+		if strings.HasPrefix(p, "\n\n") {
+			p = p[2:]
+			sep = "\n\n"
+		}
+		n := utf8.RuneCountInString(p)
+		if curLen > 0 && curLen+len(sep)+n > maxChars {
+			chunks = append(chunks, cur.String())
+			cur.Reset()
+			curLen = 0
+		}
+		if curLen > 0 {
+			cur.WriteString(sep)
+			curLen += len(sep)
+		}
+		cur.WriteString(p)
+		curLen += n
+	}
+	if curLen > 0 {
+		chunks = append(chunks, cur.String())
+	}
+	return chunks
+}
@@ -0,0 +1,59 @@
+package elevenlabs_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+)
+
+func TestSplitText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		max  int
+		want []string
+	}{
+		{"empty", " \n​ ", 100, nil},
+		{"fits", "One. Two.", 100, []string{"One. Two."}},
+		{"sentences", "One two. Three four! Five six?", 12,
+			[]string{"One two.", "Three four!", "Five six?"}},
+		{"sentences packed", "A. B. C. D.", 5, []string{"A. B.", "C. D."}},
+		{"paragraphs", "First para.\n\n\nSecond para.", 100, []string{"First para.\n\nSecond para."}},
+		{"paragraph break preferred", "First one.\n\nSecond one.", 15, []string{"First one.", "Second one."}},
+		{"closing quote", `He said "Stop." Then left.`, 16, []string{`He said "Stop."`, "Then left."}},
+		{"cjk", "こんにちは。さようなら。", 6, []string{"こんにちは。", "さようなら。"}},
+		{"words", "alpha beta gamma delta", 11, []string{"alpha beta", "gamma delta"}},
+		{"long word", "abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"multibyte word", "ääääää", 4, []string{"ääää", "ää"}},
+		{"normalized", "a  b\r\nc​", 100, []string{"a b\nc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := elevenlabs.SplitText(tt.text, tt.max)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitText(%q, %d) = %q, want %q", tt.text, tt.max, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitTextLimit(t *testing.T) {
+	text := strings.Repeat("Lorem ipsum dolor sit amet, consectetur adipiscing elit. ", 200) +
+		"\n\n" + strings.Repeat("Sed do eiusmod tempor incididunt ut labore! ", 150)
+	for _, max := range []int{50, 300, 1000, 5000} {
+		chunks := elevenlabs.SplitText(text, max)
+		var words []string
+		for _, c := range chunks {
+			if n := utf8.RuneCountInString(c); n > max || n == 0 {
+				t.Errorf("max %d: chunk of %d characters", max, n)
+			}
+			words = append(words, strings.Fields(c)...)
+		}
+		if !reflect.DeepEqual(words, strings.Fields(text)) {
+			t.Errorf("max %d: chunks don't hold the text's words in order", max)
+		}
+	}
+}
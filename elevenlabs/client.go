@@ -0,0 +1,175 @@
+// Package elevenlabs is a small client for the ElevenLabs HTTP API.
+package elevenlabs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultBaseURL = "https://api.elevenlabs.io/v1"
+
+	DefaultTTSModel = "eleven_v3"
+	DefaultSTSModel = "eleven_multilingual_sts_v2"
+
+	// defaultHeaderTimeout bounds the wait for a response to start. There's
+	// no overall timeout because streamed bodies may be read at playback pace.
+	defaultHeaderTimeout = 120 * time.Second
+)
+
+// Client talks to the ElevenLabs API. It is safe for concurrent use.
+type Client struct {
+	apiKey     string
+	bearer     bool
+	endpoints  *endpoints
+	onFailover func(from, to string, err error)
+	httpClient *http.Client
+	retry      RetryPolicy
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithBaseURL overrides the API base URL, e.g. for a gateway or a test server.
+func WithBaseURL(baseURL string) Option {
+	return WithBaseURLs(baseURL)
+}
+
+// WithHTTPClient replaces the default HTTP client.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithBearerAuth sends the key as a bearer token instead of as xi-api-key.
+func WithBearerAuth() Option {
+	return func(c *Client) {
+		c.bearer = true
+	}
+}
+
+// NewClient returns a Client authenticating with apiKey.
+func NewClient(apiKey string, opts ...Option) *Client {
+	c := &Client{
+		apiKey:     apiKey,
+		endpoints:  newEndpoints([]string{DefaultBaseURL}),
+		httpClient: &http.Client{Transport: defaultTransport()},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func defaultTransport() http.RoundTripper {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.ResponseHeaderTimeout = defaultHeaderTimeout
+	return t
+}
+
+// Audio is an audio response body. Callers must Close it.
+type Audio struct {
+	io.ReadCloser
+	ContentType string
+	RequestID   string
+	// CharacterCount is the number of characters billed for the request,
+	// or -1 if the API didn't report it.
+	CharacterCount int
+}
+
+func newAudio(resp *http.Response) *Audio {
+	a := &Audio{
+		ReadCloser:     resp.Body,
+		ContentType:    resp.Header.Get("Content-Type"),
+		RequestID:      resp.Header.Get("request-id"),
+		CharacterCount: -1,
+	}
+	if n, err := strconv.Atoi(resp.Header.Get("x-character-count")); err == nil {
+		a.CharacterCount = n
+	}
+	return a
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoints.pick()+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set(c.authHeader())
+	return req, nil
+}
+
+// authHeader returns the name and value of the header that authenticates
+// a request.
+func (c *Client) authHeader() (string, string) {
+	if c.bearer {
+		return "Authorization", "Bearer " + c.apiKey
+	}
+	return "xi-api-key", c.apiKey
+}
+
+// do sends req, with failover and retries, and returns the response if it
+// has a 2xx status. req needs GetBody set whenever it has a body.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	tried := map[string]bool{}
+	for attempt := 1; ; {
+		resp, err := c.send(req)
+		if err == nil {
+			c.endpoints.ok(c.endpoints.base(req.URL.String()))
+			return resp, nil
+		}
+		if req.Body != nil && req.GetBody == nil {
+			return nil, err
+		}
+		if to, ok := c.failover(req, err, tried); ok {
+			if req, err = c.resend(req, to); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		delay, ok := c.retry.delay(attempt, err)
+		if !ok {
+			return nil, err
+		}
+		if c.retry.OnRetry != nil {
+			c.retry.OnRetry(attempt, delay, err)
+		}
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+		attempt++
+
+		// A retry starts over from the healthiest base URL.
+		clear(tried)
+		if req, err = c.resend(req, c.endpoints.pick()); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newAPIError(resp, body)
+	}
+	return resp, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, out any) error {
+	req, err := c.newRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return err
+	}
+	return c.sendJSON(req, out)
+}
@@ -0,0 +1,305 @@
+package elevenlabs_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+)
+
+// respond serves the statuses in turn, then 200 with the body "ok", and
+// counts the requests. A request body must be the same every time.
+type respond struct {
+	statuses   []int
+	retryAfter string
+	requests   atomic.Int32
+}
+
+func (s *respond) server(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(s.requests.Add(1))
+		if body, _ := io.ReadAll(r.Body); r.Method == "POST" && string(body) != `{"text":"hi","model_id":"eleven_v3"}` {
+			t.Errorf("request %d body = %q", n, body)
+		}
+		if n <= len(s.statuses) {
+			if s.retryAfter != "" {
+				w.Header().Set("Retry-After", s.retryAfter)
+			}
+			w.WriteHeader(s.statuses[n-1])
+			w.Write([]byte(`{"detail":{"status":"busy","message":"try later"}}`))
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func tts(c *elevenlabs.Client) error {
+	a, err := c.TextToSpeech(context.Background(), "voice", elevenlabs.TTSRequest{Text: "hi"})
+	if err == nil {
+		a.Close()
+	}
+	return err
+}
+
+func TestRetryBackoff(t *testing.T) {
+	s := &respond{statuses: []int{500, 503, 429}}
+	var delays []time.Duration
+	c := elevenlabs.NewClient("key", elevenlabs.WithBaseURL(s.server(t).URL), elevenlabs.WithRetry(elevenlabs.RetryPolicy{
+		MaxRetries: 3,
+		BaseDelay:  time.Millisecond,
+		OnRetry:    func(_ int, d time.Duration, _ error) { delays = append(delays, d) },
+	}))
+	if err := tts(c); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.requests.Load(); n != 4 || len(delays) != 3 {
+		t.Errorf("%d requests after %d waits, want 4 after 3", n, len(delays))
+	}
+	// Each delay doubles, plus up to half again of jitter.
+	for i, d := range delays {
+		if lo := time.Millisecond << i; d < lo || d > lo*3/2 {
+			t.Errorf("delay %d = %v, want %v to %v", i+1, d, lo, lo*3/2)
+		}
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	s := &respond{statuses: []int{500, 500, 500, 500}}
+	c := elevenlabs.NewClient("key", elevenlabs.WithBaseURL(s.server(t).URL),
+		elevenlabs.WithRetry(elevenlabs.RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond}))
+	if err := tts(c); !errors.Is(err, elevenlabs.ErrServer) {
+		t.Errorf("got %v, want ErrServer", err)
+	}
+	if n := s.requests.Load(); n != 3 {
+		t.Errorf("%d requests, want 3", n)
+	}
+}
+
+func TestRetrySkipsPermanentErrors(t *testing.T) {
+	s := &respond{statuses: []int{400}}
+	c := elevenlabs.NewClient("key", elevenlabs.WithBaseURL(s.server(t).URL),
+		elevenlabs.WithRetry(elevenlabs.RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond}))
+	if err := tts(c); err == nil {
+		t.Fatal("no error")
+	}
+	if n := s.requests.Load(); n != 1 {
+		t.Errorf("%d requests, want 1", n)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	s := &respond{statuses: []int{429}, retryAfter: "1"}
+	var delay time.Duration
+	c := elevenlabs.NewClient("key", elevenlabs.WithBaseURL(s.server(t).URL), elevenlabs.WithRetry(elevenlabs.RetryPolicy{
+		MaxRetries: 1,
+		MaxWait:    2 * time.Second,
+		BaseDelay:  time.Millisecond,
+		OnRetry:    func(_ int, d time.Duration, _ error) { delay = d },
+	}))
+	start := time.Now()
+	if err := tts(c); err != nil {
+		t.Fatal(err)
+	}
+	if delay != time.Second || time.Since(start) < time.Second {
+		t.Errorf("waited %v (asked for %v), want Retry-After's 1s", time.Since(start), delay)
+	}
+}
+
+func TestRetryAfterOverMaxWait(t *testing.T) {
+	s := &respond{statuses: []int{429}, retryAfter: "60"}
+	c := elevenlabs.NewClient("key", elevenlabs.WithBaseURL(s.server(t).URL),
+		elevenlabs.WithRetry(elevenlabs.RetryPolicy{MaxRetries: 3, MaxWait: time.Second}))
+	start := time.Now()
+	err := tts(c)
+	var apiErr *elevenlabs.APIError
+	if !errors.As(err, &apiErr) || !errors.Is(err, elevenlabs.ErrRateLimited) || apiErr.RetryAfter != time.Minute {
+		t.Errorf("got %v, want a rate limit error asking for 1m", err)
+	}
+	if n := s.requests.Load(); n != 1 || time.Since(start) > time.Second {
+		t.Errorf("%d requests in %v, want 1 without waiting", n, time.Since(start))
+	}
+}
+
+func TestMaxWaitCapsBackoff(t *testing.T) {
+	s := &respond{statuses: []int{500, 500}}
+	var delays []time.Duration
+	c := elevenlabs.NewClient("key", elevenlabs.WithBaseURL(s.server(t).URL), elevenlabs.WithRetry(elevenlabs.RetryPolicy{
+		MaxRetries: 2,
+		MaxWait:    5 * time.Millisecond,
+		BaseDelay:  time.Hour,
+		OnRetry:    func(_ int, d time.Duration, _ error) { delays = append(delays, d) },
+	}))
+	if err := tts(c); err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range delays {
+		if d != 5*time.Millisecond {
+			t.Errorf("delay = %v, want MaxWait", d)
+		}
+	}
+}
+
+func TestAPIErrorSentinels(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   []error
+		temp   bool
+	}{
+		{"unauthorized", 401, `{"detail":{"status":"invalid_api_key","message":"bad key"}}`,
+			[]error{elevenlabs.ErrUnauthorized}, false},
+		{"quota on 401", 401, `{"detail":{"status":"quota_exceeded","message":"no credits"}}`,
+			[]error{elevenlabs.ErrQuotaExceeded}, false},
+		{"quota on 429", 429, `{"detail":{"status":"quota_exceeded"}}`,
+			[]error{elevenlabs.ErrQuotaExceeded}, false},
+		{"rate limited", 429, `{"detail":{"status":"too_many_concurrent_requests"}}`,
+			[]error{elevenlabs.ErrRateLimited}, true},
+		{"voice not found", 404, `{"detail":{"status":"voice_not_found","message":"no such voice"}}`,
+			[]error{elevenlabs.ErrInvalidVoice}, false},
+		{"invalid voice", 400, `{"detail":{"status":"invalid_voice_id"}}`,
+			[]error{elevenlabs.ErrInvalidVoice}, false},
+		{"server", 502, `bad gateway`, []error{elevenlabs.ErrServer}, true},
+		{"format by status", 403, `{"detail":{"status":"output_format_not_allowed"}}`,
+			[]error{elevenlabs.ErrFormatNotAllowed}, false},
+		{"format by message", 403, `{"detail":{"message":"This Output Format requires Pro"}}`,
+			[]error{elevenlabs.ErrFormatNotAllowed}, false},
+		{"string detail", 422, `{"detail":"text is required"}`, nil, false},
+		{"timeout", 408, ``, nil, true},
+	}
+	sentinels := []error{
+		elevenlabs.ErrUnauthorized, elevenlabs.ErrQuotaExceeded, elevenlabs.ErrRateLimited,
+		elevenlabs.ErrInvalidVoice, elevenlabs.ErrServer, elevenlabs.ErrFormatNotAllowed,
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+			_, err := elevenlabs.NewClient("key", elevenlabs.WithBaseURL(srv.URL)).User(context.Background())
+
+			var apiErr *elevenlabs.APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+				t.Fatalf("got %v, want an APIError with status %d", err, tt.status)
+			}
+			for _, s := range sentinels {
+				want := false
+				for _, w := range tt.want {
+					want = want || w == s
+				}
+				if errors.Is(err, s) != want {
+					t.Errorf("errors.Is(%v, %v) = %v, want %v", err, s, !want, want)
+				}
+			}
+			if apiErr.Temporary() != tt.temp {
+				t.Errorf("Temporary() = %v, want %v", !tt.temp, tt.temp)
+			}
+		})
+	}
+}
+
+func TestAPIErrorMessage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(401)
+		w.Write([]byte(`{"detail":{"status":"invalid_api_key","message":"Invalid API key"}}`))
+	}))
+	defer srv.Close()
+	_, err := elevenlabs.NewClient("key", elevenlabs.WithBaseURL(srv.URL)).User(context.Background())
+	if got, want := err.Error(), "API error 401: Invalid API key"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestFailover(t *testing.T) {
+	var primary, secondary atomic.Int32
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primary.Add(1)
+		w.WriteHeader(503)
+	}))
+	defer down.Close()
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secondary.Add(1)
+		if r.URL.Path != "/v1/text-to-speech/voice" || r.Header.Get("xi-api-key") != "key" {
+			t.Errorf("failed over to %s without the key", r.URL)
+		}
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte("ok"))
+	}))
+	defer up.Close()
+
+	var hops []string
+	c := elevenlabs.NewClient("key",
+		elevenlabs.WithBaseURLs(down.URL+"/v1", up.URL+"/v1/"),
+		elevenlabs.WithFailoverHook(func(from, to string, err error) { hops = append(hops, from+" -> "+to) }))
+	for range 2 {
+		if err := tts(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The failed base URL is passed over for the second request.
+	if p, s := primary.Load(), secondary.Load(); p != 1 || s != 2 {
+		t.Errorf("primary got %d requests and secondary %d, want 1 and 2", p, s)
+	}
+	if want := down.URL + "/v1 -> " + up.URL + "/v1"; len(hops) != 1 || hops[0] != want {
+		t.Errorf("failovers = %q, want %q", hops, want)
+	}
+}
+
+func TestFailoverSkipsClientErrors(t *testing.T) {
+	var secondary atomic.Int32
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(401)
+	}))
+	defer bad.Close()
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secondary.Add(1)
+	}))
+	defer other.Close()
+
+	c := elevenlabs.NewClient("key", elevenlabs.WithBaseURLs(bad.URL, other.URL))
+	if _, err := c.User(context.Background()); !errors.Is(err, elevenlabs.ErrUnauthorized) {
+		t.Errorf("got %v, want ErrUnauthorized", err)
+	}
+	if n := secondary.Load(); n != 0 {
+		t.Errorf("a 401 failed over to the next base URL")
+	}
+}
+
+func TestFailoverThenRetry(t *testing.T) {
+	a := &respond{statuses: []int{500}}
+	b := &respond{statuses: []int{500}}
+	c := elevenlabs.NewClient("key",
+		elevenlabs.WithBaseURLs(a.server(t).URL, b.server(t).URL),
+		elevenlabs.WithRetry(elevenlabs.RetryPolicy{MaxRetries: 1, BaseDelay: time.Millisecond}))
+	if err := tts(c); err != nil {
+		t.Fatal(err)
+	}
+	if n := a.requests.Load() + b.requests.Load(); n != 3 {
+		t.Errorf("%d requests, want 3", n)
+	}
+}
+
+func TestBearerAuth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" || r.Header.Get("xi-api-key") != "" {
+			w.WriteHeader(401)
+			return
+		}
+		w.Write([]byte(`{"user_id":"u"}`))
+	}))
+	defer srv.Close()
+	c := elevenlabs.NewClient("token", elevenlabs.WithBaseURL(srv.URL), elevenlabs.WithBearerAuth())
+	if _, err := c.User(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,300 @@
+package elevenlabs
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Conversation summarizes one past Conversational AI session.
+type Conversation struct {
+	AgentID          string `json:"agent_id"`
+	AgentName        string `json:"agent_name,omitempty"`
+	ConversationID   string `json:"conversation_id"`
+	StartTimeUnix    int64  `json:"start_time_unix_secs"`
+	CallDurationSecs int    `json:"call_duration_secs"`
+	MessageCount     int    `json:"message_count"`
+	Status           string `json:"status"`
+	// CallSuccessful is the agent's evaluation: success, failure or unknown.
+	CallSuccessful string `json:"call_successful"`
+}
+
+// StartTime returns when the conversation started.
+func (c *Conversation) StartTime() time.Time {
+	return time.Unix(c.StartTimeUnix, 0)
+}
+
+// ConversationTurn is one message of a conversation transcript.
+type ConversationTurn struct {
+	// Role is "agent" or "user".
+	Role           string          `json:"role"`
+	Message        string          `json:"message"`
+	TimeInCallSecs float64         `json:"time_in_call_secs"`
+	ToolCalls      json.RawMessage `json:"tool_calls,omitempty"`
+	ToolResults    json.RawMessage `json:"tool_results,omitempty"`
+}
+
+// ConversationDetail is a conversation with its transcript.
+type ConversationDetail struct {
+	AgentID        string             `json:"agent_id"`
+	ConversationID string             `json:"conversation_id"`
+	Status         string             `json:"status"`
+	Transcript     []ConversationTurn `json:"transcript"`
+	Metadata       struct {
+		StartTimeUnix    int64 `json:"start_time_unix_secs"`
+		CallDurationSecs int   `json:"call_duration_secs"`
+	} `json:"metadata"`
+	// Analysis holds the evaluation results and summary as the API
+	// returns them.
+	Analysis json.RawMessage `json:"analysis,omitempty"`
+	HasAudio bool            `json:"has_audio"`
+}
+
+// ConversationQuery filters ListConversations. Zero values don't filter.
+type ConversationQuery struct {
+	AgentID string
+	After   time.Time
+	Before  time.Time
+	// Limit caps the number returned; zero returns all.
+	Limit int
+}
+
+// ListConversations returns past conversations, newest first.
+func (c *Client) ListConversations(ctx context.Context, q ConversationQuery) ([]Conversation, error) {
+	v := url.Values{"page_size": {"100"}}
+	if q.AgentID != "" {
+		v.Set("agent_id", q.AgentID)
+	}
+	if !q.After.IsZero() {
+		v.Set("call_start_after_unix", strconv.FormatInt(q.After.Unix(), 10))
+	}
+	if !q.Before.IsZero() {
+		v.Set("call_start_before_unix", strconv.FormatInt(q.Before.Unix(), 10))
+	}
+
+	var all []Conversation
+	for {
+		var page struct {
+			Conversations []Conversation `json:"conversations"`
+			HasMore       bool           `json:"has_more"`
+			NextCursor    string         `json:"next_cursor"`
+		}
+		if err := c.getJSON(ctx, "/convai/conversations?"+v.Encode(), &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page.Conversations...)
+		if q.Limit > 0 && len(all) >= q.Limit {
+			return all[:q.Limit], nil
+		}
+		if !page.HasMore || page.NextCursor == "" {
+			return all, nil
+		}
+		v.Set("cursor", page.NextCursor)
+	}
+}
+
+// GetConversation returns a conversation with its transcript.
+func (c *Client) GetConversation(ctx context.Context, conversationID string) (*ConversationDetail, error) {
+	var d ConversationDetail
+	if err := c.getJSON(ctx, "/convai/conversations/"+url.PathEscape(conversationID), &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// ConversationAudio downloads the recording of a conversation.
+func (c *Client) ConversationAudio(ctx context.Context, conversationID string) (*Audio, error) {
+	req, err := c.newRequest(ctx, "GET", "/convai/conversations/"+url.PathEscape(conversationID)+"/audio", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return newAudio(resp), nil
+}
+
+// Agent is a Conversational AI agent.
+type Agent struct {
+	AgentID string `json:"agent_id"`
+	Name    string `json:"name"`
+}
+
+// Knowledge document types.
+const (
+	KnowledgeFile = "file"
+	KnowledgeURL  = "url"
+	KnowledgeText = "text"
+)
+
+// KnowledgeDocument is a document in the account's agent knowledge base.
+type KnowledgeDocument struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Type is KnowledgeFile, KnowledgeURL or KnowledgeText.
+	Type string `json:"type"`
+}
+
+// AgentConfig is the part of an agent's configuration kept in files. Empty
+// fields are left unchanged by UpdateAgent.
+type AgentConfig struct {
+	Name         string
+	Prompt       string
+	FirstMessage string
+	Language     string
+	LLM          string
+	VoiceID      string
+	// Knowledge replaces the agent's knowledge base when not nil.
+	Knowledge []KnowledgeDocument
+	// Tools replaces the agent's tools when not nil. Each is a tool
+	// definition as the API takes it, such as a webhook or client tool.
+	Tools []map[string]any
+}
+
+func (a *AgentConfig) body() map[string]any {
+	set := func(m map[string]any, key, value string) {
+		if value != "" {
+			m[key] = value
+		}
+	}
+	prompt := map[string]any{}
+	set(prompt, "prompt", a.Prompt)
+	set(prompt, "llm", a.LLM)
+	if a.Knowledge != nil {
+		kb := make([]map[string]any, len(a.Knowledge))
+		for i, d := range a.Knowledge {
+			kb[i] = map[string]any{"type": d.Type, "name": d.Name, "id": d.ID}
+		}
+		prompt["knowledge_base"] = kb
+	}
+	if a.Tools != nil {
+		prompt["tools"] = a.Tools
+	}
+
+	agent := map[string]any{"prompt": prompt}
+	set(agent, "first_message", a.FirstMessage)
+	set(agent, "language", a.Language)
+	conv := map[string]any{"agent": agent}
+	if a.VoiceID != "" {
+		conv["tts"] = map[string]any{"voice_id": a.VoiceID}
+	}
+
+	body := map[string]any{"conversation_config": conv}
+	set(body, "name", a.Name)
+	return body
+}
+
+// MarshalJSON encodes the request body CreateAgent and UpdateAgent send.
+func (a *AgentConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.body())
+}
+
+// ListAgents returns every agent of the account.
+func (c *Client) ListAgents(ctx context.Context) ([]Agent, error) {
+	var all []Agent
+	v := url.Values{"page_size": {"100"}}
+	for {
+		var page struct {
+			Agents     []Agent `json:"agents"`
+			HasMore    bool    `json:"has_more"`
+			NextCursor string  `json:"next_cursor"`
+		}
+		if err := c.getJSON(ctx, "/convai/agents?"+v.Encode(), &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page.Agents...)
+		if !page.HasMore || page.NextCursor == "" {
+			return all, nil
+		}
+		v.Set("cursor", page.NextCursor)
+	}
+}
+
+// CreateAgent creates an agent and returns its ID.
+func (c *Client) CreateAgent(ctx context.Context, cfg *AgentConfig) (string, error) {
+	req, err := c.jsonRequest(ctx, "POST", "/convai/agents/create", cfg)
+	if err != nil {
+		return "", err
+	}
+	var a Agent
+	if err := c.sendJSON(req, &a); err != nil {
+		return "", err
+	}
+	return a.AgentID, nil
+}
+
+// UpdateAgent changes the configuration of an agent.
+func (c *Client) UpdateAgent(ctx context.Context, agentID string, cfg *AgentConfig) error {
+	req, err := c.jsonRequest(ctx, "PATCH", "/convai/agents/"+url.PathEscape(agentID), cfg)
+	if err != nil {
+		return err
+	}
+	return c.sendJSON(req, nil)
+}
+
+// ListKnowledgeDocuments returns every document in the knowledge base.
+func (c *Client) ListKnowledgeDocuments(ctx context.Context) ([]KnowledgeDocument, error) {
+	var all []KnowledgeDocument
+	v := url.Values{"page_size": {"100"}}
+	for {
+		var page struct {
+			Documents  []KnowledgeDocument `json:"documents"`
+			HasMore    bool                `json:"has_more"`
+			NextCursor string              `json:"next_cursor"`
+		}
+		if err := c.getJSON(ctx, "/convai/knowledge-base?"+v.Encode(), &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page.Documents...)
+		if !page.HasMore || page.NextCursor == "" {
+			return all, nil
+		}
+		v.Set("cursor", page.NextCursor)
+	}
+}
+
+// AddKnowledgeFile uploads a document, such as Markdown, text or PDF, to
+// the knowledge base.
+func (c *Client) AddKnowledgeFile(ctx context.Context, name, filename string, r io.Reader) (*KnowledgeDocument, error) {
+	f := newForm()
+	f.field("name", name)
+	f.file("file", filename, r)
+	req, err := f.request(ctx, c, "POST", "/convai/knowledge-base/file")
+	if err != nil {
+		return nil, err
+	}
+	d := KnowledgeDocument{Name: name, Type: KnowledgeFile}
+	if err := c.sendJSON(req, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// AddKnowledgeURL adds a web page to the knowledge base.
+func (c *Client) AddKnowledgeURL(ctx context.Context, name, pageURL string) (*KnowledgeDocument, error) {
+	req, err := c.jsonRequest(ctx, "POST", "/convai/knowledge-base/url", map[string]string{"name": name, "url": pageURL})
+	if err != nil {
+		return nil, err
+	}
+	d := KnowledgeDocument{Name: name, Type: KnowledgeURL}
+	if err := c.sendJSON(req, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// SignedURL returns a short-lived URL a browser can open a conversation
+// with the agent on, without the API key. It expires after 15 minutes.
+func (c *Client) SignedURL(ctx context.Context, agentID string) (string, error) {
+	var r struct {
+		SignedURL string `json:"signed_url"`
+	}
+	if err := c.getJSON(ctx, "/convai/conversation/get-signed-url?"+url.Values{"agent_id": {agentID}}.Encode(), &r); err != nil {
+		return "", err
+	}
+	return r.SignedURL, nil
+}
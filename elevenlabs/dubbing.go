@@ -0,0 +1,158 @@
+package elevenlabs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Dubbing job states reported by the API.
+const (
+	DubbingInProgress = "dubbing"
+	DubbingDone       = "dubbed"
+	DubbingFailed     = "failed"
+)
+
+// DubbingRequest starts a dubbing job from either a file or a URL.
+type DubbingRequest struct {
+	File     io.Reader
+	Filename string
+	// SourceURL is used instead of File, e.g. for a YouTube link.
+	SourceURL string
+
+	Name       string
+	SourceLang string // defaults to auto-detection
+	TargetLang string
+	// NumSpeakers is the number of speakers to detect; 0 means automatic.
+	NumSpeakers int
+	Watermark   bool
+}
+
+// DubbingJob is the response to CreateDubbing.
+type DubbingJob struct {
+	DubbingID        string  `json:"dubbing_id"`
+	ExpectedDuration float64 `json:"expected_duration_sec"`
+}
+
+// Dubbing is the current state of a dubbing job.
+type Dubbing struct {
+	DubbingID       string   `json:"dubbing_id"`
+	Name            string   `json:"name"`
+	Status          string   `json:"status"`
+	TargetLanguages []string `json:"target_languages"`
+	Error           string   `json:"error,omitempty"`
+}
+
+// CreateDubbing uploads the source media and starts dubbing it.
+func (c *Client) CreateDubbing(ctx context.Context, req DubbingRequest) (*DubbingJob, error) {
+	if req.TargetLang == "" {
+		return nil, fmt.Errorf("target language required")
+	}
+	if req.SourceLang == "" {
+		req.SourceLang = "auto"
+	}
+
+	f := newForm()
+	if req.File != nil {
+		f.file("file", req.Filename, req.File)
+	}
+	f.field("source_url", req.SourceURL)
+	f.field("name", req.Name)
+	f.field("source_lang", req.SourceLang)
+	f.field("target_lang", req.TargetLang)
+	if req.NumSpeakers > 0 {
+		f.field("num_speakers", strconv.Itoa(req.NumSpeakers))
+	}
+	if req.Watermark {
+		f.field("watermark", "true")
+	}
+
+	httpReq, err := f.request(ctx, c, "POST", "/dubbing")
+	if err != nil {
+		return nil, err
+	}
+	var job DubbingJob
+	if err := c.sendJSON(httpReq, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetDubbing returns the state of a dubbing job.
+func (c *Client) GetDubbing(ctx context.Context, dubbingID string) (*Dubbing, error) {
+	var d Dubbing
+	if err := c.getJSON(ctx, "/dubbing/"+url.PathEscape(dubbingID), &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// WaitDubbing polls a dubbing job every interval until it is no longer in
+// progress. A failed job is returned together with an error.
+func (c *Client) WaitDubbing(ctx context.Context, dubbingID string, interval time.Duration, onPoll func(*Dubbing)) (*Dubbing, error) {
+	for {
+		d, err := c.GetDubbing(ctx, dubbingID)
+		if err != nil {
+			return nil, err
+		}
+		if onPoll != nil {
+			onPoll(d)
+		}
+		switch d.Status {
+		case DubbingDone:
+			return d, nil
+		case DubbingFailed:
+			if d.Error != "" {
+				return d, fmt.Errorf("dubbing %s failed: %s", dubbingID, d.Error)
+			}
+			return d, fmt.Errorf("dubbing %s failed", dubbingID)
+		}
+		if err := sleep(ctx, interval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// DubbedAudio downloads the dubbed media for one target language. The
+// container matches the source: MP4 for video, MP3 for audio.
+func (c *Client) DubbedAudio(ctx context.Context, dubbingID, lang string) (*Audio, error) {
+	path := "/dubbing/" + url.PathEscape(dubbingID) + "/audio/" + url.PathEscape(lang)
+	req, err := c.newRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return newAudio(resp), nil
+}
+
+// Subtitle formats for DubbingTranscript.
+const (
+	SubtitlesSRT = "srt"
+	SubtitlesVTT = "webvtt"
+)
+
+// DubbingTranscript returns the timed transcript of one language of a
+// dubbing job, the source's or a target's, as SRT or WebVTT subtitles.
+func (c *Client) DubbingTranscript(ctx context.Context, dubbingID, lang, format string) ([]byte, error) {
+	path := "/dubbing/" + url.PathEscape(dubbingID) + "/transcript/" + url.PathEscape(lang) + "?" + url.Values{"format_type": {format}}.Encode()
+	req, err := c.newRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transcript: %w", err)
+	}
+	return b, nil
+}
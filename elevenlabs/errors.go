@@ -0,0 +1,107 @@
+package elevenlabs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sentinel errors matched by *APIError via errors.Is.
+var (
+	ErrUnauthorized  = errors.New("authentication failed")
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	ErrRateLimited   = errors.New("rate limited")
+	ErrInvalidVoice  = errors.New("invalid voice")
+	ErrServer        = errors.New("server error")
+	// ErrFormatNotAllowed is an output format the account's plan doesn't
+	// include.
+	ErrFormatNotAllowed = errors.New("output format not allowed")
+)
+
+// APIError is returned for any non-2xx API response or websocket error.
+type APIError struct {
+	StatusCode int
+	// Status is the machine-readable detail.status field, when present.
+	Status  string
+	Message string
+	Body    string
+	// RetryAfter is the server-requested delay from the Retry-After header.
+	RetryAfter time.Duration
+}
+
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	var parsed struct {
+		Detail json.RawMessage `json:"detail"`
+	}
+	if json.Unmarshal(body, &parsed) == nil && len(parsed.Detail) > 0 {
+		var detail struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		}
+		var msg string
+		if json.Unmarshal(parsed.Detail, &detail) == nil {
+			e.Status = detail.Status
+			e.Message = detail.Message
+		} else if json.Unmarshal(parsed.Detail, &msg) == nil {
+			e.Message = msg
+		}
+	}
+	return e
+}
+
+func (e *APIError) Error() string {
+	if e.StatusCode == 0 {
+		return "API error: " + e.Body
+	}
+	if e.Message != "" {
+		return fmt.Sprintf("API error %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("API error %d: %s", e.StatusCode, e.Body)
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrQuotaExceeded:
+		return e.Status == "quota_exceeded"
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized && e.Status != "quota_exceeded"
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests && e.Status != "quota_exceeded"
+	case ErrInvalidVoice:
+		return strings.Contains(e.Status, "voice_not_found") || strings.Contains(e.Status, "invalid_voice")
+	case ErrServer:
+		return e.StatusCode >= 500
+	case ErrFormatNotAllowed:
+		return strings.Contains(e.Status, "output_format") ||
+			e.StatusCode == http.StatusForbidden && strings.Contains(strings.ToLower(e.Message), "output format")
+	}
+	return false
+}
+
+// Temporary reports whether the request may succeed if retried unchanged.
+func (e *APIError) Temporary() bool {
+	return errors.Is(e, ErrRateLimited) || errors.Is(e, ErrServer) || e.StatusCode == http.StatusRequestTimeout
+}
+
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
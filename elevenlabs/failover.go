@@ -0,0 +1,158 @@
+package elevenlabs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// failoverCooldown is how long a base URL that failed with a server or
+// network error is passed over in favour of the next one.
+const failoverCooldown = 30 * time.Second
+
+// WithBaseURLs sets an ordered list of base URLs to fail over between.
+func WithBaseURLs(baseURLs ...string) Option {
+	return func(c *Client) {
+		if len(baseURLs) > 0 {
+			c.endpoints = newEndpoints(baseURLs)
+		}
+	}
+}
+
+// WithFailoverHook sets a function called whenever a request moves from one
+// base URL to the next.
+func WithFailoverHook(fn func(from, to string, err error)) Option {
+	return func(c *Client) {
+		c.onFailover = fn
+	}
+}
+
+// endpoints are a client's base URLs with the time each is skipped until.
+type endpoints struct {
+	mu   sync.Mutex
+	urls []string
+	down []time.Time
+}
+
+func newEndpoints(urls []string) *endpoints {
+	e := &endpoints{down: make([]time.Time, len(urls))}
+	for _, u := range urls {
+		e.urls = append(e.urls, strings.TrimSuffix(u, "/"))
+	}
+	return e
+}
+
+// pick returns the first healthy base URL or, if none is, the one that
+// becomes healthy first.
+func (e *endpoints) pick() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := time.Now()
+	best := 0
+	for i, until := range e.down {
+		if until.Before(now) {
+			return e.urls[i]
+		}
+		if until.Before(e.down[best]) {
+			best = i
+		}
+	}
+	return e.urls[best]
+}
+
+// base returns the base URL that rawURL was built from, or "".
+func (e *endpoints) base(rawURL string) string {
+	for _, u := range e.urls {
+		if strings.HasPrefix(rawURL, u+"/") || strings.HasPrefix(rawURL, u+"?") || rawURL == u {
+			return u
+		}
+	}
+	return ""
+}
+
+// failed marks base as down and returns the next base URL to try, skipping
+// those in tried.
+func (e *endpoints) failed(base string, tried map[string]bool) (string, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := time.Now()
+	for i, u := range e.urls {
+		if u == base {
+			e.down[i] = now.Add(failoverCooldown)
+		}
+	}
+	for i, u := range e.urls {
+		if !tried[u] && e.down[i].Before(now) {
+			return u, true
+		}
+	}
+	return "", false
+}
+
+// ok marks base as healthy again.
+func (e *endpoints) ok(base string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i, u := range e.urls {
+		if u == base {
+			e.down[i] = time.Time{}
+		}
+	}
+}
+
+// failoverable reports whether err means the base URL itself is in
+// trouble, rather than the request or the account.
+func failoverable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500
+	}
+	return true
+}
+
+// failover marks the base URL req was sent to as down after err and
+// returns the next one to try, or false if there is none left.
+func (c *Client) failover(req *http.Request, err error, tried map[string]bool) (string, bool) {
+	if len(c.endpoints.urls) < 2 || !failoverable(err) {
+		return "", false
+	}
+	from := c.endpoints.base(req.URL.String())
+	if from == "" {
+		return "", false
+	}
+	tried[from] = true
+	to, ok := c.endpoints.failed(from, tried)
+	if ok && c.onFailover != nil {
+		c.onFailover(from, to, err)
+	}
+	return to, ok
+}
+
+// resend returns a copy of req to send again, with its body rewound and,
+// if it was built on one of the base URLs, aimed at base instead.
+func (c *Client) resend(req *http.Request, base string) (*http.Request, error) {
+	next := req.Clone(req.Context())
+	if from := c.endpoints.base(req.URL.String()); from != "" && from != base {
+		u, err := url.Parse(base + strings.TrimPrefix(req.URL.String(), from))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		next.URL, next.Host = u, ""
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to rewind request body: %w", err)
+		}
+		next.Body = body
+	}
+	return next, nil
+}
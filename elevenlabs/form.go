@@ -0,0 +1,91 @@
+package elevenlabs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+)
+
+// form builds a multipart request body in memory so it can be replayed on
+// retry.
+type form struct {
+	buf bytes.Buffer
+	w   *multipart.Writer
+	err error
+}
+
+func newForm() *form {
+	f := &form{}
+	f.w = multipart.NewWriter(&f.buf)
+	return f
+}
+
+func (f *form) field(name, value string) {
+	if f.err == nil && value != "" {
+		f.err = f.w.WriteField(name, value)
+	}
+}
+
+func (f *form) file(field, filename string, r io.Reader) {
+	if f.err != nil {
+		return
+	}
+	part, err := f.w.CreateFormFile(field, filename)
+	if err != nil {
+		f.err = fmt.Errorf("failed to create form file: %w", err)
+		return
+	}
+	if _, err := io.Copy(part, r); err != nil {
+		f.err = fmt.Errorf("failed to copy %s: %w", filename, err)
+	}
+}
+
+func (f *form) request(ctx context.Context, c *Client, method, path string) (*http.Request, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if err := f.w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish form: %w", err)
+	}
+	req, err := c.newRequest(ctx, method, path, &f.buf)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", f.w.FormDataContentType())
+	return req, nil
+}
+
+// jsonRequest returns a request with in marshaled as its JSON body.
+func (c *Client) jsonRequest(ctx context.Context, method, path string, in any) (*http.Request, error) {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := c.newRequest(ctx, method, path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// sendJSON sends req and decodes the JSON response into out, if non-nil.
+func (c *Client) sendJSON(req *http.Request, out any) error {
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
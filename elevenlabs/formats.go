@@ -0,0 +1,106 @@
+package elevenlabs
+
+import (
+	"slices"
+	"strings"
+)
+
+// outputFormats are the output_format values the API accepts, in increasing
+// quality within each codec.
+var outputFormats = []string{
+	"mp3_22050_32",
+	"mp3_24000_48",
+	"mp3_44100_32",
+	"mp3_44100_64",
+	"mp3_44100_96",
+	"mp3_44100_128",
+	"mp3_44100_192",
+	"opus_48000_32",
+	"opus_48000_64",
+	"opus_48000_96",
+	"opus_48000_128",
+	"opus_48000_192",
+	"pcm_8000",
+	"pcm_16000",
+	"pcm_22050",
+	"pcm_24000",
+	"pcm_32000",
+	"pcm_44100",
+	"pcm_48000",
+	"ulaw_8000",
+	"alaw_8000",
+}
+
+// OutputFormats returns the output formats the API accepts, optionally only
+// those of one codec ("mp3", "opus", "pcm", "ulaw", "alaw").
+func OutputFormats(codec string) []string {
+	if codec == "" {
+		return slices.Clone(outputFormats)
+	}
+	var out []string
+	for _, f := range outputFormats {
+		if strings.HasPrefix(f, codec+"_") {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// IsOutputFormat reports whether the API accepts format.
+func IsOutputFormat(format string) bool {
+	return slices.Contains(outputFormats, format)
+}
+
+// formatTiers are the lowest subscription tiers of the formats that need
+// more than the free one.
+var formatTiers = map[string]string{
+	"mp3_44100_192": "creator",
+	"pcm_44100":     "pro",
+	"pcm_48000":     "pro",
+}
+
+// tierRanks orders the subscription tiers.
+var tierRanks = map[string]int{
+	"free":             0,
+	"starter":          1,
+	"creator":          2,
+	"pro":              3,
+	"scale":            4,
+	"growing_business": 5,
+	"business":         5,
+	"enterprise":       6,
+}
+
+// FormatTier returns the lowest subscription tier that includes format, or
+// "free" if every tier does.
+func FormatTier(format string) string {
+	if tier, ok := formatTiers[format]; ok {
+		return tier
+	}
+	return "free"
+}
+
+// FormatAllowed reports whether an account on tier can use format. known
+// is false for tiers this package doesn't know, for which allowed is true.
+func FormatAllowed(format, tier string) (allowed, known bool) {
+	rank, ok := tierRanks[tier]
+	if !ok {
+		return true, false
+	}
+	return rank >= tierRanks[FormatTier(format)], true
+}
+
+// FallbackFormat returns the next lower quality format of the same codec,
+// for retrying when the account's plan doesn't include format.
+func FallbackFormat(format string) (string, bool) {
+	i := slices.Index(outputFormats, format)
+	if i <= 0 {
+		return "", false
+	}
+	codec, _, _ := strings.Cut(format, "_")
+	prev := outputFormats[i-1]
+	if !strings.HasPrefix(prev, codec+"_") {
+		return "", false
+	}
+	return prev, true
+}
@@ -0,0 +1,70 @@
+package elevenlabs_test
+
+import (
+	"testing"
+
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+)
+
+func TestFallbackFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+		ok     bool
+	}{
+		{"mp3_44100_192", "mp3_44100_128", true},
+		{"mp3_24000_48", "mp3_22050_32", true},
+		{"mp3_22050_32", "", false},
+		{"pcm_48000", "pcm_44100", true},
+		{"pcm_44100", "pcm_32000", true},
+		{"pcm_8000", "", false},
+		{"opus_48000_32", "", false},
+		{"ulaw_8000", "", false},
+		{"alaw_8000", "", false},
+		{"flac_44100", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := elevenlabs.FallbackFormat(tt.format)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("FallbackFormat(%q) = %q, %v, want %q, %v", tt.format, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFallbackFormatReachesFreeTier(t *testing.T) {
+	for _, f := range elevenlabs.OutputFormats("") {
+		for steps := 0; ; steps++ {
+			if allowed, _ := elevenlabs.FormatAllowed(f, "free"); allowed {
+				break
+			}
+			next, ok := elevenlabs.FallbackFormat(f)
+			if !ok || steps > 10 {
+				t.Errorf("no free fallback for %s", f)
+				break
+			}
+			f = next
+		}
+	}
+}
+
+func TestFormatAllowed(t *testing.T) {
+	tests := []struct {
+		format, tier   string
+		allowed, known bool
+	}{
+		{"mp3_44100_128", "free", true, true},
+		{"mp3_44100_192", "starter", false, true},
+		{"mp3_44100_192", "creator", true, true},
+		{"pcm_48000", "creator", false, true},
+		{"pcm_48000", "pro", true, true},
+		{"pcm_48000", "enterprise", true, true},
+		{"pcm_48000", "new_tier", true, false},
+	}
+	for _, tt := range tests {
+		allowed, known := elevenlabs.FormatAllowed(tt.format, tt.tier)
+		if allowed != tt.allowed || known != tt.known {
+			t.Errorf("FormatAllowed(%q, %q) = %v, %v, want %v, %v", tt.format, tt.tier, allowed, known, tt.allowed, tt.known)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package elevenlabs
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// HistoryItem is one past generation.
+type HistoryItem struct {
+	HistoryItemID string `json:"history_item_id"`
+	RequestID     string `json:"request_id"`
+	VoiceID       string `json:"voice_id"`
+	VoiceName     string `json:"voice_name"`
+	ModelID       string `json:"model_id"`
+	Text          string `json:"text"`
+	DateUnix      int64  `json:"date_unix"`
+	// CharacterCountChangeFrom and To are the account's character count
+	// before and after the generation.
+	CharacterCountChangeFrom int    `json:"character_count_change_from"`
+	CharacterCountChangeTo   int    `json:"character_count_change_to"`
+	ContentType              string `json:"content_type"`
+	State                    string `json:"state"`
+	Source                   string `json:"source"`
+}
+
+// HistoryPage is one page of history, newest first.
+type HistoryPage struct {
+	History           []HistoryItem `json:"history"`
+	HasMore           bool          `json:"has_more"`
+	LastHistoryItemID string        `json:"last_history_item_id"`
+}
+
+// HistoryQuery selects a page of history. Zero values use the API defaults.
+type HistoryQuery struct {
+	PageSize int
+	// StartAfter is the LastHistoryItemID of the previous page.
+	StartAfter string
+	VoiceID    string
+}
+
+// History returns a page of past generations.
+func (c *Client) History(ctx context.Context, q HistoryQuery) (*HistoryPage, error) {
+	v := url.Values{}
+	if q.PageSize > 0 {
+		v.Set("page_size", strconv.Itoa(q.PageSize))
+	}
+	if q.StartAfter != "" {
+		v.Set("start_after_history_item_id", q.StartAfter)
+	}
+	if q.VoiceID != "" {
+		v.Set("voice_id", q.VoiceID)
+	}
+	path := "/history"
+	if len(v) > 0 {
+		path += "?" + v.Encode()
+	}
+
+	var page HistoryPage
+	if err := c.getJSON(ctx, path, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// HistoryAudio downloads the audio of a past generation.
+func (c *Client) HistoryAudio(ctx context.Context, historyItemID string) (*Audio, error) {
+	req, err := c.newRequest(ctx, "GET", "/history/"+url.PathEscape(historyItemID)+"/audio", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return newAudio(resp), nil
+}
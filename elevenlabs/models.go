@@ -0,0 +1,62 @@
+package elevenlabs
+
+import "sort"
+
+// ModelInfo holds the published limits of a TTS model.
+type ModelInfo struct {
+	ID string `json:"model_id"`
+	// MaxChars is the per-request character limit.
+	MaxChars int `json:"max_chars"`
+	// CreditsPerChar is the billing rate; Flash and Turbo models cost half.
+	CreditsPerChar float64 `json:"credits_per_char"`
+	// Stitching reports whether requests can continue earlier ones through
+	// TTSRequest.PreviousRequestIDs.
+	Stitching bool `json:"request_stitching"`
+	// AudioTags reports whether the model performs bracketed audio tags
+	// such as [laughs] instead of reading them out.
+	AudioTags bool `json:"audio_tags"`
+}
+
+// defaultModelInfo is used for models this package doesn't know about.
+// They are assumed to take audio tags, as the newest models do.
+var defaultModelInfo = ModelInfo{MaxChars: 5000, CreditsPerChar: 1, AudioTags: true}
+
+var models = map[string]ModelInfo{
+	"eleven_v3":              {MaxChars: 5000, CreditsPerChar: 1, AudioTags: true},
+	"eleven_multilingual_v2": {MaxChars: 10000, CreditsPerChar: 1, Stitching: true},
+	"eleven_flash_v2_5":      {MaxChars: 40000, CreditsPerChar: 0.5, Stitching: true},
+	"eleven_turbo_v2_5":      {MaxChars: 40000, CreditsPerChar: 0.5, Stitching: true},
+	"eleven_flash_v2":        {MaxChars: 30000, CreditsPerChar: 0.5, Stitching: true},
+	"eleven_turbo_v2":        {MaxChars: 30000, CreditsPerChar: 0.5, Stitching: true},
+	"eleven_monolingual_v1":  {MaxChars: 10000, CreditsPerChar: 1, Stitching: true},
+	"eleven_multilingual_v1": {MaxChars: 10000, CreditsPerChar: 1, Stitching: true},
+}
+
+// Model returns the limits of a TTS model, falling back to conservative
+// defaults for unknown IDs.
+func Model(modelID string) ModelInfo {
+	if modelID == "" {
+		modelID = DefaultTTSModel
+	}
+	info, ok := models[modelID]
+	if !ok {
+		info = defaultModelInfo
+	}
+	info.ID = modelID
+	return info
+}
+
+// KnownModels returns the TTS models this package has limits for, sorted by ID.
+func KnownModels() []ModelInfo {
+	out := make([]ModelInfo, 0, len(models))
+	for id := range models {
+		out = append(out, Model(id))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// MaxChars returns the per-request character limit of a TTS model.
+func MaxChars(modelID string) int {
+	return Model(modelID).MaxChars
+}
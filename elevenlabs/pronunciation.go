@@ -0,0 +1,146 @@
+package elevenlabs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+)
+
+// Pronunciation rule types.
+const (
+	RuleAlias   = "alias"
+	RulePhoneme = "phoneme"
+)
+
+// PronunciationRule replaces a word with an alias, or gives its phonemes.
+type PronunciationRule struct {
+	StringToReplace string `json:"string_to_replace"`
+	Type            string `json:"type"`
+	Alias           string `json:"alias,omitempty"`
+	Phoneme         string `json:"phoneme,omitempty"`
+	// Alphabet is "ipa" or "cmu-arpabet", for phoneme rules.
+	Alphabet string `json:"alphabet,omitempty"`
+}
+
+// PronunciationDictionary is a server-side set of pronunciation rules.
+type PronunciationDictionary struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	LatestVersionID string `json:"latest_version_id"`
+	Description     string `json:"description,omitempty"`
+}
+
+// DictionaryVersion identifies one version of a dictionary, as TTS
+// requests reference it.
+type DictionaryVersion struct {
+	ID        string `json:"id"`
+	VersionID string `json:"version_id"`
+}
+
+// ListPronunciationDictionaries returns every pronunciation dictionary of
+// the account.
+func (c *Client) ListPronunciationDictionaries(ctx context.Context) ([]PronunciationDictionary, error) {
+	var all []PronunciationDictionary
+	v := url.Values{"page_size": {"100"}}
+	for {
+		var page struct {
+			Dictionaries []PronunciationDictionary `json:"pronunciation_dictionaries"`
+			HasMore      bool                      `json:"has_more"`
+			NextCursor   string                    `json:"next_cursor"`
+		}
+		if err := c.getJSON(ctx, "/pronunciation-dictionaries?"+v.Encode(), &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page.Dictionaries...)
+		if !page.HasMore || page.NextCursor == "" {
+			return all, nil
+		}
+		v.Set("cursor", page.NextCursor)
+	}
+}
+
+// AddPronunciationDictionary creates a dictionary from a PLS lexicon.
+func (c *Client) AddPronunciationDictionary(ctx context.Context, name, description string, pls io.Reader) (*DictionaryVersion, error) {
+	f := newForm()
+	f.field("name", name)
+	f.field("description", description)
+	f.file("file", "dictionary.pls", pls)
+	req, err := f.request(ctx, c, "POST", "/pronunciation-dictionaries/add-from-file")
+	if err != nil {
+		return nil, err
+	}
+	var v DictionaryVersion
+	if err := c.sendJSON(req, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// AddPronunciationRules adds rules to a dictionary, replacing any for the
+// same strings, and returns the new version.
+func (c *Client) AddPronunciationRules(ctx context.Context, dictionaryID string, rules []PronunciationRule) (*DictionaryVersion, error) {
+	body, err := json.Marshal(map[string]any{"rules": rules})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rules: %w", err)
+	}
+	req, err := c.newRequest(ctx, "POST", "/pronunciation-dictionaries/"+url.PathEscape(dictionaryID)+"/add-rules", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	var v DictionaryVersion
+	if err := c.sendJSON(req, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// WritePLS writes rules as a W3C Pronunciation Lexicon Specification
+// document for language lang, such as "en-US".
+func WritePLS(w io.Writer, rules []PronunciationRule, lang string) error {
+	type phoneme struct {
+		Alphabet string `xml:"alphabet,attr,omitempty"`
+		Value    string `xml:",chardata"`
+	}
+	type lexeme struct {
+		Grapheme string   `xml:"grapheme"`
+		Alias    string   `xml:"alias,omitempty"`
+		Phoneme  *phoneme `xml:"phoneme"`
+	}
+	type lexicon struct {
+		XMLName  xml.Name `xml:"http://www.w3.org/2005/01/pronunciation-lexicon lexicon"`
+		Version  string   `xml:"version,attr"`
+		Alphabet string   `xml:"alphabet,attr"`
+		Lang     string   `xml:"xml:lang,attr"`
+		Lexemes  []lexeme `xml:"lexeme"`
+	}
+
+	doc := lexicon{Version: "1.0", Alphabet: "ipa", Lang: lang}
+	for _, r := range rules {
+		lx := lexeme{Grapheme: r.StringToReplace}
+		if r.Type == RulePhoneme {
+			lx.Phoneme = &phoneme{Value: r.Phoneme}
+			if r.Alphabet != "ipa" {
+				lx.Phoneme.Alphabet = r.Alphabet
+			}
+		} else {
+			lx.Alias = r.Alias
+		}
+		doc.Lexemes = append(doc.Lexemes, lx)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,178 @@
+package elevenlabs
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/coder/websocket"
+)
+
+// DefaultRealtimeModel is used by StreamTextToSpeech when no model is given.
+// eleven_v3 isn't available on the websocket endpoint.
+const DefaultRealtimeModel = "eleven_flash_v2_5"
+
+// RealtimeRequest configures a websocket text-to-speech session.
+type RealtimeRequest struct {
+	ModelID       string
+	VoiceSettings *VoiceSettings
+	// OutputFormat is an API format string such as "mp3_44100_128".
+	OutputFormat string
+	// ChunkLengthSchedule is how many characters the server buffers before
+	// generating each successive chunk. Empty uses the server default.
+	ChunkLengthSchedule []int
+}
+
+// RealtimeStream is a websocket text-to-speech session.
+type RealtimeStream struct {
+	conn *websocket.Conn
+	ctx  context.Context
+}
+
+type realtimeMessage struct {
+	Text             string         `json:"text"`
+	VoiceSettings    *VoiceSettings `json:"voice_settings,omitempty"`
+	GenerationConfig *realtimeGen   `json:"generation_config,omitempty"`
+	Flush            bool           `json:"flush,omitempty"`
+}
+
+type realtimeGen struct {
+	ChunkLengthSchedule []int `json:"chunk_length_schedule"`
+}
+
+// StreamTextToSpeech opens a websocket session on the stream-input endpoint.
+// The session is bound to ctx; callers must Close it.
+func (c *Client) StreamTextToSpeech(ctx context.Context, voiceID string, req RealtimeRequest) (*RealtimeStream, error) {
+	if req.ModelID == "" {
+		req.ModelID = DefaultRealtimeModel
+	}
+
+	q := url.Values{"model_id": {req.ModelID}}
+	if req.OutputFormat != "" {
+		q.Set("output_format", req.OutputFormat)
+	}
+	u := c.endpoints.pick() + "/text-to-speech/" + url.PathEscape(voiceID) + "/stream-input?" + q.Encode()
+
+	auth, credential := c.authHeader()
+	conn, resp, err := websocket.Dial(ctx, u, &websocket.DialOptions{
+		HTTPClient: c.httpClient,
+		HTTPHeader: http.Header{auth: {credential}},
+	})
+	if err != nil {
+		if resp != nil && resp.StatusCode != http.StatusSwitchingProtocols {
+			body, _ := io.ReadAll(resp.Body)
+			return nil, newAPIError(resp, body)
+		}
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	// Audio messages are base64 and regularly exceed the 32 KiB default.
+	conn.SetReadLimit(16 << 20)
+
+	s := &RealtimeStream{conn: conn, ctx: ctx}
+	// The session starts with a single space carrying the settings.
+	first := realtimeMessage{Text: " ", VoiceSettings: req.VoiceSettings}
+	if len(req.ChunkLengthSchedule) > 0 {
+		first.GenerationConfig = &realtimeGen{ChunkLengthSchedule: req.ChunkLengthSchedule}
+	}
+	if err := s.send(first); err != nil {
+		conn.CloseNow()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *RealtimeStream) send(m realtimeMessage) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	if err := s.conn.Write(s.ctx, websocket.MessageText, b); err != nil {
+		return fmt.Errorf("failed to send text: %w", err)
+	}
+	return nil
+}
+
+// Send queues text for synthesis. The server waits for enough text to
+// generate natural speech; text should end with a space at word boundaries.
+func (s *RealtimeStream) Send(text string) error {
+	if text == "" {
+		return nil
+	}
+	return s.send(realtimeMessage{Text: text})
+}
+
+// Flush forces generation of all text sent so far, e.g. at the end of an
+// utterance, without ending the session.
+func (s *RealtimeStream) Flush() error {
+	return s.send(realtimeMessage{Text: " ", Flush: true})
+}
+
+// CloseSend signals the end of the text. Recv returns the remaining audio,
+// then io.EOF.
+func (s *RealtimeStream) CloseSend() error {
+	return s.send(realtimeMessage{Text: ""})
+}
+
+// Recv returns the next chunk of audio, or io.EOF once the server has sent
+// its final message.
+func (s *RealtimeStream) Recv() ([]byte, error) {
+	for {
+		_, b, err := s.conn.Read(s.ctx)
+		if err != nil {
+			if websocket.CloseStatus(err) == websocket.StatusNormalClosure {
+				return nil, io.EOF
+			}
+			var ce websocket.CloseError
+			if errors.As(err, &ce) {
+				return nil, realtimeError(ce.Reason, "")
+			}
+			return nil, fmt.Errorf("failed to receive audio: %w", err)
+		}
+
+		var msg struct {
+			Audio   string `json:"audio"`
+			IsFinal bool   `json:"isFinal"`
+			Message string `json:"message"`
+			Error   string `json:"error"`
+		}
+		if err := json.Unmarshal(b, &msg); err != nil {
+			return nil, fmt.Errorf("failed to decode message: %w", err)
+		}
+		if msg.Error != "" {
+			return nil, realtimeError(msg.Message, msg.Error)
+		}
+		if msg.Audio != "" {
+			chunk, err := base64.StdEncoding.DecodeString(msg.Audio)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode audio: %w", err)
+			}
+			return chunk, nil
+		}
+		if msg.IsFinal {
+			return nil, io.EOF
+		}
+	}
+}
+
+// realtimeError maps an error reported over the websocket onto APIError so
+// errors.Is works as for HTTP responses.
+func realtimeError(message, status string) *APIError {
+	e := &APIError{Status: status, Message: message, Body: message}
+	switch status {
+	case "invalid_api_key", "unauthorized":
+		e.StatusCode = http.StatusUnauthorized
+	case "rate_limited", "too_many_concurrent_requests":
+		e.StatusCode = http.StatusTooManyRequests
+	}
+	return e
+}
+
+// Close ends the session.
+func (s *RealtimeStream) Close() error {
+	return s.conn.Close(websocket.StatusNormalClosure, "")
+}
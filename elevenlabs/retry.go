@@ -0,0 +1,81 @@
+package elevenlabs
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+const defaultRetryBaseDelay = 500 * time.Millisecond
+
+// RetryPolicy controls how failed requests are retried. The zero value
+// disables retries.
+type RetryPolicy struct {
+	// MaxRetries is the number of attempts after the first.
+	MaxRetries int
+	// MaxWait caps a single delay between attempts. A Retry-After longer
+	// than MaxWait fails the request instead of waiting. Zero means no cap.
+	MaxWait time.Duration
+	// BaseDelay is the first exponential backoff step (default 500ms).
+	BaseDelay time.Duration
+	// OnRetry, if set, is called before each wait.
+	OnRetry func(attempt int, delay time.Duration, err error)
+}
+
+// WithRetry sets the retry policy for all requests.
+func WithRetry(p RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = p
+	}
+}
+
+// delay returns how long to wait before retry number attempt (1-based), or
+// false if err should not be retried.
+func (p RetryPolicy) delay(attempt int, err error) (time.Duration, bool) {
+	if attempt > p.MaxRetries || !retryable(err) {
+		return 0, false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		if p.MaxWait > 0 && apiErr.RetryAfter > p.MaxWait {
+			return 0, false
+		}
+		return apiErr.RetryAfter, true
+	}
+
+	base := p.BaseDelay
+	if base <= 0 {
+		base = defaultRetryBaseDelay
+	}
+	d := base << (attempt - 1)
+	d += rand.N(d/2 + 1)
+	if p.MaxWait > 0 && d > p.MaxWait {
+		d = p.MaxWait
+	}
+	return d, true
+}
+
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+	// Anything else is a transport failure.
+	return true
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
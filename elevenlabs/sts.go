@@ -0,0 +1,66 @@
+package elevenlabs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+)
+
+// STSRequest is a speech-to-speech request. ModelID defaults to DefaultSTSModel.
+type STSRequest struct {
+	Audio    io.Reader
+	Filename string
+	ModelID  string
+
+	// VoiceSettings overrides the voice's stored settings when set.
+	VoiceSettings         *VoiceSettings
+	RemoveBackgroundNoise bool
+
+	// OutputFormat is an API format string such as "mp3_44100_128".
+	OutputFormat string
+	// Stream uses the streaming endpoint, which starts sending audio before
+	// the whole input has been converted.
+	Stream bool
+}
+
+// SpeechToSpeech re-voices req.Audio with the given voice.
+func (c *Client) SpeechToSpeech(ctx context.Context, voiceID string, req STSRequest) (*Audio, error) {
+	if req.ModelID == "" {
+		req.ModelID = DefaultSTSModel
+	}
+	if req.Filename == "" {
+		req.Filename = "audio"
+	}
+
+	f := newForm()
+	f.file("audio", req.Filename, req.Audio)
+	f.field("model_id", req.ModelID)
+	if req.VoiceSettings != nil {
+		settings, err := json.Marshal(req.VoiceSettings)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal voice settings: %w", err)
+		}
+		f.field("voice_settings", string(settings))
+	}
+	if req.RemoveBackgroundNoise {
+		f.field("remove_background_noise", "true")
+	}
+
+	path := "/speech-to-speech/" + url.PathEscape(voiceID)
+	if req.Stream {
+		path += "/stream"
+	}
+	path += formatQuery(req.OutputFormat)
+	httpReq, err := f.request(ctx, c, "POST", path)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	return newAudio(resp), nil
+}
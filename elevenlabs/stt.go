@@ -0,0 +1,102 @@
+package elevenlabs
+
+import (
+	"context"
+	"io"
+)
+
+// DefaultSTTModel is the speech-to-text model used when none is given.
+const DefaultSTTModel = "scribe_v1"
+
+// STTRequest is a speech-to-text request. ModelID defaults to
+// DefaultSTTModel.
+type STTRequest struct {
+	Audio    io.Reader
+	Filename string
+	ModelID  string
+	// LanguageCode is an ISO-639 code; empty detects the language.
+	LanguageCode string
+	// Diarize labels words with the speaker who said them.
+	Diarize bool
+	// TagAudioEvents marks sounds such as (laughter) in the text.
+	TagAudioEvents bool
+}
+
+// TranscriptWord is one word, space or audio event of a transcript, with
+// its times in seconds.
+type TranscriptWord struct {
+	Text      string  `json:"text"`
+	Type      string  `json:"type"`
+	Start     float64 `json:"start"`
+	End       float64 `json:"end"`
+	SpeakerID string  `json:"speaker_id,omitempty"`
+}
+
+// Transcript is the result of SpeechToText.
+type Transcript struct {
+	LanguageCode        string           `json:"language_code"`
+	LanguageProbability float64          `json:"language_probability"`
+	Text                string           `json:"text"`
+	Words               []TranscriptWord `json:"words"`
+}
+
+// SpeechToText transcribes req.Audio.
+func (c *Client) SpeechToText(ctx context.Context, req STTRequest) (*Transcript, error) {
+	if req.ModelID == "" {
+		req.ModelID = DefaultSTTModel
+	}
+	if req.Filename == "" {
+		req.Filename = "audio"
+	}
+
+	f := newForm()
+	f.file("file", req.Filename, req.Audio)
+	f.field("model_id", req.ModelID)
+	f.field("language_code", req.LanguageCode)
+	if req.Diarize {
+		f.field("diarize", "true")
+	}
+	if req.TagAudioEvents {
+		f.field("tag_audio_events", "true")
+	}
+
+	httpReq, err := f.request(ctx, c, "POST", "/speech-to-text")
+	if err != nil {
+		return nil, err
+	}
+	var t Transcript
+	if err := c.sendJSON(httpReq, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// STTModelInfo holds the approximate rates of a speech-to-text model.
+type STTModelInfo struct {
+	ID string `json:"model_id"`
+	// CreditsPerMinute is the approximate billing rate per started minute
+	// of audio.
+	CreditsPerMinute float64 `json:"credits_per_minute"`
+	// Speed is roughly how many times faster than real time the model
+	// transcribes, for estimating the wait.
+	Speed float64 `json:"speed"`
+}
+
+var sttModels = map[string]STTModelInfo{
+	"scribe_v1":              {CreditsPerMinute: 67, Speed: 30},
+	"scribe_v1_experimental": {CreditsPerMinute: 67, Speed: 30},
+}
+
+// STTModel returns the rates of a speech-to-text model, falling back to
+// those of DefaultSTTModel for unknown IDs.
+func STTModel(modelID string) STTModelInfo {
+	if modelID == "" {
+		modelID = DefaultSTTModel
+	}
+	info, ok := sttModels[modelID]
+	if !ok {
+		info = sttModels[DefaultSTTModel]
+	}
+	info.ID = modelID
+	return info
+}
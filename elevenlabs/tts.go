@@ -0,0 +1,80 @@
+package elevenlabs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// VoiceSettings tunes how a voice is rendered.
+type VoiceSettings struct {
+	Stability       float64 `json:"stability"`
+	SimilarityBoost float64 `json:"similarity_boost"`
+	Style           float64 `json:"style"`
+	Speed           float64 `json:"speed"`
+	UseSpeakerBoost bool    `json:"use_speaker_boost"`
+}
+
+// MaxPreviousRequests is how many request IDs TTSRequest.PreviousRequestIDs
+// may hold.
+const MaxPreviousRequests = 3
+
+// TTSRequest is a text-to-speech request. ModelID defaults to DefaultTTSModel.
+type TTSRequest struct {
+	Text          string         `json:"text"`
+	ModelID       string         `json:"model_id"`
+	VoiceSettings *VoiceSettings `json:"voice_settings,omitempty"`
+
+	// PreviousText and NextText are the surrounding text when Text is one
+	// chunk of a longer script; they keep prosody continuous across chunks.
+	PreviousText string `json:"previous_text,omitempty"`
+	NextText     string `json:"next_text,omitempty"`
+	// PreviousRequestIDs are up to MaxPreviousRequests earlier requests
+	// with the same voice and model that Text continues; see
+	// ModelInfo.Stitching.
+	PreviousRequestIDs []string `json:"previous_request_ids,omitempty"`
+
+	// OutputFormat is an API format string such as "mp3_44100_128".
+	OutputFormat string `json:"-"`
+	// Stream uses the streaming endpoint, which starts sending audio before
+	// the whole text has been synthesized.
+	Stream bool `json:"-"`
+}
+
+// TextToSpeech synthesizes req.Text with the given voice.
+func (c *Client) TextToSpeech(ctx context.Context, voiceID string, req TTSRequest) (*Audio, error) {
+	if req.ModelID == "" {
+		req.ModelID = DefaultTTSModel
+	}
+
+	jsonBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	path := "/text-to-speech/" + url.PathEscape(voiceID)
+	if req.Stream {
+		path += "/stream"
+	}
+	path += formatQuery(req.OutputFormat)
+	httpReq, err := c.newRequest(ctx, "POST", path, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	return newAudio(resp), nil
+}
+
+func formatQuery(outputFormat string) string {
+	if outputFormat == "" {
+		return ""
+	}
+	return "?output_format=" + url.QueryEscape(outputFormat)
+}
@@ -0,0 +1,48 @@
+package elevenlabs
+
+import (
+	"context"
+	"time"
+)
+
+// User is the account that owns the API key.
+type User struct {
+	UserID    string `json:"user_id"`
+	FirstName string `json:"first_name"`
+}
+
+// User returns the account for the client's API key. It is a cheap way to
+// check that the key is valid.
+func (c *Client) User(ctx context.Context) (*User, error) {
+	var u User
+	if err := c.getJSON(ctx, "/user", &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// Subscription is the account's plan and character usage.
+type Subscription struct {
+	Tier                   string `json:"tier"`
+	Status                 string `json:"status"`
+	CharacterCount         int    `json:"character_count"`
+	CharacterLimit         int    `json:"character_limit"`
+	NextCharacterResetUnix int64  `json:"next_character_count_reset_unix"`
+	VoiceLimit             int    `json:"voice_limit"`
+	VoiceSlotsUsed         int    `json:"voice_slots_used"`
+	CanUseInstantCloning   bool   `json:"can_use_instant_voice_cloning"`
+}
+
+// NextReset returns when the character count resets.
+func (s *Subscription) NextReset() time.Time {
+	return time.Unix(s.NextCharacterResetUnix, 0)
+}
+
+// Subscription returns the account's plan and current usage.
+func (c *Client) Subscription(ctx context.Context) (*Subscription, error) {
+	var s Subscription
+	if err := c.getJSON(ctx, "/user/subscription", &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
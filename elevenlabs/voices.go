@@ -0,0 +1,125 @@
+package elevenlabs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+)
+
+// Voice is a voice available to the account.
+type Voice struct {
+	VoiceID     string            `json:"voice_id"`
+	Name        string            `json:"name"`
+	Category    string            `json:"category,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+}
+
+// VoiceSample is an audio file used to clone a voice.
+type VoiceSample struct {
+	Filename string
+	Audio    io.Reader
+}
+
+// VoiceRequest creates or edits an instant voice clone.
+type VoiceRequest struct {
+	Name        string
+	Description string
+	Labels      map[string]string
+	Samples     []VoiceSample
+	// RemoveBackgroundNoise cleans up the samples before cloning.
+	RemoveBackgroundNoise bool
+}
+
+func (r VoiceRequest) form() (*form, error) {
+	f := newForm()
+	f.field("name", r.Name)
+	f.field("description", r.Description)
+	if len(r.Labels) > 0 {
+		labels, err := json.Marshal(r.Labels)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal labels: %w", err)
+		}
+		f.field("labels", string(labels))
+	}
+	if r.RemoveBackgroundNoise {
+		f.field("remove_background_noise", "true")
+	}
+	for _, s := range r.Samples {
+		f.file("files", s.Filename, s.Audio)
+	}
+	return f, nil
+}
+
+// ListVoices returns all voices available to the account.
+func (c *Client) ListVoices(ctx context.Context) ([]Voice, error) {
+	var resp struct {
+		Voices []Voice `json:"voices"`
+	}
+	if err := c.getJSON(ctx, "/voices", &resp); err != nil {
+		return nil, err
+	}
+	return resp.Voices, nil
+}
+
+// GetVoice returns a single voice.
+func (c *Client) GetVoice(ctx context.Context, voiceID string) (*Voice, error) {
+	var v Voice
+	if err := c.getJSON(ctx, "/voices/"+url.PathEscape(voiceID), &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// AddVoice creates an instant voice clone from req.Samples and returns its ID.
+func (c *Client) AddVoice(ctx context.Context, req VoiceRequest) (string, error) {
+	if req.Name == "" {
+		return "", fmt.Errorf("voice name required")
+	}
+	if len(req.Samples) == 0 {
+		return "", fmt.Errorf("at least one sample required")
+	}
+	f, err := req.form()
+	if err != nil {
+		return "", err
+	}
+	httpReq, err := f.request(ctx, c, "POST", "/voices/add")
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		VoiceID string `json:"voice_id"`
+	}
+	if err := c.sendJSON(httpReq, &resp); err != nil {
+		return "", err
+	}
+	return resp.VoiceID, nil
+}
+
+// EditVoice updates a voice. Name is required by the API; any samples are
+// added to the existing ones.
+func (c *Client) EditVoice(ctx context.Context, voiceID string, req VoiceRequest) error {
+	if req.Name == "" {
+		return fmt.Errorf("voice name required")
+	}
+	f, err := req.form()
+	if err != nil {
+		return err
+	}
+	httpReq, err := f.request(ctx, c, "POST", "/voices/"+url.PathEscape(voiceID)+"/edit")
+	if err != nil {
+		return err
+	}
+	return c.sendJSON(httpReq, nil)
+}
+
+// DeleteVoice permanently deletes a voice.
+func (c *Client) DeleteVoice(ctx context.Context, voiceID string) error {
+	req, err := c.newRequest(ctx, "DELETE", "/voices/"+url.PathEscape(voiceID), nil)
+	if err != nil {
+		return err
+	}
+	return c.sendJSON(req, nil)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		want string
+		warn bool
+	}{
+		{"utf-8", []byte("héllo"), "héllo", false},
+		{"utf-8 bom", []byte("\xef\xbb\xbfhéllo"), "héllo", false},
+		{"utf-16le", []byte("\xff\xfeh\x00\xe9\x00\x3d\xd8\x00\xde"), "hé😀", false},
+		{"utf-16be", []byte("\xfe\xff\x00h\x00\xe9\xd8\x3d\xde\x00"), "hé😀", false},
+		{"utf-16le bom only", []byte("\xff\xfe"), "", false},
+		{"windows-1252", []byte("caf\xe9 \x93quoted\x94 \x80"), "café “quoted” €", true},
+		{"windows-1252 unused byte", []byte("a\x81b"), "a\ufffdb", true},
+		{"empty", nil, "", false},
+	}
+	keepWarnings = true
+	defer func() { keepWarnings = false }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			takeWarnings()
+			if got := string(decodeText(tt.in, "input")); got != tt.want {
+				t.Errorf("decodeText = %q, want %q", got, tt.want)
+			}
+			ws := takeWarnings()
+			if warned := len(ws) == 1 && ws[0].Code == "input_not_utf8"; warned != tt.warn {
+				t.Errorf("warnings = %+v, want a warning: %v", ws, tt.warn)
+			}
+		})
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"mpv --no-video", []string{"mpv", "--no-video"}},
+		{`"C:\Program Files\mpv\mpv.exe" --no-video`, []string{`C:\Program Files\mpv\mpv.exe`, "--no-video"}},
+		{`C:\Tools\ffplay.exe -nodisp -autoexit`, []string{`C:\Tools\ffplay.exe`, "-nodisp", "-autoexit"}},
+		{`"C:\Program Files (x86)\VLC\vlc.exe" "--input-title-format=a b"`, []string{`C:\Program Files (x86)\VLC\vlc.exe`, "--input-title-format=a b"}},
+		{`--title=""`, []string{"--title="}},
+		{`""`, []string{""}},
+		{"  spaced \t out\r\n", []string{"spaced", "out"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := splitCommand(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitCommand(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
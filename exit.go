@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+	"github.com/pink-tools/pink-otel"
+)
+
+// Exit codes. 5 and 7 are worth retrying later; the rest are not.
+const (
+	exitError        = 1
+	exitAuth         = 3
+	exitQuota        = 4
+	exitRateLimited  = 5
+	exitInvalidVoice = 6
+	exitServer       = 7
+	exitQC           = 8
+)
+
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, elevenlabs.ErrQuotaExceeded):
+		return exitQuota
+	case errors.Is(err, elevenlabs.ErrUnauthorized):
+		return exitAuth
+	case errors.Is(err, elevenlabs.ErrRateLimited):
+		return exitRateLimited
+	case errors.Is(err, elevenlabs.ErrInvalidVoice):
+		return exitInvalidVoice
+	case errors.Is(err, elevenlabs.ErrServer):
+		return exitServer
+	case errors.Is(err, errQCFailed):
+		return exitQC
+	}
+	return exitError
+}
+
+// fail logs err under event, releases any locks and exits with the code
+// matching its kind.
+func fail(event string, err error) {
+	code := exitCode(err)
+	otel.Error(event, map[string]any{"error": err.Error(), "exit_code": code})
+	fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+	exit(code)
+}
+
+// exit releases any locks and exits with code, for exits while a session or
+// feed state may be locked.
+func exit(code int) {
+	releaseLocks()
+	os.Exit(code)
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+)
+
+// withFormatFallback calls call with format and, unless strict, with lower
+// formats while the plan rejects it. It also returns the format used.
+func withFormatFallback[T any](format string, strict bool, call func(format string) (T, error)) (T, string, error) {
+	for {
+		v, err := call(format)
+		if err == nil || strict || !errors.Is(err, elevenlabs.ErrFormatNotAllowed) {
+			return v, format, err
+		}
+		next, ok := elevenlabs.FallbackFormat(format)
+		if !ok {
+			return v, format, err
+		}
+		warn("format_fallback", map[string]any{"format": format, "fallback": next},
+			"%s is not available on this plan, using %s", format, next)
+		format = next
+	}
+}
@@ -0,0 +1,623 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+	"unicode"
+
+	"github.com/pink-tools/pink-elevenlabs/audio"
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+	"github.com/pink-tools/pink-otel"
+)
+
+// feedEntry is an item of an RSS or Atom feed.
+type feedEntry struct {
+	ID        string
+	Title     string
+	Link      string
+	Published time.Time
+	// Content is the entry's HTML or text.
+	Content string
+}
+
+// feed is a parsed RSS 2.0, RSS 1.0 or Atom feed.
+type feed struct {
+	Title   string
+	Entries []feedEntry
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	PubDate     string `xml:"pubDate"`
+	Date        string `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Description string `xml:"description"`
+	Encoded     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+}
+
+type atomEntry struct {
+	ID    string `xml:"id"`
+	Title string `xml:"title"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+	Summary   string `xml:"summary"`
+	Content   string `xml:"content"`
+}
+
+var feedDateLayouts = []string{
+	time.RFC1123Z, time.RFC1123, time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700", "2006-01-02",
+}
+
+func parseFeedDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range feedDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// parseFeed reads an RSS or Atom document. Entries are returned oldest
+// first.
+func parseFeed(r io.Reader) (*feed, error) {
+	var doc struct {
+		Channel struct {
+			Title string    `xml:"title"`
+			Items []rssItem `xml:"item"`
+		} `xml:"channel"`
+		// RSS 1.0 keeps items beside the channel.
+		Items   []rssItem   `xml:"item"`
+		Title   string      `xml:"title"`
+		Entries []atomEntry `xml:"entry"`
+	}
+	d := xml.NewDecoder(r)
+	d.Strict = false
+	d.Entity = xml.HTMLEntity
+	d.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		switch strings.ToLower(charset) {
+		case "iso-8859-1", "latin1", "windows-1252", "us-ascii":
+			// Each Latin-1 byte is the code point of the same value;
+			// Windows-1252 differs only in rarely used punctuation.
+			b, err := io.ReadAll(input)
+			if err != nil {
+				return nil, err
+			}
+			runes := make([]rune, len(b))
+			for i, c := range b {
+				runes[i] = rune(c)
+			}
+			return strings.NewReader(string(runes)), nil
+		}
+		return nil, fmt.Errorf("unsupported feed charset %s", charset)
+	}
+	if err := d.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid feed: %w", err)
+	}
+
+	f := &feed{Title: strings.TrimSpace(doc.Channel.Title + doc.Title)}
+	for _, it := range append(doc.Channel.Items, doc.Items...) {
+		e := feedEntry{
+			ID:        strings.TrimSpace(it.GUID),
+			Title:     strings.TrimSpace(it.Title),
+			Link:      strings.TrimSpace(it.Link),
+			Published: parseFeedDate(it.PubDate),
+			Content:   it.Encoded,
+		}
+		if e.Published.IsZero() {
+			e.Published = parseFeedDate(it.Date)
+		}
+		if e.Content == "" {
+			e.Content = it.Description
+		}
+		if e.ID == "" {
+			e.ID = e.Link
+		}
+		f.Entries = append(f.Entries, e)
+	}
+	for _, it := range doc.Entries {
+		e := feedEntry{
+			ID:        strings.TrimSpace(it.ID),
+			Title:     strings.TrimSpace(it.Title),
+			Published: parseFeedDate(it.Published),
+			Content:   it.Content,
+		}
+		for _, l := range it.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				e.Link = l.Href
+				break
+			}
+		}
+		if e.Published.IsZero() {
+			e.Published = parseFeedDate(it.Updated)
+		}
+		if e.Content == "" {
+			e.Content = it.Summary
+		}
+		if e.ID == "" {
+			e.ID = e.Link
+		}
+		f.Entries = append(f.Entries, e)
+	}
+
+	// Feeds list the newest first; keep that order among undated entries.
+	for i, j := 0, len(f.Entries)-1; i < j; i, j = i+1, j-1 {
+		f.Entries[i], f.Entries[j] = f.Entries[j], f.Entries[i]
+	}
+	sort.SliceStable(f.Entries, func(i, j int) bool {
+		a, b := f.Entries[i].Published, f.Entries[j].Published
+		return !a.IsZero() && !b.IsZero() && a.Before(b)
+	})
+	return f, nil
+}
+
+func fetchFeed(ctx context.Context, feedURL string) (*feed, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid feed URL: %w", err)
+	}
+	req.Header.Set("User-Agent", serviceName+"/"+version)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch feed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch feed: %s", resp.Status)
+	}
+	return parseFeed(io.LimitReader(resp.Body, maxArticleBytes))
+}
+
+// entryText returns what is read for an entry: its title, then its
+// content as text.
+func entryText(e feedEntry) string {
+	body := ""
+	if a, err := extractArticle(e.Content); err == nil {
+		a.Title = ""
+		body = a.Text()
+	} else {
+		body = html.UnescapeString(htmlTag.ReplaceAllString(e.Content, " "))
+	}
+	if e.Title == "" {
+		return body
+	}
+	return (&article{Paragraphs: []string{e.Title}}).Text() + "\n\n" + body
+}
+
+// slug turns a title into a short file name part.
+func slug(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+		if b.Len() >= 60 {
+			break
+		}
+	}
+	return b.String()
+}
+
+// feedState records which entries of a feed were synthesized, in
+// the order they were.
+type feedState struct {
+	path    string
+	lock    *fileLock
+	Feed    string            `json:"feed"`
+	Title   string            `json:"title"`
+	Entries []feedStateRecord `json:"entries"`
+}
+
+type feedStateRecord struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Link      string    `json:"link,omitempty"`
+	Published time.Time `json:"published,omitzero"`
+	// Output is empty for entries skipped with --mark-read.
+	Output     string    `json:"output,omitempty"`
+	Characters int       `json:"characters,omitempty"`
+	Done       time.Time `json:"done"`
+}
+
+func loadFeedState(path, feedURL string) (*feedState, error) {
+	s := &feedState{path: path, Feed: feedURL}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed state: %w", err)
+	}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("invalid feed state %s: %w", path, err)
+	}
+	return s, nil
+}
+
+func (s *feedState) seen(id string) bool {
+	for _, e := range s.Entries {
+		if e.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// save writes the state through a temporary file, so an interrupted run
+// never leaves it truncated.
+func (s *feedState) save() error {
+	if err := s.lock.check(); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write feed state: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// writePodcast writes an RSS feed with one episode per synthesized entry.
+func (s *feedState) writePodcast(path, baseURL string) error {
+	type enclosure struct {
+		URL    string `xml:"url,attr"`
+		Length int64  `xml:"length,attr"`
+		Type   string `xml:"type,attr"`
+	}
+	type item struct {
+		Title     string    `xml:"title"`
+		Link      string    `xml:"link,omitempty"`
+		GUID      string    `xml:"guid"`
+		PubDate   string    `xml:"pubDate"`
+		Enclosure enclosure `xml:"enclosure"`
+	}
+	type channel struct {
+		Title       string `xml:"title"`
+		Link        string `xml:"link"`
+		Description string `xml:"description"`
+		Items       []item `xml:"item"`
+	}
+	type rss struct {
+		XMLName xml.Name `xml:"rss"`
+		Version string   `xml:"version,attr"`
+		Channel channel  `xml:"channel"`
+	}
+
+	doc := rss{Version: "2.0", Channel: channel{
+		Title:       s.Title,
+		Link:        s.Feed,
+		Description: "Audio edition of " + s.Feed,
+	}}
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	entries := slices.Clone(s.Entries)
+	date := func(e feedStateRecord) time.Time {
+		if e.Published.IsZero() {
+			return e.Done
+		}
+		return e.Published
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return date(entries[i]).After(date(entries[j])) })
+	for _, e := range entries {
+		if e.Output == "" {
+			continue
+		}
+		st, err := os.Stat(e.Output)
+		if err != nil {
+			continue
+		}
+		it := item{
+			Title: e.Title,
+			Link:  e.Link,
+			GUID:  e.ID,
+			Enclosure: enclosure{
+				URL:    baseURL + url.PathEscape(filepath.Base(e.Output)),
+				Length: st.Size(),
+				Type:   enclosureType(e.Output),
+			},
+		}
+		it.PubDate = date(e).Format(time.RFC1123Z)
+		doc.Channel.Items = append(doc.Channel.Items, it)
+	}
+
+	b, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append([]byte(xml.Header), append(b, '\n')...), 0644); err != nil {
+		return fmt.Errorf("failed to write podcast feed: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+func enclosureType(path string) string {
+	switch filepath.Ext(path) {
+	case ".mp3":
+		return "audio/mpeg"
+	case ".ogg":
+		return "audio/ogg"
+	}
+	return "application/octet-stream"
+}
+
+// feedRun synthesizes the new entries of a feed.
+type feedRun struct {
+	client      *elevenlabs.Client
+	out         *outputFlags
+	feedURL     string
+	state       *feedState
+	voiceID     string
+	model       string
+	settings    elevenlabs.VoiceSettings
+	limit       int
+	chunkSize   int
+	continuity  bool
+	fullArticle bool
+	markRead    bool
+	podcast     string
+	podcastURL  string
+}
+
+// poll fetches the feed once and synthesizes its new entries, newest last.
+func (r *feedRun) poll(ctx context.Context) error {
+	f, err := fetchFeed(ctx, r.feedURL)
+	if err != nil {
+		return err
+	}
+	r.state.Title = f.Title
+
+	var fresh []feedEntry
+	for _, e := range f.Entries {
+		if e.ID != "" && !r.state.seen(e.ID) {
+			fresh = append(fresh, e)
+		}
+	}
+	if r.limit > 0 && len(fresh) > r.limit {
+		fresh = fresh[len(fresh)-r.limit:]
+	}
+	otel.Info("feed_poll", map[string]any{"feed": r.feedURL, "entries": len(f.Entries), "new": len(fresh)})
+
+	for _, e := range fresh {
+		// Each entry's report lists only its own warnings.
+		takeWarnings()
+		rec := feedStateRecord{ID: e.ID, Title: e.Title, Link: e.Link, Published: e.Published}
+		if !r.markRead {
+			path, characters, err := r.synthesize(ctx, e)
+			if err != nil {
+				return fmt.Errorf("entry %q: %w", e.Title, err)
+			}
+			rec.Output = path
+			if characters >= 0 {
+				rec.Characters = characters
+			}
+			r.out.report(path, characters, nil)
+		}
+		rec.Done = time.Now().UTC()
+		r.state.Entries = append(r.state.Entries, rec)
+		if err := r.state.save(); err != nil {
+			return err
+		}
+	}
+	if r.podcast != "" && (len(fresh) > 0 || !fileExists(r.podcast)) {
+		return r.state.writePodcast(r.podcast, r.podcastURL)
+	}
+	return nil
+}
+
+func (r *feedRun) synthesize(ctx context.Context, e feedEntry) (string, int, error) {
+	text := entryText(e)
+	if r.fullArticle && e.Link != "" {
+		a, err := fetchArticle(ctx, e.Link)
+		if err != nil {
+			return "", 0, err
+		}
+		text = a.Text()
+	}
+
+	limit := r.chunkSize
+	if limit <= 0 {
+		limit = elevenlabs.MaxChars(r.model)
+	}
+	parts := buildParts(stripAudioTags([]segment{{Text: text}}, r.model), r.voiceID, limit, r.continuity)
+	if len(parts) == 0 {
+		return "", 0, errors.New("entry has no text")
+	}
+	tags := maps.Clone(audio.Tags(r.out.tags))
+	if codec := formatCodec(*r.out.format); tags["title"] == "" && e.Title != "" && (codec == audio.MP3 || codec == audio.Opus) {
+		if tags == nil {
+			tags = audio.Tags{}
+		}
+		tags["title"] = e.Title
+	}
+	job := ttsJob{
+		Parts:    parts,
+		Model:    r.model,
+		Format:   *r.out.format,
+		Settings: r.settings,
+		Tags:     tags,
+		Stream:   *r.out.stream,
+		Strict:   *r.out.strict,
+	}
+
+	otel.Info("feed_entry_request", map[string]any{"feed": r.feedURL, "id": e.ID, "characters": len(text)})
+	path, generated := r.out.resolve(outputName{Prefix: "feed", Voice: r.voiceID, Input: slug(e.Title), Seed: e.ID})
+	w, err := r.out.open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	res, err := textToSpeech(ctx, r.client, job, w)
+	if cerr := w.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("failed to write output: %w", cerr)
+	}
+	if err != nil {
+		if generated {
+			os.Remove(path)
+		}
+		return "", 0, err
+	}
+	otel.Info("feed_entry_complete", map[string]any{"feed": r.feedURL, "id": e.ID, "output": path, "characters": res.Characters})
+	*r.out.format = res.Format
+	r.out.finish(path, res.Characters, "feed_play_failed")
+	return path, res.Characters, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func cmdFeed(args []string) {
+	fs := flag.NewFlagSet("feed", flag.ExitOnError)
+
+	out := addOutputFlags(fs)
+
+	voice := fs.String("voice", "", "Voice ID")
+	fs.StringVar(voice, "v", "", "Voice ID")
+	model := fs.String("model", "", "Model ID (default: "+elevenlabs.DefaultTTSModel+")")
+	fs.StringVar(model, "m", "", "Model ID")
+	settings := addSettingsFlags(fs)
+	chunkSize := fs.Int("chunk-size", 0, "Max characters per request (default: model limit)")
+	continuity := fs.Bool("continuity", false, "Send neighbouring chunk text for smoother prosody")
+
+	watch := fs.Duration("watch", 0, "Poll the feed at this interval instead of once")
+	limit := fs.Int("limit", 0, "Synthesize at most the newest n new entries per poll (0: all)")
+	markRead := fs.Bool("mark-read", false, "Record the current entries as done without synthesizing them")
+	fullArticle := fs.Bool("full-article", false, "Read each entry's linked page instead of the feed's text")
+	statePath := fs.String("state", "", "State file (default: feed-<hash>.json in the output directory)")
+	podcast := fs.String("podcast", "", "Also write a podcast RSS feed of the audio here")
+	podcastURL := fs.String("podcast-url", "", "URL the audio files are served under, for --podcast")
+
+	api := addClientFlags(fs)
+
+	parseArgs(fs, args)
+
+	if fs.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "ERROR: Feed URL argument required")
+		exit(1)
+	}
+	if *podcast != "" && *podcastURL == "" {
+		fmt.Fprintln(os.Stderr, "ERROR: --podcast needs --podcast-url")
+		exit(1)
+	}
+	if *out.output == "-" {
+		fmt.Fprintln(os.Stderr, "ERROR: feed writes one file per entry, not -o -")
+		exit(1)
+	}
+	out.check()
+	feedURL := fs.Arg(0)
+
+	dir := getOutputDir(*out.outputDir)
+	if *out.output == "" {
+		// Name files after the entries unless a template is given.
+		*out.output = filepath.Join(dir, "{date}-{input}{ext}")
+	}
+	if *statePath == "" {
+		sum := sha256.Sum256([]byte(feedURL))
+		*statePath = filepath.Join(dir, "feed-"+hex.EncodeToString(sum[:])[:8]+".json")
+	}
+	if err := os.MkdirAll(filepath.Dir(*statePath), 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: failed to create output directory: %v\n", err)
+		exit(1)
+	}
+	voiceID := *voice
+	var client *elevenlabs.Client
+	if !*markRead {
+		if voiceID == "" {
+			voiceID = getTTSVoiceID()
+		}
+		client = api.newClient()
+	}
+
+	// Two runs on the same state would synthesize the same entries.
+	lock, err := lockFile(context.Background(), *statePath, false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		exit(1)
+	}
+	defer lock.unlock()
+	state, err := loadFeedState(*statePath, feedURL)
+	if err != nil {
+		lock.unlock()
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		exit(1)
+	}
+	state.lock = lock
+
+	run := &feedRun{
+		client:      client,
+		out:         out,
+		feedURL:     feedURL,
+		state:       state,
+		voiceID:     voiceID,
+		model:       *model,
+		settings:    settings.settings(),
+		limit:       *limit,
+		chunkSize:   *chunkSize,
+		continuity:  *continuity,
+		fullArticle: *fullArticle,
+		markRead:    *markRead,
+		podcast:     *podcast,
+		podcastURL:  *podcastURL,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	for {
+		err := run.poll(ctx)
+		if *watch == 0 || ctx.Err() != nil {
+			if err != nil {
+				fail("feed_failed", err)
+			}
+			return
+		}
+		if err != nil {
+			warn("feed_poll_failed", map[string]any{"feed": feedURL, "error": err.Error()}, "%v", err)
+		}
+		takeWarnings()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(*watch):
+		}
+	}
+}
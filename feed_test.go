@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFeedMarkReadWithoutKey(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<?xml version="1.0"?><rss version="2.0"><channel><title>News</title>
+<item><title>One</title><guid>one</guid><description>First</description></item>
+<item><title>Two</title><guid>two</guid><description>Second</description></item>
+</channel></rss>`))
+	}))
+	defer srv.Close()
+
+	state := filepath.Join(t.TempDir(), "state.json")
+	_, stderr, code := runMain(t, nil, "feed", "--mark-read", "--state", state, srv.URL)
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	var s feedState
+	b, _ := os.ReadFile(state)
+	if err := json.Unmarshal(b, &s); err != nil || len(s.Entries) != 2 {
+		t.Errorf("state has %d entries (%v), want 2", len(s.Entries), err)
+	}
+}
+
+func TestFeedProfileVoice(t *testing.T) {
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<?xml version="1.0"?><rss version="2.0"><channel><title>News</title>
+<item><title>One</title><guid>one</guid><description>First</description></item>
+</channel></rss>`))
+	}))
+	defer feed.Close()
+	paths := make(chan string, 1)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case paths <- r.URL.Path:
+		default:
+		}
+		w.Write(make([]byte, 320))
+	}))
+	defer api.Close()
+
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.yaml")
+	os.WriteFile(config, []byte("default_profile: work\nprofiles:\n  work:\n    tts_voice: profilevoice\n"), 0644)
+	_, stderr, code := runMain(t, []string{
+		"ELEVENLABS_API_KEY=key",
+		"ELEVENLABS_BASE_URL=" + api.URL,
+		"ELEVENLABS_CONFIG=" + config,
+	}, "feed", "-f", "pcm", "--state", filepath.Join(dir, "state.json"), "-d", dir, feed.URL)
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if path := <-paths; !strings.HasPrefix(path, "/text-to-speech/profilevoice") {
+		t.Errorf("request path = %q, want the profile's voice", path)
+	}
+}
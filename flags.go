@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// keyValueFlag collects key=value pairs from repeated flags, each of which
+// may also hold a comma-separated list.
+type keyValueFlag map[string]string
+
+func (f keyValueFlag) String() string {
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + f[k]
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (f keyValueFlag) Set(v string) error {
+	for _, pair := range strings.Split(v, ",") {
+		k, val, ok := strings.Cut(pair, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return fmt.Errorf("expected key=value, got %q", pair)
+		}
+		f[k] = strings.TrimSpace(val)
+	}
+	return nil
+}
+
+// parseArgs parses fs allowing flags after positional arguments, then fills
+// in flags from the job and the profile.
+func parseArgs(fs *flag.FlagSet, args []string) {
+	profileName := fs.String("profile", "", "Config profile (default: ELEVENLABS_PROFILE or the config's default_profile)")
+	otelAttrs := keyValueFlag{}
+	fs.Var(otelAttrs, "otel-attr", "otel resource attribute as key=value, repeatable")
+
+	var positional []string
+	for len(args) > 0 {
+		if err := fs.Parse(args); err != nil {
+			// The flag set has printed the error and usage, as ExitOnError would.
+			if err == flag.ErrHelp {
+				os.Exit(0)
+			}
+			os.Exit(2)
+		}
+		rest := fs.Args()
+		if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+			positional = append(positional, rest...)
+			break
+		}
+		if len(rest) == 0 {
+			break
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+	if fs.Lookup("job") != nil {
+		job, err := loadJob(fs)
+		if err == nil && job != nil {
+			positional, err = applyJob(fs, job, positional)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	fs.Parse(append([]string{"--"}, positional...))
+
+	applyProfile(fs, *profileName)
+	if machineMode(fs) {
+		claimStdout()
+	}
+	if f := fs.Lookup("json"); f != nil && f.Value.String() == "true" {
+		keepWarnings = true
+	}
+	initTelemetry(otelAttrs)
+}
+
+// listFlag collects values from repeated flags, each of which may also hold
+// a comma-separated list.
+type listFlag []string
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *listFlag) Set(v string) error {
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			*f = append(*f, s)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	t.Setenv("ELEVENLABS_CONFIG", filepath.Join(t.TempDir(), "config.yaml"))
+	t.Setenv("ELEVENLABS_PROFILE", "")
+	tests := []struct {
+		args       []string
+		output     string
+		verbose    bool
+		positional []string
+	}{
+		{[]string{"-o", "a.mp3", "text"}, "a.mp3", false, []string{"text"}},
+		{[]string{"text", "-o", "a.mp3"}, "a.mp3", false, []string{"text"}},
+		{[]string{"one", "-v", "two", "--output=b.mp3"}, "b.mp3", true, []string{"one", "two"}},
+		{[]string{"text", "--", "-o", "c.mp3"}, "", false, []string{"text", "-o", "c.mp3"}},
+		{[]string{"-v", "--", "--"}, "", true, []string{"--"}},
+		{nil, "", false, []string{}},
+	}
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		output := fs.String("output", "", "")
+		fs.StringVar(output, "o", "", "")
+		verbose := fs.Bool("v", false, "")
+		parseArgs(fs, tt.args)
+		if *output != tt.output || *verbose != tt.verbose || !reflect.DeepEqual(fs.Args(), tt.positional) {
+			t.Errorf("parseArgs(%q): -o %q -v %v args %q, want -o %q -v %v args %q",
+				tt.args, *output, *verbose, fs.Args(), tt.output, tt.verbose, tt.positional)
+		}
+	}
+}
+
+func TestParseArgsBadFlagAfterPositional(t *testing.T) {
+	_, stderr, code := runMain(t, []string{"ELEVENLABS_API_KEY=key", "ELEVENLABS_TTS_VOICE_ID=voice"},
+		"tts", "Hello", "--stability", "high")
+	if code != 2 || !bytes.Contains(stderr, []byte(`invalid value "high" for flag -stability`)) {
+		t.Errorf("exit %d, stderr %q; want a usage error", code, stderr)
+	}
+}
+
+func TestParseArgsContinueOnError(t *testing.T) {
+	if os.Getenv("PINK_ELEVENLABS_PARSE_CHILD") != "" {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.Int("n", 0, "")
+		parseArgs(fs, []string{"text", "-n", "x"})
+		os.Exit(0)
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestParseArgsContinueOnError$")
+	cmd.Env = append(os.Environ(), "PINK_ELEVENLABS_PARSE_CHILD=1",
+		"ELEVENLABS_CONFIG="+filepath.Join(t.TempDir(), "config.yaml"), "ELEVENLABS_PROFILE=")
+	var exit *exec.ExitError
+	if err := cmd.Run(); !errors.As(err, &exit) || exit.ExitCode() != 2 {
+		t.Errorf("bad flag value after a positional argument: %v, want exit 2", err)
+	}
+}
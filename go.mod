@@ -1,10 +1,12 @@
-module pink-elevenlabs
+module github.com/pink-tools/pink-elevenlabs
 
 go 1.24.0
 
 require (
+	github.com/coder/websocket v1.8.15
 	github.com/joho/godotenv v1.5.1
 	github.com/pink-tools/pink-otel v0.0.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
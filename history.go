@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"os"
+
+	"github.com/pink-tools/pink-elevenlabs/audio"
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+	"github.com/pink-tools/pink-otel"
+)
+
+// historyScanLimit is how many recent history items --reuse-history looks
+// through.
+const historyScanLimit = 500
+
+type historyKey struct {
+	voiceID, model, text string
+}
+
+// historyIndex maps the text, voice and model of recent generations to
+// their history item IDs, keeping the newest of duplicates.
+type historyIndex map[historyKey]string
+
+func loadHistoryIndex(ctx context.Context, client *elevenlabs.Client) (historyIndex, error) {
+	idx := historyIndex{}
+	q := elevenlabs.HistoryQuery{PageSize: 100}
+	for seen := 0; seen < historyScanLimit; {
+		page, err := client.History(ctx, q)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list history: %w", err)
+		}
+		for _, item := range page.History {
+			k := historyKey{item.VoiceID, item.ModelID, item.Text}
+			if _, ok := idx[k]; !ok {
+				idx[k] = item.HistoryItemID
+			}
+		}
+		seen += len(page.History)
+		if !page.HasMore || len(page.History) == 0 {
+			break
+		}
+		q.StartAfter = page.LastHistoryItemID
+	}
+	return idx, nil
+}
+
+// historyCodecs maps the content types of history audio to codecs. Raw
+// formats don't say their sample rate and are never reused.
+var historyCodecs = map[string]audio.Codec{
+	"audio/mpeg": audio.MP3,
+	"audio/mp3":  audio.MP3,
+	"audio/ogg":  audio.Opus,
+	"audio/opus": audio.Opus,
+}
+
+// reuse returns the audio of an earlier identical generation of part, or nil.
+func (idx historyIndex) reuse(ctx context.Context, client *elevenlabs.Client, part ttsPart, model, format string) *elevenlabs.Audio {
+	if model == "" {
+		model = elevenlabs.DefaultTTSModel
+	}
+	id, ok := idx[historyKey{part.VoiceID, model, part.Text}]
+	if !ok {
+		return nil
+	}
+	want := formatCodec(format)
+	if want != audio.MP3 && want != audio.Opus {
+		return nil
+	}
+
+	a, err := client.HistoryAudio(ctx, id)
+	if err != nil {
+		warn("tts_history_reuse_failed", map[string]any{"history_item_id": id, "error": err.Error()},
+			"failed to download history item %s, synthesizing: %v", id, err)
+		return nil
+	}
+	ct, _, _ := mime.ParseMediaType(a.ContentType)
+	if c, ok := historyCodecs[ct]; !ok || c != want {
+		a.Close()
+		return nil
+	}
+	// Downloads aren't billed.
+	a.CharacterCount = 0
+	otel.Info("tts_history_reused", map[string]any{"history_item_id": id, "voice_id": part.VoiceID})
+	fmt.Fprintf(os.Stderr, "Reusing history item %s\n", id)
+	return a
+}
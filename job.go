@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A job file is a JSON object holding a whole invocation, keyed by long
+// flag names:
+//
+//	{"text": "Hello", "voice": "VOICE_ID", "format": "mp3", "tag": {"title": "Hi"}}
+
+// jobArgs names the job field holding each command's positional argument.
+var jobArgs = map[string]string{
+	"tts":   "text",
+	"voice": "input",
+}
+
+// addJobFlag registers --job; parseArgs applies the job of any command that
+// has it.
+func addJobFlag(fs *flag.FlagSet) {
+	fs.String("job", "", "JSON job file with flag values (- for stdin; default: PINK_ELEVENLABS_JOB)")
+}
+
+// loadJob reads the job named by --job, or PINK_ELEVENLABS_JOB, which may
+// hold either the JSON itself or a path.
+func loadJob(fs *flag.FlagSet) (map[string]any, error) {
+	src := fs.Lookup("job").Value.String()
+	var b []byte
+	var err error
+	switch {
+	case src != "":
+		b, err = readInputFile(src)
+	default:
+		loadEnv()
+		env := strings.TrimSpace(os.Getenv("PINK_ELEVENLABS_JOB"))
+		if env == "" {
+			return nil, nil
+		}
+		if strings.HasPrefix(env, "{") {
+			b = []byte(env)
+		} else {
+			b, err = readInputFile(env)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var job map[string]any
+	if err := json.Unmarshal(b, &job); err != nil {
+		return nil, fmt.Errorf("failed to parse job: %w", err)
+	}
+	return job, nil
+}
+
+// applyJob sets every flag of fs named in job that wasn't given, and returns
+// the positional arguments.
+func applyJob(fs *flag.FlagSet, job map[string]any, positional []string) ([]string, error) {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+		if long, ok := shortFlags[f.Name]; ok {
+			set[long] = true
+		}
+	})
+
+	keys := make([]string, 0, len(job))
+	for k := range job {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, name := range keys {
+		value := job[name]
+		if fs.Lookup(name) == nil {
+			if name != jobArgs[fs.Name()] {
+				return nil, fmt.Errorf("unknown job field %q for %s", name, fs.Name())
+			}
+			if len(positional) == 0 {
+				s, ok := value.(string)
+				if !ok {
+					return nil, fmt.Errorf("job field %q must be a string", name)
+				}
+				positional = []string{s}
+			}
+			continue
+		}
+		if set[name] {
+			continue
+		}
+		values, err := jobValues(value)
+		if err != nil {
+			return nil, fmt.Errorf("job field %q: %w", name, err)
+		}
+		for _, v := range values {
+			if err := fs.Set(name, v); err != nil {
+				return nil, fmt.Errorf("job field %q: %w", name, err)
+			}
+		}
+	}
+	return positional, nil
+}
+
+func jobValues(v any) ([]string, error) {
+	switch v := v.(type) {
+	case string:
+		return []string{v}, nil
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}, nil
+	case bool:
+		return []string{strconv.FormatBool(v)}, nil
+	case []any:
+		var out []string
+		for _, e := range v {
+			s, err := jobValues(e)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, s...)
+		}
+		return out, nil
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var out []string
+		for _, k := range keys {
+			s, err := jobValues(v[k])
+			if err != nil || len(s) != 1 {
+				return nil, fmt.Errorf("value of %q must be a scalar", k)
+			}
+			out = append(out, k+"="+s[0])
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unsupported value %v", v)
+	}
+}
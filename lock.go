@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// A lock file untouched for lockStale belongs to a run that died.
+	lockStale     = 30 * time.Second
+	lockHeartbeat = lockStale / 3
+	lockPoll      = 200 * time.Millisecond
+)
+
+var (
+	errLocked   = errors.New("in use by another run")
+	errLockLost = errors.New("lock was taken over by another run")
+)
+
+var (
+	heldLocksMu sync.Mutex
+	heldLocks   = map[*fileLock]bool{}
+)
+
+// fileLock is a lock file beside a file shared between runs, such as a
+// session or a feed's state.
+type fileLock struct {
+	path  string
+	id    string
+	token string
+	stop  chan struct{}
+	done  chan struct{}
+
+	mu   sync.Mutex
+	lost bool
+}
+
+// lockFile locks path for this run. If another run holds it, lockFile
+// waits for it to finish or, unless wait is set, returns errLocked.
+func lockFile(ctx context.Context, path string, wait bool) (*fileLock, error) {
+	host, _ := os.Hostname()
+	nonce := make([]byte, 8)
+	rand.Read(nonce)
+	id := hex.EncodeToString(nonce)
+	l := &fileLock{
+		path:  path + ".lock",
+		id:    id,
+		token: fmt.Sprintf("pid %d on %s (%s)", os.Getpid(), host, id),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	announced := false
+	for {
+		f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = fmt.Fprintln(f, l.token)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(l.path)
+				return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+			}
+			go l.heartbeat()
+			heldLocksMu.Lock()
+			heldLocks[l] = true
+			heldLocksMu.Unlock()
+			return l, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+
+		if fi, err := os.Stat(l.path); err == nil && time.Since(fi.ModTime()) > lockStale {
+			l.breakStale()
+			continue
+		}
+		holder, _ := os.ReadFile(l.path)
+		if !wait {
+			return nil, fmt.Errorf("%s is %w (%s)", path, errLocked, strings.TrimSpace(string(holder)))
+		}
+		if !announced {
+			fmt.Fprintf(os.Stderr, "Waiting for %s, in use by %s\n", path, strings.TrimSpace(string(holder)))
+			announced = true
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockPoll):
+		}
+	}
+}
+
+// breakStale removes a stale lock file, moving it aside first so a fresh
+// lock taken in its place is spared.
+func (l *fileLock) breakStale() {
+	aside := l.path + "." + l.id + ".stale"
+	if os.Rename(l.path, aside) != nil {
+		return
+	}
+	if fi, err := os.Stat(aside); err == nil && time.Since(fi.ModTime()) <= lockStale {
+		// Another run's live lock: put it back unless it was replaced.
+		os.Link(aside, l.path)
+	}
+	os.Remove(aside)
+}
+
+// owned reports whether the lock file is still this lock's.
+func (l *fileLock) owned() bool {
+	b, err := os.ReadFile(l.path)
+	return err == nil && strings.TrimSpace(string(b)) == l.token
+}
+
+// check returns errLockLost if another run has taken the lock over. It is
+// nil-safe, for state that isn't locked.
+func (l *fileLock) check() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.lost {
+		return errLockLost
+	}
+	return nil
+}
+
+// heartbeat keeps the lock file fresh while the lock is held, and notices
+// when it has been taken over.
+func (l *fileLock) heartbeat() {
+	defer close(l.done)
+	t := time.NewTicker(lockHeartbeat)
+	defer t.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case now := <-t.C:
+			// breakStale briefly moves a live lock aside; look twice.
+			if !l.owned() {
+				time.Sleep(lockPoll)
+				if !l.owned() {
+					l.mu.Lock()
+					l.lost = true
+					l.mu.Unlock()
+					warn("lock_lost", map[string]any{"path": l.path},
+						"lost the lock on %s to another run", strings.TrimSuffix(l.path, ".lock"))
+					return
+				}
+			}
+			os.Chtimes(l.path, now, now)
+		}
+	}
+}
+
+// unlock releases the lock. It is safe to call more than once.
+func (l *fileLock) unlock() {
+	heldLocksMu.Lock()
+	held := heldLocks[l]
+	delete(heldLocks, l)
+	heldLocksMu.Unlock()
+	if !held {
+		return
+	}
+	close(l.stop)
+	<-l.done
+	if l.owned() {
+		os.Remove(l.path)
+	}
+}
+
+// releaseLocks releases every lock still held, before exiting.
+func releaseLocks() {
+	heldLocksMu.Lock()
+	locks := make([]*fileLock, 0, len(heldLocks))
+	for l := range heldLocks {
+		locks = append(locks, l)
+	}
+	heldLocksMu.Unlock()
+	for _, l := range locks {
+		l.unlock()
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLockFileExclusive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var inside, maxInside atomic.Int32
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 5 {
+				l, err := lockFile(ctx, path, true)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				n := inside.Add(1)
+				for {
+					m := maxInside.Load()
+					if n <= m || maxInside.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				inside.Add(-1)
+				l.unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if m := maxInside.Load(); m != 1 {
+		t.Errorf("%d runs held the lock at once", m)
+	}
+}
+
+func TestLockFileStaleTakeover(t *testing.T) {
+	for round := range 50 {
+		path := filepath.Join(t.TempDir(), "state.json")
+		old := time.Now().Add(-2 * lockStale)
+		if err := os.WriteFile(path+".lock", []byte("pid 1 on dead\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path+".lock", old, old)
+
+		// Every waiter sees the same stale lock; exactly one may take it,
+		// and the others must still find it held.
+		const waiters = 8
+		var start sync.WaitGroup
+		start.Add(1)
+		locks := make(chan *fileLock, waiters)
+		var wg sync.WaitGroup
+		for range waiters {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				start.Wait()
+				l, err := lockFile(context.Background(), path, false)
+				if err == nil {
+					locks <- l
+				} else if !errors.Is(err, errLocked) {
+					t.Error(err)
+				}
+			}()
+		}
+		start.Done()
+		wg.Wait()
+		close(locks)
+
+		var held []*fileLock
+		for l := range locks {
+			held = append(held, l)
+		}
+		if len(held) != 1 {
+			t.Fatalf("round %d: %d waiters took over the stale lock", round, len(held))
+		}
+		if !held[0].owned() {
+			t.Fatalf("round %d: the lock file isn't the holder's", round)
+		}
+		held[0].unlock()
+		if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+			t.Fatalf("round %d: unlock left the lock file: %v", round, err)
+		}
+	}
+}
+
+func TestBreakStaleSparesFreshLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	old := time.Now().Add(-2 * lockStale)
+	os.WriteFile(path+".lock", []byte("pid 1 on dead\n"), 0644)
+	os.Chtimes(path+".lock", old, old)
+
+	a, err := lockFile(context.Background(), path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.unlock()
+
+	// A second waiter acts on the stale lock it saw before a took over.
+	b := &fileLock{path: path + ".lock", id: "b"}
+	b.breakStale()
+	if !a.owned() {
+		t.Fatal("breaking the stale lock removed the fresh one")
+	}
+	if _, err := lockFile(context.Background(), path, false); !errors.Is(err, errLocked) {
+		t.Fatalf("got %v, want errLocked", err)
+	}
+}
+
+func TestUnlockSparesTakenOverLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	l, err := lockFile(context.Background(), path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.check(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another run takes over; its lock must survive this one's unlock.
+	os.WriteFile(path+".lock", []byte("pid 2 on elsewhere\n"), 0644)
+	if l.owned() {
+		t.Fatal("owned after being taken over")
+	}
+	l.unlock()
+	if b, _ := os.ReadFile(path + ".lock"); string(b) != "pid 2 on elsewhere\n" {
+		t.Errorf("unlock removed another run's lock, left %q", b)
+	}
+}
+
+func TestExitReleasesSessionLock(t *testing.T) {
+	srv := fakeTTS(t)
+	dir := t.TempDir()
+	blocker := filepath.Join(dir, "file")
+	os.WriteFile(blocker, nil, 0644)
+	env := append(ttsEnv(srv), "ELEVENLABS_SESSION_DIR="+dir)
+	_, stderr, code := runMain(t, env, "tts", "--session", "s1", "-f", "mp3", "-o", filepath.Join(blocker, "{hash}{ext}"), "Hello")
+	if code != 1 {
+		t.Fatalf("exit %d: %s; want a failure to reserve the output", code, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "s1.json.lock")); !os.IsNotExist(err) {
+		t.Errorf("session lock left behind after exit (%v)", err)
+	}
+}
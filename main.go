@@ -1,18 +1,19 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"cmp"
+	"context"
 	"flag"
 	"fmt"
-	"io"
-	"mime/multipart"
-	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/pink-tools/pink-elevenlabs/audio"
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
 	"github.com/pink-tools/pink-otel"
 )
 
@@ -20,33 +21,23 @@ const (
 	serviceName = "pink-elevenlabs"
 	version     = "2.0.0"
 
-	apiBaseURL = "https://api.elevenlabs.io/v1"
-
-	defaultTTSModel   = "eleven_v3"
-	defaultVoiceModel = "eleven_multilingual_sts_v2"
-
-	defaultStability      = 0.0
+	defaultStability       = 0.0
 	defaultSimilarityBoost = 0.75
-	defaultStyle          = 0.5
-	defaultSpeed          = 1.0
+	defaultStyle           = 0.5
+	defaultSpeed           = 1.0
+
+	defaultRetries      = 3
+	defaultRetryMaxWait = 60 * time.Second
 )
 
+// outputFormats are the defaults behind the short format names; -f also
+// accepts any API format string such as mp3_22050_32.
 var outputFormats = map[string]string{
 	"opus": "opus_48000_96",
 	"mp3":  "mp3_44100_128",
 	"pcm":  "pcm_44100",
-}
-
-func init() {
-	otel.Init(serviceName)
-}
-
-func getDefaultTTSOutput() string {
-	return filepath.Join(os.TempDir(), "speech.ogg")
-}
-
-func getDefaultVoiceOutput() string {
-	return filepath.Join(os.TempDir(), "voice_changed.ogg")
+	"ulaw": "ulaw_8000",
+	"alaw": "alaw_8000",
 }
 
 func loadEnv() {
@@ -62,15 +53,26 @@ func loadEnv() {
 	godotenv.Load(".env")
 }
 
-func getAPIKey() string {
+// lookupAuth returns the credential and whether it is a bearer token, or "".
+func lookupAuth() (string, bool) {
+	if p := activeProfile; p != nil && (p.BearerToken != "" || p.APIKey != "") {
+		return cmp.Or(p.BearerToken, p.APIKey), p.BearerToken != ""
+	}
 	loadEnv()
-	key := os.Getenv("ELEVENLABS_API_KEY")
+	if token := os.Getenv("ELEVENLABS_BEARER_TOKEN"); token != "" {
+		return token, true
+	}
+	return os.Getenv("ELEVENLABS_API_KEY"), false
+}
+
+func getAuth() (string, bool) {
+	key, bearer := lookupAuth()
 	if key == "" {
 		otel.Error("ELEVENLABS_API_KEY not found")
-		fmt.Fprintln(os.Stderr, "ERROR: ELEVENLABS_API_KEY not found in environment")
+		fmt.Fprintln(os.Stderr, "ERROR: ELEVENLABS_API_KEY (or ELEVENLABS_BEARER_TOKEN) not found in environment")
 		os.Exit(1)
 	}
-	return key
+	return key, bearer
 }
 
 func getTTSVoiceID() string {
@@ -95,188 +97,184 @@ func getVoiceChangeID() string {
 	return id
 }
 
-func checkHealth() bool {
-	loadEnv()
-	key := os.Getenv("ELEVENLABS_API_KEY")
-	if key == "" {
-		return false
-	}
-
-	req, err := http.NewRequest("GET", apiBaseURL+"/user", nil)
-	if err != nil {
-		return false
-	}
-	req.Header.Set("xi-api-key", key)
+type clientFlags struct {
+	retries      *int
+	retryMaxWait *time.Duration
+	baseURLs     *listFlag
+}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return false
+func addClientFlags(fs *flag.FlagSet) *clientFlags {
+	loadEnv()
+	f := &clientFlags{
+		retries:      fs.Int("retries", envInt("ELEVENLABS_RETRIES", defaultRetries), "Retries for rate-limited or failed requests"),
+		retryMaxWait: fs.Duration("retry-max-wait", envDuration("ELEVENLABS_RETRY_MAX_WAIT", defaultRetryMaxWait), "Longest wait between retries"),
+		baseURLs:     &listFlag{},
 	}
-	defer resp.Body.Close()
-
-	return resp.StatusCode == 200
+	fs.Var(f.baseURLs, "base-url", "API base URL, repeatable in failover order (default: ELEVENLABS_BASE_URL or "+elevenlabs.DefaultBaseURL+")")
+	return f
 }
 
-type ttsRequest struct {
-	Text         string       `json:"text"`
-	ModelID      string       `json:"model_id"`
-	VoiceSettings voiceSettings `json:"voice_settings"`
+func (f *clientFlags) newClient() *elevenlabs.Client {
+	baseURLs := *f.baseURLs
+	if len(baseURLs) == 0 {
+		baseURLs.Set(os.Getenv("ELEVENLABS_BASE_URL"))
+	}
+	failover := func(from, to string, err error) {
+		warn("api_failover", map[string]any{"from": from, "to": to, "error": err.Error()},
+			"%s failed (%v), switching to %s", from, err, to)
+	}
+	key, bearer := getAuth()
+	opts := []elevenlabs.Option{elevenlabs.WithBaseURLs(baseURLs...), elevenlabs.WithFailoverHook(failover), elevenlabs.WithRetry(elevenlabs.RetryPolicy{
+		MaxRetries: *f.retries,
+		MaxWait:    *f.retryMaxWait,
+		OnRetry: func(attempt int, delay time.Duration, err error) {
+			otel.Info("api_retry", map[string]any{
+				"attempt":  attempt,
+				"delay_ms": delay.Milliseconds(),
+				"error":    err.Error(),
+			})
+			fmt.Fprintf(os.Stderr, "Retrying in %s (%d/%d): %v\n", delay.Round(time.Millisecond), attempt, *f.retries, err)
+		},
+	})}
+	if bearer {
+		opts = append(opts, elevenlabs.WithBearerAuth())
+	}
+	return elevenlabs.NewClient(key, opts...)
 }
 
-type voiceSettings struct {
-	Stability       float64 `json:"stability"`
-	SimilarityBoost float64 `json:"similarity_boost"`
-	Style           float64 `json:"style"`
-	Speed           float64 `json:"speed"`
-	UseSpeakerBoost bool    `json:"use_speaker_boost"`
+type settingsFlags struct {
+	fs              *flag.FlagSet
+	stability       *float64
+	similarityBoost *float64
+	style           *float64
+	speed           *float64
+	noSpeakerBoost  *bool
 }
 
-func textToSpeech(text, outputPath, voiceID, format string, stability, similarityBoost, style, speed float64, speakerBoost bool) error {
-	apiKey := getAPIKey()
-
-	apiFormat, ok := outputFormats[format]
-	if !ok {
-		return fmt.Errorf("unsupported format: %s", format)
-	}
-
-	reqBody := ttsRequest{
-		Text:    text,
-		ModelID: defaultTTSModel,
-		VoiceSettings: voiceSettings{
-			Stability:       stability,
-			SimilarityBoost: similarityBoost,
-			Style:           style,
-			Speed:           speed,
-			UseSpeakerBoost: speakerBoost,
-		},
+func addSettingsFlags(fs *flag.FlagSet) *settingsFlags {
+	return &settingsFlags{
+		fs:              fs,
+		stability:       fs.Float64("stability", defaultStability, "Voice stability (0.0-1.0)"),
+		similarityBoost: fs.Float64("similarity-boost", defaultSimilarityBoost, "Similarity boost (0.0-1.0)"),
+		style:           fs.Float64("style", defaultStyle, "Style exaggeration (0.0-1.0)"),
+		speed:           fs.Float64("speed", defaultSpeed, "Speech speed (0.7-1.2)"),
+		noSpeakerBoost:  fs.Bool("no-speaker-boost", false, "Disable speaker boost"),
 	}
+}
 
-	jsonBody, err := json.Marshal(reqBody)
-	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
+// settings returns the voice settings, with any out of range clamped into
+// it and a warning, rather than rejected by the API.
+func (f *settingsFlags) settings() elevenlabs.VoiceSettings {
+	return elevenlabs.VoiceSettings{
+		Stability:       clampSetting("stability", *f.stability, 0, 1),
+		SimilarityBoost: clampSetting("similarity-boost", *f.similarityBoost, 0, 1),
+		Style:           clampSetting("style", *f.style, 0, 1),
+		Speed:           clampSetting("speed", *f.speed, 0.7, 1.2),
+		UseSpeakerBoost: !*f.noSpeakerBoost,
 	}
+}
 
-	url := fmt.Sprintf("%s/text-to-speech/%s?output_format=%s", apiBaseURL, voiceID, apiFormat)
-	req, err := http.NewRequest("POST", url, bytes.NewReader(jsonBody))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+func clampSetting(name string, v, lo, hi float64) float64 {
+	c := min(max(v, lo), hi)
+	if c != v {
+		warn("setting_clamped", map[string]any{"setting": name, "value": v, "clamped": c},
+			"--%s %g is out of range (%g-%g), using %g", name, v, lo, hi, c)
 	}
+	return c
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("xi-api-key", apiKey)
-
-	otel.Info("tts_request", map[string]any{
-		"voice_id": voiceID,
-		"format":   format,
-		"text_len": len(text),
+// changed reports whether any setting was given on the command line.
+func (f *settingsFlags) changed() bool {
+	set := false
+	f.fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "stability", "similarity-boost", "style", "speed", "no-speaker-boost":
+			set = true
+		}
 	})
+	return set
+}
 
-	client := &http.Client{Timeout: 120 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
-	}
-
-	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
-	}
-
-	outFile, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
+func envInt(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
 	}
-	defer outFile.Close()
-
-	_, err = io.Copy(outFile, resp.Body)
+	n, err := strconv.Atoi(v)
 	if err != nil {
-		return fmt.Errorf("failed to write output: %w", err)
+		fmt.Fprintf(os.Stderr, "ERROR: invalid %s: %s\n", name, v)
+		os.Exit(1)
 	}
-
-	otel.Info("tts_complete", map[string]any{"output": outputPath})
-	return nil
+	return n
 }
 
-func voiceChange(inputPath, outputPath, voiceID, format string) error {
-	apiKey := getAPIKey()
-
-	apiFormat, ok := outputFormats[format]
-	if !ok {
-		return fmt.Errorf("unsupported format: %s", format)
+func envDuration(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
 	}
-
-	inputFile, err := os.Open(inputPath)
+	d, err := time.ParseDuration(v)
 	if err != nil {
-		return fmt.Errorf("failed to open input file: %w", err)
-	}
-	defer inputFile.Close()
-
-	var body bytes.Buffer
-	writer := multipart.NewWriter(&body)
-
-	part, err := writer.CreateFormFile("audio", filepath.Base(inputPath))
-	if err != nil {
-		return fmt.Errorf("failed to create form file: %w", err)
+		fmt.Fprintf(os.Stderr, "ERROR: invalid %s: %s\n", name, v)
+		os.Exit(1)
 	}
+	return d
+}
 
-	_, err = io.Copy(part, inputFile)
-	if err != nil {
-		return fmt.Errorf("failed to copy audio data: %w", err)
+func checkHealth() bool {
+	key, bearer := lookupAuth()
+	if key == "" {
+		return false
 	}
 
-	writer.WriteField("model_id", defaultVoiceModel)
-	writer.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-	url := fmt.Sprintf("%s/speech-to-speech/%s?output_format=%s", apiBaseURL, voiceID, apiFormat)
-	req, err := http.NewRequest("POST", url, &body)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	var baseURLs listFlag
+	baseURLs.Set(os.Getenv("ELEVENLABS_BASE_URL"))
+	opts := []elevenlabs.Option{elevenlabs.WithBaseURLs(baseURLs...)}
+	if bearer {
+		opts = append(opts, elevenlabs.WithBearerAuth())
 	}
+	_, err := elevenlabs.NewClient(key, opts...).User(ctx)
+	return err == nil
+}
 
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-	req.Header.Set("xi-api-key", apiKey)
-
-	otel.Info("voice_change_request", map[string]any{
-		"voice_id": voiceID,
-		"format":   format,
-		"input":    inputPath,
-	})
+// apiFormat returns the API format string for a short name or a full API
+// format, which must be one the API accepts.
+func apiFormat(format string) (string, error) {
+	return resolveFormat(format, 0, 0)
+}
 
-	client := &http.Client{Timeout: 120 * time.Second}
-	resp, err := client.Do(req)
+// resolveFormat applies a sample rate and bitrate, when non-zero, to format
+// and validates the result.
+func resolveFormat(format string, sampleRate, bitrate int) (string, error) {
+	f := format
+	if def, ok := outputFormats[format]; ok {
+		f = def
+	}
+	af, err := audio.ParseFormat(f)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return "", fmt.Errorf("unsupported format: %s", format)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error %d: %s", resp.StatusCode, string(respBody))
+	if sampleRate > 0 {
+		af.SampleRate = sampleRate
 	}
-
-	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
-	}
-
-	outFile, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
+	if bitrate > 0 {
+		if af.Bitrate == 0 {
+			return "", fmt.Errorf("%s has no bitrate setting", af.Codec)
+		}
+		af.Bitrate = bitrate
 	}
-	defer outFile.Close()
-
-	_, err = io.Copy(outFile, resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to write output: %w", err)
+	f = af.String()
+	if !elevenlabs.IsOutputFormat(f) {
+		allowed := elevenlabs.OutputFormats(string(af.Codec))
+		if len(allowed) == 0 {
+			return "", fmt.Errorf("unsupported format: %s", format)
+		}
+		return "", fmt.Errorf("unsupported format: %s (%s allows %s)", f, af.Codec, strings.Join(allowed, ", "))
 	}
-
-	otel.Info("voice_change_complete", map[string]any{"output": outputPath})
-	return nil
+	return f, nil
 }
 
 func printUsage() {
@@ -284,25 +282,212 @@ func printUsage() {
 
 Usage:
   pink-elevenlabs tts "text" [options]     Text-to-speech synthesis
+  pink-elevenlabs tts -i file.txt [options] Text-to-speech from a file (any length)
+  pink-elevenlabs tts --from-url <url>     Read a web page's article aloud
+  pink-elevenlabs tts --clipboard          Speak the text on the clipboard
+  cmd | pink-elevenlabs tts --realtime     Speak text as it is piped in
+  pink-elevenlabs feed <url> [options]     Synthesize new entries of an RSS/Atom feed
   pink-elevenlabs voice <input> [options]  Voice transformation
+  pink-elevenlabs voice --monitor          Convert the microphone live and play it
+  pink-elevenlabs dub create <input> -t es Start dubbing a file or URL
+  pink-elevenlabs dub --dir <dir> -t es    Dub every media file in a directory
+  pink-elevenlabs dub status <id>          Show dubbing status
+  pink-elevenlabs dub download <id>        Download dubbed media
+  pink-elevenlabs dub subtitles <id>       Download a language's subtitles (SRT/VTT)
+  pink-elevenlabs silence -t 2s [options]  Generate encoded silence
+  pink-elevenlabs voices list              List available voices
+  pink-elevenlabs voices add <samples...>  Clone a voice, prints its ID
+  pink-elevenlabs voices edit <id> [samples...]
+  pink-elevenlabs voices delete <id>
+  pink-elevenlabs stats <file>             Estimate characters, chunks, credits, duration
+  pink-elevenlabs usage [--json]           Show plan, character quota and reset date
+  pink-elevenlabs report voices            Characters and generations per voice and model
+  pink-elevenlabs devices [--json]         List playback (--device) and capture (--mic) devices
+  pink-elevenlabs clean [--older-than 7d]  Remove old templated outputs from the output directory
+  pink-elevenlabs transcribe <file>        Speech to text, after a cost preflight
+  pink-elevenlabs dict apply <csv> --name <name>
+                                           Create or update a pronunciation dictionary
+  pink-elevenlabs dict pls <csv>           Convert a CSV of terms to a PLS lexicon
+  pink-elevenlabs dict list                List pronunciation dictionaries
+  pink-elevenlabs conversations list       List past agent conversations
+  pink-elevenlabs conversations export [ids...]
+                                           Export conversation transcripts and audio
+  pink-elevenlabs agents apply <agent.yaml> Create or update an agent from files
+  pink-elevenlabs agents signed-url <id>   Print a signed URL for a browser session
+  pink-elevenlabs agents serve --agent <id> Serve signed URLs over HTTP
   pink-elevenlabs --health                 Check API key validity
+  pink-elevenlabs capabilities [--json]    Commands, features, formats and models of this build
   pink-elevenlabs --version                Show version
 
+Formats: mp3 22050/32, 24000/48, 44100/32-192 kbps; opus 48000/32-192 kbps;
+pcm 8000-48000 Hz; ulaw_8000 and alaw_8000 for telephony.
+
+Output files default to a unique name in ELEVENLABS_OUTPUT_DIR (or %s).
+Generated names in the temp dir are removed after ELEVENLABS_OUTPUT_TTL (default 7d);
+set it to clean the output dir (ELEVENLABS_OUTPUT_DIR or -d) too.
+-o and ELEVENLABS_OUTPUT_TEMPLATE accept {prefix} {voice} {input} {format}
+{ext} {date} {time} {hash}, e.g. -o "out/{input}-{voice}{ext}".
+
+Every command accepts --profile <name> to take defaults from a profile in
+the config file (ELEVENLABS_CONFIG, default %s),
+and --otel-attr k=v to add otel resource attributes (repeatable).
+tts and voice also take --job <file.json> (or PINK_ELEVENLABS_JOB) holding
+flag values by long name, plus "text" (tts) or "input" (voice).
+Flags override the job, the job the profile, the profile the environment.
+
 TTS options:
-  -o, --output <path>         Output file (default: %s)
+  -o, --output <path>         Output file, - for stdout (default: speech-<time>-<hash>.<ext>)
+  -d, --output-dir <dir>      Directory for default output names
+  -i, --input <file>          Read text from file, - for stdin
+  --segments <file>           Read JSON segments with pauses, - for stdin
+  --from-url <url>            Read the main article text of a web page
+  --clipboard                 Read the clipboard text; plays it unless -o or -d is given
   -v, --voice <id>            Voice ID (default: ELEVENLABS_TTS_VOICE_ID env)
-  -f, --format <fmt>          Output format: opus, mp3, pcm (default: opus)
+  -m, --model <id>            Model ID (default: eleven_v3; realtime: eleven_flash_v2_5)
+  -f, --format <fmt>          opus, mp3, pcm, ulaw, alaw or e.g. mp3_22050_32 (default: opus)
+  --sample-rate <hz>          Sample rate within the format (e.g. 16000 for pcm)
+  --bitrate <kbps>            Bitrate for mp3 and opus (e.g. 64)
+  --strict                    Fail if the plan doesn't allow the format instead of falling back
   --stability <0.0-1.0>       Voice stability (default: %.1f)
   --similarity-boost <0.0-1.0> Similarity boost (default: %.2f)
   --style <0.0-1.0>           Style exaggeration (default: %.1f)
   --speed <0.7-1.2>           Speech speed (default: %.1f)
   --no-speaker-boost          Disable speaker boost
+  --chunk-size <n>            Max characters per request (default: model limit)
+  --continuity                Send neighbouring chunk text for smoother prosody
+  --realtime                  Stream stdin (or --input) over a websocket as it arrives
+  --reuse-history             Download identical generations from history instead of synthesizing
+  --session <name>            Continue the prosody of earlier runs with the same session name
+  --progress <text|jsonl>     Report each finished chunk (i/N, characters, elapsed, ETA) on stderr
+  --stems                     Also write one file per voice, aligned with the mix
+  --keep-partial              On cancel or failure keep finished chunks and a resume manifest
+  --resume <dir>              Resume a run kept by --keep-partial (<output>.partial)
+  --play                      Play the audio after saving it
+  --stream                    Use the streaming endpoint; with --play, play while saving
+  --device <name|index>       Playback device for --play (default: ELEVENLABS_AUDIO_DEVICE)
+  --show-usage                Print billed characters to stderr
+  --json                      Print the output path, format and warnings as JSON
+  --qc                        Fail (exit 8) on clipping, long silence or loudness out of range
+  --qc-min-lufs, --qc-max-lufs <lufs>
+                              Loudness range for --qc (default: %.0f to %.0f)
+  --qc-max-silence <dur>      Longest silence for --qc (default: %s)
+  --tag key=value             Metadata (title, artist, album, ...), repeatable; opus and mp3
+  --retries <n>               Retries on 429/5xx (default: %d, ELEVENLABS_RETRIES env)
+  --retry-max-wait <dur>      Longest wait between retries (default: %s, ELEVENLABS_RETRY_MAX_WAIT env)
+  --base-url <url>            API base URL; repeat for failover, in order (ELEVENLABS_BASE_URL env)
+
+Feed options (plus the TTS voice, model, settings and output options):
+  --watch <dur>               Poll at this interval instead of once
+  --limit <n>                 At most the newest n new entries per poll (default: all)
+  --mark-read                 Record the current entries as done without synthesizing
+  --full-article              Read each entry's linked page instead of the feed text
+  --state <file>              State file (default: feed-<hash>.json in the output directory)
+  --podcast <file>            Also write a podcast RSS feed of the audio
+  --podcast-url <url>         URL the audio files are served under (for --podcast)
 
 Voice options:
-  -o, --output <path>         Output file (default: %s)
+  -o, --output <path>         Output file (default: voice-<time>-<hash>.<ext>)
+  -d, --output-dir <dir>      Directory for default output names
   -v, --voice <id>            Target voice ID (default: ELEVENLABS_VOICE_CHANGE_ID env)
-  -f, --format <fmt>          Output format: opus, mp3, pcm (default: opus)
-`, version, getDefaultTTSOutput(), defaultStability, defaultSimilarityBoost, defaultStyle, defaultSpeed, getDefaultVoiceOutput())
+  -m, --model <id>            Model ID (default: eleven_multilingual_sts_v2)
+  -f, --format <fmt>          opus, mp3, pcm, ulaw, alaw or e.g. mp3_22050_32 (default: opus)
+  --sample-rate <hz>          Sample rate within the format (e.g. 16000 for pcm)
+  --bitrate <kbps>            Bitrate for mp3 and opus (e.g. 64)
+  --strict                    Fail if the plan doesn't allow the format instead of falling back
+  --stability, --similarity-boost, --style, --speed, --no-speaker-boost
+                              Override the voice's stored settings
+  --remove-background-noise   Clean up the input before conversion
+  --range <start-end>         Convert only this span of a WAV input and splice it
+                              back, e.g. 00:01:10-00:02:30; repeatable
+  --monitor                   Capture the microphone, convert and play it until Ctrl-C
+  --chunk <dur>               Microphone chunk length for --monitor (default: %s)
+  --mic <device>              Capture device for --monitor, by index or name (default: ELEVENLABS_MIC env)
+  --gate <dbfs>               With --monitor, skip chunks quieter than this (default: %.0f)
+  --play, --stream, --show-usage, --json, --qc, --tag, --retries,
+  --retry-max-wait            As for TTS
+
+Dub options:
+  -t, --target-lang <code>    Target language (create, required)
+  --source-lang <code>        Source language (create, default: auto)
+  --name <name>               Project name (create)
+  --num-speakers <n>          Speakers to detect (create, default: auto)
+  --watermark                 Watermark video output (create)
+  --wait                      Block until finished (create, status)
+  --poll-interval <dur>       Poll interval for --wait (default: 10s)
+  --json                      Print full status (status) or the --dir report as JSON
+  -l, --lang <code>           Language to download (download, subtitles)
+  -f, --format <srt|vtt>      Subtitle format (subtitles, default: srt)
+  --subtitles <srt|vtt>       Also save subtitles next to the media (create, download)
+  -o, --output <path>         Output file (create: implies --wait, download, subtitles)
+  -d, --output-dir <dir>      Directory for default output names (--dir: the directory itself)
+  --dir <dir>                 Dub every media file in dir, writing <name>.<lang>.<ext> (create)
+  --concurrency <n>           Files dubbed at once with --dir (default: %d)
+
+Silence options:
+  -t, --duration <dur>        Length, e.g. 500ms, 2s (default: 1s)
+  -o, --output <path>         Output file (default: silence-<time>-<hash>.<ext>)
+  -d, --output-dir <dir>      Directory for default output names
+  -f, --format <fmt>          opus, mp3, pcm, ulaw, alaw or e.g. mp3_22050_32 (default: opus)
+  --sample-rate <hz>          Sample rate within the format (e.g. 16000 for pcm)
+  --bitrate <kbps>            Bitrate for mp3 and opus (e.g. 64)
+
+Voices options:
+  --name <name>               Voice name (add: required)
+  --description <text>        Voice description
+  --labels k=v[,k=v]          Labels, repeatable
+  --remove-background-noise   Clean up samples before cloning
+  --json                      Print JSON (list)
+
+Stats options:
+  -m, --model <id>            Only report this model (default: all known)
+  --speed <0.7-1.2>           Speed for the duration estimate (default: %.1f)
+  --json                      Print JSON
+
+Transcribe options:
+  -o, --output <path>         Write the transcript to a file (default: stdout)
+  -d, --output-dir <dir>      Directory for default output names
+  -m, --model <id>            Model ID (default: scribe_v1)
+  -l, --language <code>       Language code (default: detect)
+  --diarize                   Label words with their speaker
+  --tag-audio-events          Mark sounds such as (laughter) in the text
+  --dry-run                   Only report duration, credits and processing time
+  --max-duration <dur>        Refuse longer inputs before uploading them
+  --json                      Print the full transcript (or dry-run report) as JSON
+
+Dict options:
+  --name <name>               Dictionary to create or update (apply, required)
+  --description <text>        Description of a new dictionary (apply)
+  --lang <code>               Lexicon language (default: en-US)
+  -o, --output <path>         PLS file (pls, default: stdout)
+  --json                      Print JSON (list)
+
+Report options:
+  --since, --until <time>     Time bounds: 2006-01-02, RFC 3339 or a duration ago (default since: 90d)
+  --by <period>               Group by day, week, month or all (default: month)
+  -v, --voice <id>            Only this voice
+  --json                      Print JSON
+
+Conversations options:
+  -a, --agent <id>            Only this agent's conversations
+  --since, --until <time>     Start time bounds: 2006-01-02, RFC 3339 or a duration ago
+  --status <result>           Only success, failure or unknown evaluations
+  --limit <n>                 At most n conversations, newest first
+  -d, --output-dir <dir>      Export directory (export)
+  --audio                     Also download recordings (export)
+  --json                      Print JSON (list)
+
+Agents options:
+  --dry-run                   Print the agent configuration without uploading (apply)
+  --listen <addr>             Address to serve on (serve, default: %s)
+  --agent <id>                Agent to issue URLs for, repeatable (serve, required)
+  --allow-origin <origin>     Origin allowed by CORS, repeatable (serve)
+  --json                      Print JSON (signed-url)
+
+Exit codes:
+  1 general error, 3 authentication failed, 4 quota exceeded,
+  5 rate limited (retryable), 6 invalid voice, 7 server error (retryable),
+  8 quality check failed
+`, version, os.TempDir(), configPath(), defaultStability, defaultSimilarityBoost, defaultStyle, defaultSpeed, defaultQCMinLoudness, defaultQCMaxLoudness, defaultQCMaxSilence, defaultRetries, defaultRetryMaxWait, defaultMonitorChunk, defaultMonitorGate, defaultDubConcurrency, defaultSpeed, defaultSignedURLListen)
 }
 
 func main() {
@@ -317,6 +502,7 @@ func main() {
 	}
 
 	if os.Args[1] == "--health" {
+		initTelemetry(nil)
 		if checkHealth() {
 			fmt.Println("OK")
 			os.Exit(0)
@@ -336,90 +522,37 @@ func main() {
 		cmdTTS(os.Args[2:])
 	case "voice":
 		cmdVoice(os.Args[2:])
+	case "dub":
+		cmdDub(os.Args[2:])
+	case "silence":
+		cmdSilence(os.Args[2:])
+	case "voices":
+		cmdVoices(os.Args[2:])
+	case "stats":
+		cmdStats(os.Args[2:])
+	case "usage":
+		cmdUsage(os.Args[2:])
+	case "report":
+		cmdReport(os.Args[2:])
+	case "clean":
+		cmdClean(os.Args[2:])
+	case "capabilities":
+		cmdCapabilities(os.Args[2:])
+	case "transcribe":
+		cmdTranscribe(os.Args[2:])
+	case "devices":
+		cmdDevices(os.Args[2:])
+	case "feed":
+		cmdFeed(os.Args[2:])
+	case "dict":
+		cmdDict(os.Args[2:])
+	case "conversations":
+		cmdConversations(os.Args[2:])
+	case "agents":
+		cmdAgents(os.Args[2:])
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", os.Args[1])
 		printUsage()
 		os.Exit(1)
 	}
 }
-
-func cmdTTS(args []string) {
-	fs := flag.NewFlagSet("tts", flag.ExitOnError)
-
-	output := fs.String("output", getDefaultTTSOutput(), "Output file path")
-	fs.StringVar(output, "o", getDefaultTTSOutput(), "Output file path")
-
-	voice := fs.String("voice", "", "Voice ID")
-	fs.StringVar(voice, "v", "", "Voice ID")
-
-	format := fs.String("format", "opus", "Output format (opus, mp3, pcm)")
-	fs.StringVar(format, "f", "opus", "Output format")
-
-	stability := fs.Float64("stability", defaultStability, "Voice stability (0.0-1.0)")
-	similarityBoost := fs.Float64("similarity-boost", defaultSimilarityBoost, "Similarity boost (0.0-1.0)")
-	style := fs.Float64("style", defaultStyle, "Style exaggeration (0.0-1.0)")
-	speed := fs.Float64("speed", defaultSpeed, "Speech speed (0.7-1.2)")
-	noSpeakerBoost := fs.Bool("no-speaker-boost", false, "Disable speaker boost")
-
-	fs.Parse(args)
-
-	if fs.NArg() < 1 {
-		fmt.Fprintln(os.Stderr, "ERROR: Text argument required")
-		os.Exit(1)
-	}
-
-	text := fs.Arg(0)
-	voiceID := *voice
-	if voiceID == "" {
-		voiceID = getTTSVoiceID()
-	}
-
-	err := textToSpeech(text, *output, voiceID, *format, *stability, *similarityBoost, *style, *speed, !*noSpeakerBoost)
-	if err != nil {
-		otel.Error("tts_failed", map[string]any{"error": err.Error()})
-		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
-		os.Exit(1)
-	}
-
-	fmt.Println(*output)
-}
-
-func cmdVoice(args []string) {
-	fs := flag.NewFlagSet("voice", flag.ExitOnError)
-
-	output := fs.String("output", getDefaultVoiceOutput(), "Output file path")
-	fs.StringVar(output, "o", getDefaultVoiceOutput(), "Output file path")
-
-	voice := fs.String("voice", "", "Target voice ID")
-	fs.StringVar(voice, "v", "", "Target voice ID")
-
-	format := fs.String("format", "opus", "Output format (opus, mp3, pcm)")
-	fs.StringVar(format, "f", "opus", "Output format")
-
-	fs.Parse(args)
-
-	if fs.NArg() < 1 {
-		fmt.Fprintln(os.Stderr, "ERROR: Input file argument required")
-		os.Exit(1)
-	}
-
-	inputPath := fs.Arg(0)
-	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
-		fmt.Fprintf(os.Stderr, "ERROR: Input file not found: %s\n", inputPath)
-		os.Exit(1)
-	}
-
-	voiceID := *voice
-	if voiceID == "" {
-		voiceID = getVoiceChangeID()
-	}
-
-	err := voiceChange(inputPath, *output, voiceID, *format)
-	if err != nil {
-		otel.Error("voice_change_failed", map[string]any{"error": err.Error()})
-		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
-		os.Exit(1)
-	}
-
-	fmt.Println(*output)
-}
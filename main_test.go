@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// TestMain runs main itself when a test re-executes the test binary through
+// runMain, since commands exit the process.
+func TestMain(m *testing.M) {
+	if args := os.Getenv("PINK_ELEVENLABS_TEST_ARGS"); args != "" {
+		os.Args = []string{"pink-elevenlabs"}
+		if err := json.Unmarshal([]byte(args), &os.Args); err != nil {
+			panic(err)
+		}
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runMain runs the command line args in a child process with env added to a
+// clean environment, and returns what it wrote to stdout and stderr.
+func runMain(t *testing.T, env []string, args ...string) (stdout, stderr []byte, code int) {
+	t.Helper()
+	dir := t.TempDir()
+	argv, _ := json.Marshal(append([]string{"pink-elevenlabs"}, args...))
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	cmd.Dir = dir
+	cmd.Env = append([]string{
+		"PINK_ELEVENLABS_TEST_ARGS=" + string(argv),
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + dir,
+		"TMPDIR=" + dir,
+		"ELEVENLABS_CONFIG=" + dir + "/config.yaml",
+	}, env...)
+	var out, errOut bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &errOut
+	err := cmd.Run()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		code = exit.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes(), errOut.Bytes(), code
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pink-tools/pink-elevenlabs/audio"
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+	"github.com/pink-tools/pink-otel"
+)
+
+const (
+	defaultMonitorChunk = 1500 * time.Millisecond
+	defaultMonitorGate  = -50.0
+
+	// The microphone is captured as 16-bit mono PCM at monitorRate, and the
+	// converted audio comes back as monitorFormat, which every plan allows.
+	monitorRate   = 16000
+	monitorFormat = "pcm_22050"
+	// monitorAhead is how many chunks may be converting while an earlier
+	// one is still playing.
+	monitorAhead = 3
+	// monitorMaxFailures consecutive failed chunks end the monitor.
+	monitorMaxFailures = 3
+)
+
+// recorderCommand builds the command that captures mic as raw 16-bit mono
+// PCM at monitorRate on stdout.
+func recorderCommand(ctx context.Context, mic string) (*exec.Cmd, error) {
+	loadEnv()
+	if custom := splitCommand(os.Getenv("ELEVENLABS_RECORDER")); len(custom) > 0 {
+		for i, a := range custom {
+			if a == "{device}" {
+				custom[i] = mic
+			}
+		}
+		return exec.CommandContext(ctx, custom[0], custom[1:]...), nil
+	}
+
+	rate := fmt.Sprint(monitorRate)
+	if ffmpeg, err := exec.LookPath("ffmpeg"); err == nil {
+		var input []string
+		switch runtime.GOOS {
+		case "darwin":
+			input = []string{"-f", "avfoundation", "-i", cmp.Or(mic, ":default")}
+		case "windows":
+			if mic == "" {
+				return nil, errors.New(`--mic is required on Windows, e.g. --mic "audio=Microphone" (see devices)`)
+			}
+			input = []string{"-f", "dshow", "-i", mic}
+		default:
+			driver, name, ok := strings.Cut(mic, "/")
+			if !ok || (driver != "alsa" && driver != "pulse") {
+				driver, name = "pulse", mic
+			}
+			input = []string{"-f", driver, "-i", cmp.Or(name, "default")}
+		}
+		args := append([]string{"-hide_banner", "-loglevel", "error", "-fflags", "nobuffer"}, input...)
+		args = append(args, "-ac", "1", "-ar", rate, "-f", "s16le", "-")
+		return exec.CommandContext(ctx, ffmpeg, args...), nil
+	}
+	if arecord, err := exec.LookPath("arecord"); err == nil {
+		args := []string{"-q", "-t", "raw", "-f", "S16_LE", "-c", "1", "-r", rate}
+		if mic != "" {
+			args = append(args, "-D", strings.TrimPrefix(mic, "alsa/"))
+		}
+		return exec.CommandContext(ctx, arecord, args...), nil
+	}
+	return nil, errors.New("no audio recorder found (install ffmpeg, or set ELEVENLABS_RECORDER)")
+}
+
+// pcmLevel returns the RMS level of 16-bit PCM in dBFS.
+func pcmLevel(pcm []byte) float64 {
+	n := len(pcm) / 2
+	if n == 0 {
+		return math.Inf(-1)
+	}
+	var sum float64
+	for i := 0; i < n; i++ {
+		s := float64(int16(binary.LittleEndian.Uint16(pcm[2*i:]))) / 32768
+		sum += s * s
+	}
+	return 10 * math.Log10(sum/float64(n))
+}
+
+// monitorJob is a voice --monitor session.
+type monitorJob struct {
+	VoiceID               string
+	Model                 string
+	Settings              *elevenlabs.VoiceSettings
+	RemoveBackgroundNoise bool
+	// Chunk is the length of each captured piece of audio.
+	Chunk time.Duration
+	// Gate is the level in dBFS below which a chunk is treated as silence
+	// and not converted.
+	Gate float64
+}
+
+// monitorStats counts a session's chunks and the latency of each converted
+// one: the time from the end of its capture to the first converted audio.
+type monitorStats struct {
+	Converted int
+	Silent    int
+	Failed    int
+	Latencies []time.Duration
+}
+
+func (s *monitorStats) percentile(p float64) time.Duration {
+	sorted := append([]time.Duration(nil), s.Latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[int(p*float64(len(sorted)-1))]
+}
+
+// print writes the session summary to stderr.
+func (s *monitorStats) print(chunk time.Duration) {
+	fmt.Fprintf(os.Stderr, "Converted %d chunks, skipped %d silent, failed %d\n", s.Converted, s.Silent, s.Failed)
+	fields := map[string]any{"converted": s.Converted, "silent": s.Silent, "failed": s.Failed}
+	if len(s.Latencies) > 0 {
+		var total time.Duration
+		for _, l := range s.Latencies {
+			total += l
+		}
+		avg := total / time.Duration(len(s.Latencies))
+		p50, p95, worst := s.percentile(0.5), s.percentile(0.95), s.percentile(1)
+		fmt.Fprintf(os.Stderr, "Latency: avg %s, p50 %s, p95 %s, max %s (plus %s chunk length)\n",
+			ms(avg), ms(p50), ms(p95), ms(worst), ms(chunk))
+		fields["latency_avg_ms"] = avg.Milliseconds()
+		fields["latency_p50_ms"] = p50.Milliseconds()
+		fields["latency_p95_ms"] = p95.Milliseconds()
+		fields["latency_max_ms"] = worst.Milliseconds()
+	}
+	otel.Info("voice_monitor_complete", fields)
+}
+
+func ms(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
+
+// monitorChunk is a captured chunk on its way to the player.
+type monitorChunk struct {
+	n        int
+	captured time.Time
+	result   chan monitorResult
+}
+
+type monitorResult struct {
+	audio *elevenlabs.Audio
+	err   error
+}
+
+// cmdVoiceMonitor runs voice --monitor until interrupted.
+func cmdVoiceMonitor(out *outputFlags, api *clientFlags, job monitorJob, mic string) {
+	*out.format = monitorFormat
+	*out.play = true
+	out.check()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	rec, err := recorderCommand(ctx, mic)
+	if err != nil {
+		fail("voice_monitor_failed", err)
+	}
+	capture, err := rec.StdoutPipe()
+	if err != nil {
+		fail("voice_monitor_failed", err)
+	}
+	rec.Stderr = os.Stderr
+	p, err := startPlayer(monitorFormat, *out.device)
+	if err != nil {
+		fail("voice_monitor_failed", err)
+	}
+	if err := rec.Start(); err != nil {
+		p.Close()
+		fail("voice_monitor_failed", fmt.Errorf("failed to start recorder: %w", err))
+	}
+
+	otel.Info("voice_monitor_start", map[string]any{"voice_id": job.VoiceID, "model": job.Model, "chunk_ms": job.Chunk.Milliseconds()})
+	fmt.Fprintf(os.Stderr, "Monitoring in %s chunks, press Ctrl-C to stop\n", ms(job.Chunk))
+
+	stats, err := voiceMonitor(ctx, api.newClient(), job, capture, p.stdin)
+	interrupted := ctx.Err() != nil
+	stop()
+	if werr := rec.Wait(); werr != nil && err == nil && !interrupted {
+		err = fmt.Errorf("recorder stopped: %w", werr)
+	}
+	p.Close()
+	stats.print(job.Chunk)
+	if err != nil {
+		fail("voice_monitor_failed", err)
+	}
+}
+
+// voiceMonitor converts the PCM read from capture chunk by chunk, up to
+// monitorAhead at a time, and writes the results to w in order.
+func voiceMonitor(ctx context.Context, client *elevenlabs.Client, job monitorJob, capture io.Reader, w io.Writer) (*monitorStats, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stats := &monitorStats{}
+	queue := make(chan *monitorChunk, monitorAhead)
+	played := make(chan error, 1)
+	go func() {
+		played <- playMonitorChunks(ctx, queue, w, stats)
+		cancel()
+	}()
+
+	size := int(job.Chunk.Seconds()*monitorRate) * 2
+	for n := 1; ; n++ {
+		pcm := make([]byte, size)
+		if _, err := io.ReadFull(capture, pcm); err != nil {
+			break
+		}
+		if pcmLevel(pcm) < job.Gate {
+			stats.Silent++
+			continue
+		}
+
+		c := &monitorChunk{n: n, captured: time.Now(), result: make(chan monitorResult, 1)}
+		go func() {
+			var clip bytes.Buffer
+			audio.WriteWAVHeader(&clip, 1, monitorRate, int64(len(pcm)))
+			clip.Write(pcm)
+			a, err := client.SpeechToSpeech(ctx, job.VoiceID, elevenlabs.STSRequest{
+				Audio:                 &clip,
+				Filename:              "mic.wav",
+				ModelID:               job.Model,
+				VoiceSettings:         job.Settings,
+				RemoveBackgroundNoise: job.RemoveBackgroundNoise,
+				OutputFormat:          monitorFormat,
+				Stream:                true,
+			})
+			c.result <- monitorResult{a, err}
+		}()
+		select {
+		case queue <- c:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(queue)
+	err := <-played
+	return stats, err
+}
+
+// playMonitorChunks writes the converted chunks to w in capture order.
+func playMonitorChunks(ctx context.Context, queue <-chan *monitorChunk, w io.Writer, stats *monitorStats) error {
+	failures := 0
+	buf := make([]byte, 32*1024)
+	for c := range queue {
+		r := <-c.result
+		if r.err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			stats.Failed++
+			failures++
+			warn("voice_monitor_chunk_failed", map[string]any{"chunk": c.n, "error": r.err.Error()},
+				"chunk %d failed: %v", c.n, r.err)
+			if failures >= monitorMaxFailures {
+				return r.err
+			}
+			continue
+		}
+		failures = 0
+
+		first := true
+		for {
+			n, err := r.audio.Read(buf)
+			if n > 0 {
+				if first {
+					latency := time.Since(c.captured)
+					stats.Converted++
+					stats.Latencies = append(stats.Latencies, latency)
+					fmt.Fprintf(os.Stderr, "Chunk %d: %s\n", c.n, ms(latency))
+					first = false
+				}
+				if _, werr := w.Write(buf[:n]); werr != nil {
+					r.audio.Close()
+					return fmt.Errorf("player stopped: %w", werr)
+				}
+			}
+			if err != nil {
+				if !errors.Is(err, io.EOF) && ctx.Err() == nil {
+					warn("voice_monitor_chunk_cut", map[string]any{"chunk": c.n, "error": err.Error()},
+						"chunk %d cut short: %v", c.n, err)
+				}
+				break
+			}
+		}
+		r.audio.Close()
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+)
+
+// pcmChunk returns 100ms of 16-bit PCM at monitorRate holding sample v.
+func pcmChunk(v int16) []byte {
+	b := make([]byte, monitorRate/10*2)
+	for i := 0; i < len(b); i += 2 {
+		binary.LittleEndian.PutUint16(b[i:], uint16(v))
+	}
+	return b
+}
+
+func TestPCMLevel(t *testing.T) {
+	tests := []struct {
+		name string
+		pcm  []byte
+		want float64
+	}{
+		{"empty", nil, math.Inf(-1)},
+		{"digital silence", pcmChunk(0), math.Inf(-1)},
+		{"full scale", pcmChunk(-32768), 0},
+		{"half scale", pcmChunk(16384), -6.02},
+		{"quiet", pcmChunk(33), -59.94},
+	}
+	for _, tt := range tests {
+		got := pcmLevel(tt.pcm)
+		if math.IsInf(tt.want, -1) && !math.IsInf(got, -1) || !math.IsInf(tt.want, -1) && math.Abs(got-tt.want) > 0.05 {
+			t.Errorf("%s: pcmLevel = %.2f, want %.2f", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMonitorPercentile(t *testing.T) {
+	s := &monitorStats{Latencies: []time.Duration{50, 10, 40, 20, 30}}
+	for p, want := range map[float64]time.Duration{0: 10, 0.5: 30, 0.95: 40, 1: 50} {
+		if got := s.percentile(p); got != want {
+			t.Errorf("percentile(%v) = %v, want %v", p, got, want)
+		}
+	}
+	one := &monitorStats{Latencies: []time.Duration{7}}
+	if got := one.percentile(0.95); got != 7 {
+		t.Errorf("percentile of one = %v, want 7", got)
+	}
+}
+
+// fakeSTS converts a chunk to "<sample>;", from the first sample of the WAV
+// it was sent. delay and fail pick the slow and failing chunks by sample.
+func fakeSTS(t *testing.T, delay map[int16]time.Duration, fail map[int16]bool) *elevenlabs.Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		i := bytes.Index(body, []byte("WAVEfmt "))
+		if i >= 0 {
+			i += bytes.Index(body[i:], []byte("data"))
+		}
+		if i < 0 || len(body) < i+10 {
+			http.Error(w, "no audio", http.StatusBadRequest)
+			return
+		}
+		v := int16(binary.LittleEndian.Uint16(body[i+8:]))
+		time.Sleep(delay[v])
+		if fail[v] {
+			http.Error(w, `{"detail":{"message":"bad chunk"}}`, http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, "%d;", v)
+	}))
+	t.Cleanup(srv.Close)
+	return elevenlabs.NewClient("key", elevenlabs.WithBaseURL(srv.URL))
+}
+
+func monitorCapture(samples ...int16) io.Reader {
+	var b bytes.Buffer
+	for _, v := range samples {
+		b.Write(pcmChunk(v))
+	}
+	return &b
+}
+
+var testMonitorJob = monitorJob{VoiceID: "voice", Chunk: 100 * time.Millisecond, Gate: defaultMonitorGate}
+
+func TestVoiceMonitorOrderAndGate(t *testing.T) {
+	client := fakeSTS(t, map[int16]time.Duration{1000: 100 * time.Millisecond}, nil)
+	var out bytes.Buffer
+	stats, err := voiceMonitor(context.Background(), client, testMonitorJob, monitorCapture(1000, 0, 2000, 3000), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "1000;2000;3000;" {
+		t.Errorf("played %q, want the chunks in capture order", out.String())
+	}
+	if stats.Converted != 3 || stats.Silent != 1 || stats.Failed != 0 || len(stats.Latencies) != 3 {
+		t.Errorf("stats = %+v, want 3 converted, 1 silent", stats)
+	}
+}
+
+func TestVoiceMonitorChunkFailure(t *testing.T) {
+	client := fakeSTS(t, nil, map[int16]bool{2000: true})
+	var out bytes.Buffer
+	stats, err := voiceMonitor(context.Background(), client, testMonitorJob, monitorCapture(1000, 2000, 3000), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "1000;3000;" {
+		t.Errorf("played %q, want the chunks around the failed one", out.String())
+	}
+	if stats.Converted != 2 || stats.Failed != 1 {
+		t.Errorf("stats = %+v, want 2 converted, 1 failed", stats)
+	}
+}
+
+func TestVoiceMonitorStopsAfterFailures(t *testing.T) {
+	fail := map[int16]bool{}
+	var samples []int16
+	for v := int16(1000); v < 11000; v += 1000 {
+		fail[v] = true
+		samples = append(samples, v)
+	}
+	client := fakeSTS(t, nil, fail)
+	stats, err := voiceMonitor(context.Background(), client, testMonitorJob, monitorCapture(samples...), io.Discard)
+	if err == nil {
+		t.Fatal("monitor kept going after every chunk failed")
+	}
+	if stats.Failed != monitorMaxFailures {
+		t.Errorf("failed = %d, want to stop after %d", stats.Failed, monitorMaxFailures)
+	}
+}
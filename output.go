@@ -0,0 +1,368 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pink-tools/pink-elevenlabs/audio"
+)
+
+var codecExtensions = map[audio.Codec]string{
+	audio.Opus: ".ogg",
+	audio.MP3:  ".mp3",
+	audio.PCM:  ".pcm",
+	audio.ULaw: ".ulaw",
+	audio.ALaw: ".alaw",
+}
+
+func formatCodec(format string) audio.Codec {
+	f, _ := audio.ParseFormat(format)
+	return f.Codec
+}
+
+// formatExtension returns the file extension for an API format string.
+func formatExtension(format string) string {
+	return codecExtensions[formatCodec(format)]
+}
+
+func getOutputDir(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	loadEnv()
+	if dir := os.Getenv("ELEVENLABS_OUTPUT_DIR"); dir != "" {
+		return dir
+	}
+	return os.TempDir()
+}
+
+// defaultTemplate is the name of generated output files when neither -o nor
+// ELEVENLABS_OUTPUT_TEMPLATE is set.
+const defaultTemplate = "{prefix}-{date}-{time}-{hash}{ext}"
+
+// outputName describes a command's output for naming it.
+type outputName struct {
+	Prefix string // "speech", "voice", ...
+	Voice  string
+	Input  string // input file, if any
+	Format string
+	Ext    string // with the leading dot
+	Seed   string // content identity, hashed into {hash}
+}
+
+// expand fills the placeholders of tmpl.
+func (n outputName) expand(tmpl string, now time.Time) string {
+	sum := sha256.Sum256([]byte(n.Seed))
+	input := filepath.Base(n.Input)
+	input = strings.TrimSuffix(input, filepath.Ext(input))
+	return strings.NewReplacer(
+		"{prefix}", n.Prefix,
+		"{voice}", safeName(n.Voice, maxNamePart),
+		"{input}", safeName(input, maxNamePart),
+		"{format}", n.Format,
+		"{ext}", n.Ext,
+		"{date}", now.Format("20060102"),
+		"{time}", now.Format("150405"),
+		"{hash}", hex.EncodeToString(sum[:])[:8],
+	).Replace(tmpl)
+}
+
+// templatedOutputPath expands tmpl and reserves the file, appending a
+// counter if the name is taken, and records it for clean. outputDir is the
+// output directory, which autoClean may clean.
+func templatedOutputPath(tmpl string, name outputName, outputDir string) (string, error) {
+	path := name.expand(tmpl, time.Now())
+	if filepath.Ext(path) == "" {
+		path += name.Ext
+	}
+	if base := filepath.Base(path); len(base) > maxName {
+		ext := filepath.Ext(base)
+		path = filepath.Join(filepath.Dir(path), truncateName(strings.TrimSuffix(base, ext), maxName-len(ext))+ext)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+	autoClean(filepath.Dir(path), outputDir)
+	path, err := reservePath(path)
+	if err == nil {
+		recordOutput(path)
+	}
+	return path, err
+}
+
+const (
+	// maxNamePart is the longest a {voice} or {input} value may be, and
+	// maxName the longest a generated file name, in bytes. File systems
+	// allow 255; the rest is room for a counter.
+	maxNamePart = 80
+	maxName     = 200
+)
+
+// windowsReserved are the device names Windows won't create a file under,
+// with or without an extension.
+var windowsReserved = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// safeName makes s usable in a file name on every platform, at most n bytes
+// long.
+func safeName(s string, n int) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(`<>:"'/\|?*`, r) {
+			return '-'
+		}
+		return r
+	}, s)
+	s = strings.Trim(s, ". ")
+	if stem, _, _ := strings.Cut(s, "."); windowsReserved[strings.ToUpper(stem)] {
+		s = stem + "_" + s[len(stem):]
+	}
+	return strings.TrimRight(truncateName(s, n), ". ")
+}
+
+// truncateName cuts s to at most n bytes without splitting a character.
+func truncateName(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func reservePath(path string) (string, error) {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	candidate := path
+	for i := 2; ; i++ {
+		f, err := os.OpenFile(candidate, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			f.Close()
+			return candidate, nil
+		}
+		if !os.IsExist(err) {
+			return "", fmt.Errorf("failed to create output file: %w", err)
+		}
+		candidate = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+}
+
+// resolveOutput returns the explicit output path, or a reserved path from the
+// template. generated reports the latter.
+func resolveOutput(output, outputDir string, name outputName) (path string, generated bool) {
+	tmpl := output
+	dir := getOutputDir(outputDir)
+	if tmpl == "" {
+		loadEnv()
+		tmpl = os.Getenv("ELEVENLABS_OUTPUT_TEMPLATE")
+		if tmpl == "" {
+			tmpl = defaultTemplate
+		}
+		if !filepath.IsAbs(tmpl) {
+			tmpl = filepath.Join(dir, tmpl)
+		}
+	} else if !strings.Contains(output, "{") {
+		return output, false
+	}
+
+	path, err := templatedOutputPath(tmpl, name, dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		exit(1)
+	}
+	return path, true
+}
+
+// outputFlags are the output options shared by the commands that produce
+// speech.
+type outputFlags struct {
+	output     *string
+	outputDir  *string
+	format     *string
+	sampleRate *int
+	bitrate    *int
+	strict     *bool
+	play       *bool
+	device     *string
+	stream     *bool
+	showUsage  *bool
+	json       *bool
+	tags       keyValueFlag
+	qc         *qcFlags
+}
+
+func addOutputFlags(fs *flag.FlagSet) *outputFlags {
+	f := &outputFlags{
+		output:     fs.String("output", "", "Output file path or name template"),
+		outputDir:  fs.String("output-dir", "", "Directory for default output names"),
+		format:     fs.String("format", "opus", "Output format: opus, mp3, pcm, ulaw, alaw or an API format such as mp3_22050_32"),
+		sampleRate: fs.Int("sample-rate", 0, "Sample rate in Hz (default: the format's)"),
+		bitrate:    fs.Int("bitrate", 0, "Bitrate in kbps for mp3 and opus (default: the format's)"),
+		strict:     fs.Bool("strict", false, "Fail instead of falling back to a lower format the plan allows"),
+		play:       fs.Bool("play", false, "Play the audio"),
+		device:     fs.String("device", "", "Playback device name or index, see devices (default: ELEVENLABS_AUDIO_DEVICE or the system's)"),
+		stream:     fs.Bool("stream", false, "Use the streaming endpoint; with --play, play while saving"),
+		showUsage:  fs.Bool("show-usage", false, "Print billed characters to stderr"),
+		json:       fs.Bool("json", false, "Print the result as JSON, with any warnings"),
+		tags:       keyValueFlag{},
+		qc:         addQCFlags(fs),
+	}
+	fs.StringVar(f.output, "o", "", "Output file path or name template")
+	fs.StringVar(f.outputDir, "d", "", "Directory for default output names")
+	fs.StringVar(f.format, "f", "opus", "Output format")
+	fs.Var(f.tags, "tag", "Metadata as key=value (title, artist, album, ...), repeatable")
+	return f
+}
+
+// check validates the output flags, exiting on error.
+func (f *outputFlags) check() {
+	resolved, err := resolveFormat(*f.format, *f.sampleRate, *f.bitrate)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		exit(1)
+	}
+	*f.format = resolved
+	if codec := formatCodec(resolved); len(f.tags) > 0 && codec != audio.MP3 && codec != audio.Opus {
+		fmt.Fprintf(os.Stderr, "ERROR: --tag is not supported for %s output\n", codec)
+		exit(1)
+	}
+	f.checkOutput()
+}
+
+// checkOutput validates the output and playback flags other than the
+// format, and resolves the playback device.
+func (f *outputFlags) checkOutput() {
+	if *f.output == "-" && *f.play && !*f.stream {
+		fmt.Fprintln(os.Stderr, "ERROR: --play with -o - needs --stream")
+		exit(1)
+	}
+	if *f.output == "-" && *f.json {
+		fmt.Fprintln(os.Stderr, "ERROR: --json can't be used with -o -")
+		exit(1)
+	}
+	if *f.output == "-" && *f.qc.enabled {
+		fmt.Fprintln(os.Stderr, "ERROR: --qc needs an output file, not -o -")
+		exit(1)
+	}
+	// A named pipe can only be read once, by whoever is on the other end.
+	if isNamedPipe(*f.output) && (*f.qc.enabled || (*f.play && !*f.stream)) {
+		fmt.Fprintln(os.Stderr, "ERROR: --qc and --play without --stream need an output file, not a named pipe")
+		exit(1)
+	}
+	if *f.play {
+		if *f.device == "" {
+			loadEnv()
+			*f.device = os.Getenv("ELEVENLABS_AUDIO_DEVICE")
+		}
+		device, err := resolveAudioDevice(*f.device)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			exit(1)
+		}
+		*f.device = device
+	}
+}
+
+func (f *outputFlags) resolve(name outputName) (path string, generated bool) {
+	name.Format = *f.format
+	name.Ext = formatExtension(*f.format)
+	return resolveOutput(*f.output, *f.outputDir, name)
+}
+
+// open creates the output sink, with a player attached when streaming
+// playback was requested.
+func (f *outputFlags) open(path string) (*sink, error) {
+	return openSink(path, *f.format, *f.play && *f.stream, *f.device)
+}
+
+// finish reports usage, runs the --qc check and plays the saved file
+// unless it was already played while streaming.
+func (f *outputFlags) finish(path string, characters int, event string) {
+	if *f.showUsage {
+		reportUsage(characters)
+	}
+	if err := f.qc.check(path, *f.format); err != nil {
+		fail("qc_failed", err)
+	}
+	if *f.play && !*f.stream {
+		if err := playFile(path, *f.format, *f.device); err != nil {
+			fail(event, err)
+		}
+	}
+}
+
+// outputReport is the --json result of a command that writes audio.
+type outputReport struct {
+	Output string `json:"output"`
+	Format string `json:"format"`
+	// Characters is omitted when the endpoint doesn't report them.
+	Characters int      `json:"characters,omitempty"`
+	Stems      []string `json:"stems,omitempty"`
+}
+
+// report prints the output paths or, with --json, the result. characters
+// is -1 if unknown.
+func (f *outputFlags) report(path string, characters int, stems []string) {
+	if *f.json {
+		printJSON(outputReport{Output: path, Format: *f.format, Characters: max(characters, 0), Stems: stems})
+		return
+	}
+	printPath(path)
+	for _, p := range stems {
+		printPath(p)
+	}
+}
+
+// isNamedPipe reports whether path is an existing FIFO.
+func isNamedPipe(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.Mode()&os.ModeNamedPipe != 0
+}
+
+// createOutput creates the output file, or returns stdout for "-".
+func createOutput(outputPath string) (*os.File, error) {
+	if outputPath == "-" {
+		return stdout, nil
+	}
+	if isNamedPipe(outputPath) {
+		fmt.Fprintf(os.Stderr, "Waiting for a reader on %s\n", outputPath)
+		f, err := os.OpenFile(outputPath, os.O_WRONLY, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open output pipe: %w", err)
+		}
+		return f, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	outFile, err := os.Create(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+	return outFile, nil
+}
+
+func writeOutput(outputPath string, r io.Reader) error {
+	outFile, err := createOutput(outputPath)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	if _, err := io.Copy(outFile, r); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
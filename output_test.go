@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSafeName(t *testing.T) {
+	tests := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{"Rachel", 80, "Rachel"},
+		{`a<b>c:d"e'f/g\h|i?j*k`, 80, "a-b-c-d-e-f-g-h-i-j-k"},
+		{"tab\there\x00\x7f", 80, "tab-here--"},
+		{"CON", 80, "CON_"},
+		{"con", 80, "con_"},
+		{"nul.txt", 80, "nul_.txt"},
+		{"COM1.tar.gz", 80, "COM1_.tar.gz"},
+		{"LPT9", 80, "LPT9_"},
+		{"CONSOLE", 80, "CONSOLE"},
+		{"COM10", 80, "COM10"},
+		{"..hidden. ", 80, "hidden"},
+		{" . ", 80, ""},
+		{"abcdef", 3, "abc"},
+		{"ab.  cd", 4, "ab"},
+		{"héllo", 2, "h"},
+		{"日本語", 7, "日本"},
+		{"😀😀", 5, "😀"},
+	}
+	for _, tt := range tests {
+		got := safeName(tt.in, tt.n)
+		if got != tt.want {
+			t.Errorf("safeName(%q, %d) = %q, want %q", tt.in, tt.n, got, tt.want)
+		}
+		if len(got) > tt.n || !utf8.ValidString(got) {
+			t.Errorf("safeName(%q, %d) = %q, over the limit or invalid UTF-8", tt.in, tt.n, got)
+		}
+	}
+}
+
+func TestTruncateName(t *testing.T) {
+	tests := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exact", 5, "exact"},
+		{"longer", 3, "lon"},
+		{"aé", 2, "a"},
+		{"aé", 3, "aé"},
+		{"€uro", 1, ""},
+		{"€uro", 3, "€"},
+		{strings.Repeat("ж", 150), maxName, strings.Repeat("ж", 100)},
+	}
+	for _, tt := range tests {
+		if got := truncateName(tt.in, tt.n); got != tt.want {
+			t.Errorf("truncateName(%q, %d) = %q, want %q", tt.in, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestGetOutputDir(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	t.Setenv("ELEVENLABS_OUTPUT_DIR", "")
+	if got := getOutputDir(""); got != os.TempDir() {
+		t.Errorf("default = %q, want os.TempDir() %q", got, os.TempDir())
+	}
+	env := filepath.Join(tmp, "env")
+	t.Setenv("ELEVENLABS_OUTPUT_DIR", env)
+	if got := getOutputDir(""); got != env {
+		t.Errorf("with ELEVENLABS_OUTPUT_DIR = %q, want %q", got, env)
+	}
+	if got := getOutputDir("flag"); got != "flag" {
+		t.Errorf("with a flag = %q, want flag", got)
+	}
+}
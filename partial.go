@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pink-tools/pink-elevenlabs/audio"
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+	"github.com/pink-tools/pink-otel"
+)
+
+const manifestName = "manifest.json"
+
+// partialManifest describes a --keep-partial run: the whole job, and for
+// each finished chunk the file holding its audio.
+type partialManifest struct {
+	Output   string                   `json:"output"`
+	Model    string                   `json:"model,omitempty"`
+	Format   string                   `json:"format"`
+	Settings elevenlabs.VoiceSettings `json:"voice_settings"`
+	Tags     audio.Tags               `json:"tags,omitempty"`
+	Parts    []partialPart            `json:"parts"`
+}
+
+type partialPart struct {
+	Text         string   `json:"text,omitempty"`
+	VoiceID      string   `json:"voice_id,omitempty"`
+	PreviousText string   `json:"previous_text,omitempty"`
+	NextText     string   `json:"next_text,omitempty"`
+	Pause        duration `json:"pause,omitempty"`
+	File         string   `json:"file,omitempty"`
+}
+
+// partialRun keeps the audio of every finished chunk beside the output for
+// --resume.
+type partialRun struct {
+	dir      string
+	manifest partialManifest
+}
+
+func partialDir(output string) string {
+	return output + ".partial"
+}
+
+func newPartialRun(output string, job ttsJob) (*partialRun, error) {
+	p := &partialRun{
+		dir: partialDir(output),
+		manifest: partialManifest{
+			Output:   output,
+			Model:    job.Model,
+			Format:   job.Format,
+			Settings: job.Settings,
+			Tags:     job.Tags,
+		},
+	}
+	for _, part := range job.Parts {
+		p.manifest.Parts = append(p.manifest.Parts, partialPart{
+			Text:         part.Text,
+			VoiceID:      part.VoiceID,
+			PreviousText: part.PreviousText,
+			NextText:     part.NextText,
+			Pause:        duration(part.Pause),
+		})
+	}
+	if err := os.MkdirAll(p.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create partial directory: %w", err)
+	}
+	return p, p.save()
+}
+
+func loadPartialRun(dir string) (*partialRun, error) {
+	b, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resume manifest: %w", err)
+	}
+	p := &partialRun{dir: dir}
+	if err := json.Unmarshal(b, &p.manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse resume manifest: %w", err)
+	}
+	return p, nil
+}
+
+// job rebuilds the job the manifest describes.
+func (p *partialRun) job() ttsJob {
+	m := p.manifest
+	job := ttsJob{Model: m.Model, Format: m.Format, Settings: m.Settings, Tags: m.Tags, Partial: p}
+	for _, part := range m.Parts {
+		job.Parts = append(job.Parts, ttsPart{
+			Text:         part.Text,
+			VoiceID:      part.VoiceID,
+			PreviousText: part.PreviousText,
+			NextText:     part.NextText,
+			Pause:        time.Duration(part.Pause),
+		})
+	}
+	return job
+}
+
+// finished reports whether any chunk's audio was kept.
+func (p *partialRun) finished() bool {
+	for _, part := range p.manifest.Parts {
+		if part.File != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// saved opens the kept audio of part i, or returns nil if there is none.
+func (p *partialRun) saved(i int) (*elevenlabs.Audio, error) {
+	name := p.manifest.Parts[i].File
+	if name == "" {
+		return nil, nil
+	}
+	f, err := os.Open(filepath.Join(p.dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open kept chunk: %w", err)
+	}
+	// Kept chunks were billed when first synthesized.
+	return &elevenlabs.Audio{ReadCloser: f}, nil
+}
+
+func chunkFile(i int, format string) string {
+	return fmt.Sprintf("%04d%s", i, formatExtension(format))
+}
+
+// keep copies the audio of part i to the partial directory as it is read.
+// done records it once it was read in full.
+func (p *partialRun) keep(i int, resp *elevenlabs.Audio, format string) (*elevenlabs.Audio, error) {
+	f, err := os.Create(filepath.Join(p.dir, chunkFile(i, format)))
+	if err != nil {
+		resp.Close()
+		return nil, fmt.Errorf("failed to create kept chunk: %w", err)
+	}
+	kept := *resp
+	kept.ReadCloser = &keptReader{Reader: io.TeeReader(resp, f), file: f, resp: resp}
+	return &kept, nil
+}
+
+func (p *partialRun) done(i int, format string) error {
+	p.manifest.Format = format
+	p.manifest.Parts[i].File = chunkFile(i, format)
+	return p.save()
+}
+
+// abandon keeps the directory of a failed run if any chunk finished, and
+// says how to resume it.
+func (p *partialRun) abandon() {
+	if !p.finished() {
+		os.RemoveAll(p.dir)
+		return
+	}
+	otel.Warn("tts_partial_kept", map[string]any{"dir": p.dir})
+	fmt.Fprintf(os.Stderr, "Partial output kept in %s; resume with: %s tts --resume %s\n", p.dir, serviceName, p.dir)
+}
+
+func (p *partialRun) save() error {
+	b, err := json.MarshalIndent(p.manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to write resume manifest: %w", err)
+	}
+	tmp := filepath.Join(p.dir, manifestName+".tmp")
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return fmt.Errorf("failed to write resume manifest: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(p.dir, manifestName)); err != nil {
+		return fmt.Errorf("failed to write resume manifest: %w", err)
+	}
+	return nil
+}
+
+type keptReader struct {
+	io.Reader
+	file *os.File
+	resp io.Closer
+}
+
+func (r *keptReader) Close() error {
+	r.resp.Close()
+	return r.file.Close()
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/pink-tools/pink-elevenlabs/audio"
+)
+
+// playerCandidate describes how to run one external player.
+type playerCandidate struct {
+	name   string
+	args   func(src, format string) []string
+	device func(device string) (args, env []string, ok bool)
+}
+
+// rawFormats are the sample formats of headerless codecs, as ffmpeg and mpv
+// name them.
+var rawFormats = map[audio.Codec]string{
+	audio.PCM:  "s16le",
+	audio.ULaw: "mulaw",
+	audio.ALaw: "alaw",
+}
+
+var playerCandidates = []playerCandidate{
+	{"ffplay", func(src, format string) []string {
+		args := []string{"-nodisp", "-autoexit", "-loglevel", "quiet"}
+		if raw, rate, ok := rawFormat(format); ok {
+			args = append(args, "-f", raw, "-ar", rate, "-ac", "1")
+		}
+		return append(args, "-i", src)
+	}, func(device string) ([]string, []string, bool) {
+		env, ok := ffplayDeviceEnv(device)
+		return nil, env, ok
+	}},
+	{"mpv", func(src, format string) []string {
+		args := []string{"--no-video", "--really-quiet"}
+		if raw, rate, ok := rawFormat(format); ok {
+			args = append(args, "--demuxer=rawaudio", "--demuxer-rawaudio-format="+raw,
+				"--demuxer-rawaudio-rate="+rate, "--demuxer-rawaudio-channels=1")
+		}
+		return append(args, src)
+	}, func(device string) ([]string, []string, bool) {
+		return []string{"--audio-device=" + device}, nil, true
+	}},
+}
+
+// rawFormat returns the sample format and rate a player needs to be told
+// for a headerless API format.
+func rawFormat(format string) (raw, rate string, ok bool) {
+	f, err := audio.ParseFormat(format)
+	if err != nil {
+		return "", "", false
+	}
+	raw, ok = rawFormats[f.Codec]
+	return raw, strconv.Itoa(f.SampleRate), ok
+}
+
+// playerCommand builds the command that plays src ("-" for stdin) on device.
+func playerCommand(src, format, device string) (*exec.Cmd, error) {
+	loadEnv()
+	if custom := splitCommand(os.Getenv("ELEVENLABS_PLAYER")); len(custom) > 0 {
+		args := custom[1:]
+		replaced := false
+		for i, a := range args {
+			switch a {
+			case "{}":
+				args[i] = src
+				replaced = true
+			case "{device}":
+				args[i] = device
+			}
+		}
+		if !replaced {
+			args = append(args, src)
+		}
+		return exec.Command(custom[0], args...), nil
+	}
+
+	for _, c := range playerCandidates {
+		path, err := exec.LookPath(c.name)
+		if err != nil {
+			continue
+		}
+		if device == "" {
+			return exec.Command(path, c.args(src, format)...), nil
+		}
+		args, env, ok := c.device(device)
+		if !ok {
+			continue
+		}
+		cmd := exec.Command(path, append(args, c.args(src, format)...)...)
+		if env != nil {
+			cmd.Env = append(os.Environ(), env...)
+		}
+		return cmd, nil
+	}
+	if device != "" {
+		return nil, fmt.Errorf("no audio player found that can play on %s (install mpv, or set ELEVENLABS_PLAYER)", device)
+	}
+	if src != "-" {
+		if path, err := exec.LookPath("afplay"); err == nil {
+			return exec.Command(path, src), nil
+		}
+	}
+	return nil, fmt.Errorf("no audio player found (install ffplay or mpv, or set ELEVENLABS_PLAYER)")
+}
+
+func playFile(path, format, device string) error {
+	cmd, err := playerCommand(path, format, device)
+	if err != nil {
+		return err
+	}
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("player failed: %w", err)
+	}
+	return nil
+}
+
+type player struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+}
+
+func startPlayer(format, device string) (*player, error) {
+	cmd, err := playerCommand("-", format, device)
+	if err != nil {
+		return nil, err
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start player: %w", err)
+	}
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start player: %w", err)
+	}
+	return &player{cmd: cmd, stdin: stdin}, nil
+}
+
+// Close ends the input and waits for playback to finish.
+func (p *player) Close() error {
+	p.stdin.Close()
+	return p.cmd.Wait()
+}
+
+// sink is where synthesized audio goes: the output file and, optionally, a
+// player.
+type sink struct {
+	file   *os.File
+	player *player
+}
+
+// openSink creates the output, with a player on device attached if play is
+// set.
+func openSink(outputPath, format string, play bool, device string) (*sink, error) {
+	f, err := createOutput(outputPath)
+	if err != nil {
+		return nil, err
+	}
+	s := &sink{file: f}
+	if play {
+		p, err := startPlayer(format, device)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		s.player = p
+	}
+	return s, nil
+}
+
+func (s *sink) Write(b []byte) (int, error) {
+	n, err := s.file.Write(b)
+	if err != nil {
+		return n, err
+	}
+	if s.player != nil {
+		if _, err := s.player.stdin.Write(b); err != nil {
+			s.player.Close()
+			s.player = nil
+		}
+	}
+	return n, nil
+}
+
+func (s *sink) Close() error {
+	err := s.file.Close()
+	if s.player != nil {
+		s.player.Close()
+	}
+	return err
+}
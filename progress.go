@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Progress modes of --progress.
+const (
+	progressText  = "text"
+	progressJSONL = "jsonl"
+)
+
+func checkProgressMode(mode string) error {
+	switch mode {
+	case "", progressText, progressJSONL:
+		return nil
+	}
+	return fmt.Errorf("invalid --progress %q (use %s or %s)", mode, progressText, progressJSONL)
+}
+
+// progressEvent is one line of --progress jsonl output.
+type progressEvent struct {
+	Event           string  `json:"event"`
+	Chunk           int     `json:"chunk"`
+	Chunks          int     `json:"chunks"`
+	Characters      int     `json:"characters"`
+	TotalCharacters int     `json:"total_characters"`
+	ElapsedSec      float64 `json:"elapsed_sec"`
+	ETASec          float64 `json:"eta_sec"`
+}
+
+// progress reports completed chunks of a long synthesis on stderr. The ETA
+// assumes the remaining characters take as long as the finished ones.
+type progress struct {
+	mode       string
+	chunks     int
+	total      int
+	done       int
+	characters int
+	start      time.Time
+}
+
+func newProgress(mode string, chunks, totalChars int) *progress {
+	return &progress{mode: mode, chunks: chunks, total: totalChars, start: time.Now()}
+}
+
+func (p *progress) chunkDone(chars int) {
+	p.done++
+	p.characters += chars
+	if p.mode == "" {
+		return
+	}
+
+	elapsed := time.Since(p.start)
+	var eta time.Duration
+	if p.characters > 0 {
+		eta = time.Duration(float64(elapsed) * float64(p.total-p.characters) / float64(p.characters))
+	}
+	switch p.mode {
+	case progressJSONL:
+		b, _ := json.Marshal(progressEvent{
+			Event:           "tts_chunk",
+			Chunk:           p.done,
+			Chunks:          p.chunks,
+			Characters:      p.characters,
+			TotalCharacters: p.total,
+			ElapsedSec:      elapsed.Seconds(),
+			ETASec:          eta.Seconds(),
+		})
+		fmt.Fprintln(os.Stderr, string(b))
+	default:
+		fmt.Fprintf(os.Stderr, "Chunk %d/%d: %d/%d characters, %s elapsed, ETA %s\n",
+			p.done, p.chunks, p.characters, p.total, elapsed.Round(time.Second), eta.Round(time.Second))
+	}
+}
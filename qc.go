@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pink-tools/pink-elevenlabs/audio"
+	"github.com/pink-tools/pink-otel"
+)
+
+// errQCFailed wraps the findings of a failed --qc check.
+var errQCFailed = errors.New("quality check failed")
+
+const (
+	defaultQCMinLoudness = -26.0
+	defaultQCMaxLoudness = -12.0
+	defaultQCMaxSilence  = 3 * time.Second
+)
+
+// qcFlags are the limits of the --qc gate.
+type qcFlags struct {
+	enabled     *bool
+	minLoudness *float64
+	maxLoudness *float64
+	maxSilence  *time.Duration
+}
+
+func addQCFlags(fs *flag.FlagSet) *qcFlags {
+	return &qcFlags{
+		enabled:     fs.Bool("qc", false, "Check the output for clipping, long silence and loudness, and fail if out of spec"),
+		minLoudness: fs.Float64("qc-min-lufs", defaultQCMinLoudness, "Lowest integrated loudness --qc accepts, in LUFS"),
+		maxLoudness: fs.Float64("qc-max-lufs", defaultQCMaxLoudness, "Highest integrated loudness --qc accepts, in LUFS"),
+		maxSilence:  fs.Duration("qc-max-silence", defaultQCMaxSilence, "Longest silence --qc accepts"),
+	}
+}
+
+// measureOutput decodes the output file at path, in format, to PCM and
+// measures it. Raw and WAV output is decoded here; mp3 and opus need ffmpeg.
+func measureOutput(path, format string) (*audio.Levels, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output: %w", err)
+	}
+	defer f.Close()
+
+	if format == wavFormat {
+		wav, err := audio.ReadWAVHeader(f)
+		if err != nil {
+			return nil, err
+		}
+		return audio.MeasurePCM(io.LimitReader(f, wav.DataSize), wav.SampleRate, wav.Channels)
+	}
+
+	af, err := audio.ParseFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	switch af.Codec {
+	case audio.PCM:
+		return audio.MeasurePCM(f, af.SampleRate, 1)
+	case audio.ULaw, audio.ALaw:
+		return audio.MeasurePCM(audio.NewG711Reader(f, af.Codec), af.SampleRate, 1)
+	}
+
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return nil, fmt.Errorf("--qc of %s output needs ffmpeg to decode it", af.Codec)
+	}
+	const rate = 48000
+	cmd := exec.Command(ffmpeg, "-v", "error", "-i", "-", "-f", "s16le", "-ac", "1", "-ar", fmt.Sprint(rate), "-")
+	cmd.Stdin = f
+	cmd.Stderr = os.Stderr
+	pcm, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+	levels, err := audio.MeasurePCM(pcm, rate, 1)
+	if werr := cmd.Wait(); err == nil && werr != nil {
+		err = fmt.Errorf("ffmpeg failed to decode output: %w", werr)
+	}
+	return levels, err
+}
+
+// problems lists every way levels miss the limits.
+func (q *qcFlags) problems(l *audio.Levels) []string {
+	var p []string
+	if l.Clipped > 0 {
+		p = append(p, fmt.Sprintf("%d clipped samples", l.Clipped))
+	}
+	if l.LongestSilence > *q.maxSilence {
+		p = append(p, fmt.Sprintf("%s of silence (max %s)", l.LongestSilence.Round(10*time.Millisecond), *q.maxSilence))
+	}
+	if l.Loudness < *q.minLoudness {
+		p = append(p, fmt.Sprintf("loudness %.1f LUFS below %.1f", l.Loudness, *q.minLoudness))
+	}
+	if l.Loudness > *q.maxLoudness {
+		p = append(p, fmt.Sprintf("loudness %.1f LUFS above %.1f", l.Loudness, *q.maxLoudness))
+	}
+	return p
+}
+
+// check measures the output and reports its levels on stderr, returning an
+// error wrapping errQCFailed if any limit is missed.
+func (q *qcFlags) check(path, format string) error {
+	if !*q.enabled {
+		return nil
+	}
+	if path == "-" {
+		return fmt.Errorf("--qc needs an output file, not -o -")
+	}
+	l, err := measureOutput(path, format)
+	if err != nil {
+		return fmt.Errorf("--qc: %w", err)
+	}
+	problems := q.problems(l)
+	otel.Info("qc_result", map[string]any{
+		"output":             path,
+		"loudness_lufs":      l.Loudness,
+		"peak_dbfs":          l.Peak,
+		"clipped":            l.Clipped,
+		"longest_silence_ms": l.LongestSilence.Milliseconds(),
+		"passed":             len(problems) == 0,
+	})
+	fmt.Fprintf(os.Stderr, "QC: %.1f LUFS, peak %.1f dBFS, %d clipped, longest silence %s\n",
+		l.Loudness, l.Peak, l.Clipped, l.LongestSilence.Round(10*time.Millisecond))
+	if len(problems) > 0 {
+		return fmt.Errorf("%w for %s: %s", errQCFailed, path, strings.Join(problems, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pink-tools/pink-elevenlabs/audio"
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+	"github.com/pink-tools/pink-otel"
+)
+
+// wavFormat stands in for an API format when the output is a WAV file
+// spliced from the input rather than audio as the API returned it.
+const wavFormat = "wav"
+
+// timeRange is a span of the input, from --range.
+type timeRange struct {
+	Start, End time.Duration
+}
+
+func (r timeRange) String() string {
+	return formatTimestamp(r.Start) + "-" + formatTimestamp(r.End)
+}
+
+// rangeFlag collects --range values such as 00:01:10-00:02:30, repeated or
+// comma-separated.
+type rangeFlag []timeRange
+
+func (f *rangeFlag) String() string {
+	s := make([]string, len(*f))
+	for i, r := range *f {
+		s[i] = r.String()
+	}
+	return strings.Join(s, ",")
+}
+
+func (f *rangeFlag) Set(v string) error {
+	for _, part := range strings.Split(v, ",") {
+		from, to, ok := strings.Cut(strings.TrimSpace(part), "-")
+		if !ok {
+			return fmt.Errorf("expected start-end, got %q", part)
+		}
+		start, err := parseTimestamp(from)
+		if err != nil {
+			return err
+		}
+		end, err := parseTimestamp(to)
+		if err != nil {
+			return err
+		}
+		if end <= start {
+			return fmt.Errorf("range %q ends before it starts", part)
+		}
+		*f = append(*f, timeRange{start, end})
+	}
+	return nil
+}
+
+// parseTimestamp parses [[hh:]mm:]ss[.fff].
+func parseTimestamp(s string) (time.Duration, error) {
+	fields := strings.Split(strings.TrimSpace(s), ":")
+	if len(fields) > 3 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	var secs float64
+	for i, field := range fields {
+		last := i == len(fields)-1
+		v, err := strconv.ParseFloat(field, 64)
+		if err != nil || v < 0 || !last && v != float64(int(v)) {
+			return 0, fmt.Errorf("invalid time %q", s)
+		}
+		secs = secs*60 + v
+	}
+	return time.Duration(secs * float64(time.Second)), nil
+}
+
+func formatTimestamp(d time.Duration) string {
+	h := d / time.Hour
+	m := d % time.Hour / time.Minute
+	s := float64(d%time.Minute) / float64(time.Second)
+	return fmt.Sprintf("%02d:%02d:%06.3f", h, m, s)
+}
+
+// sortRanges orders ranges by start and rejects overlaps and ranges past
+// the end of the input.
+func sortRanges(ranges []timeRange, length time.Duration) ([]timeRange, error) {
+	sorted := append([]timeRange(nil), ranges...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+	for i, r := range sorted {
+		if r.Start >= length {
+			return nil, fmt.Errorf("range %s starts after the end of the input (%s)", r, formatTimestamp(length))
+		}
+		if i > 0 && r.Start < sorted[i-1].End {
+			return nil, fmt.Errorf("ranges %s and %s overlap", sorted[i-1], r)
+		}
+	}
+	return sorted, nil
+}
+
+// pcmFormatFor returns the highest PCM output format not above rate, so
+// converted audio needs no more than downsampling where possible.
+func pcmFormatFor(rate int) string {
+	formats := elevenlabs.OutputFormats(string(audio.PCM))
+	best := formats[0]
+	for _, f := range formats {
+		if af, err := audio.ParseFormat(f); err == nil && af.SampleRate <= rate {
+			best = f
+		}
+	}
+	return best
+}
+
+// voiceChangeRanges converts only job.Ranges of a 16-bit PCM WAV input,
+// keeping everything around them in place.
+func voiceChangeRanges(ctx context.Context, client *elevenlabs.Client, job voiceJob, w io.Writer) (voiceResult, error) {
+	res := voiceResult{Format: wavFormat}
+	inputFile, err := os.Open(job.Input)
+	if err != nil {
+		return res, fmt.Errorf("failed to open input file: %w", err)
+	}
+	defer inputFile.Close()
+
+	wav, err := audio.ReadWAVHeader(inputFile)
+	if err != nil {
+		return res, fmt.Errorf("--range needs a 16-bit PCM WAV input: %w", err)
+	}
+	ranges, err := sortRanges(job.Ranges, wav.Duration())
+	if err != nil {
+		return res, err
+	}
+
+	otel.Info("voice_change_request", map[string]any{
+		"voice_id": job.VoiceID,
+		"model":    job.Model,
+		"format":   wavFormat,
+		"input":    job.Input,
+		"ranges":   len(ranges),
+	})
+
+	frame := int64(wav.FrameBytes())
+	size := wav.DataSize / frame * frame
+	if err := audio.WriteWAVHeader(w, wav.Channels, wav.SampleRate, size); err != nil {
+		return res, fmt.Errorf("failed to write output: %w", err)
+	}
+	// offset converts a time in the input to a frame-aligned byte offset
+	// into its sample data.
+	offset := func(d time.Duration) int64 {
+		frames := int64(d.Seconds() * float64(wav.SampleRate))
+		return min(frames*frame, size)
+	}
+	section := func(from, to int64) io.Reader {
+		return io.NewSectionReader(inputFile, wav.DataOffset+from, to-from)
+	}
+
+	format := pcmFormatFor(wav.SampleRate)
+	var pos int64
+	for i, r := range ranges {
+		start, end := offset(r.Start), offset(r.End)
+		if _, err := io.Copy(w, section(pos, start)); err != nil {
+			return res, fmt.Errorf("failed to write output: %w", err)
+		}
+
+		var clip bytes.Buffer
+		if err := audio.WriteWAVHeader(&clip, wav.Channels, wav.SampleRate, end-start); err != nil {
+			return res, err
+		}
+		if _, err := io.Copy(&clip, section(start, end)); err != nil {
+			return res, fmt.Errorf("failed to read input file: %w", err)
+		}
+		resp, f, err := withFormatFallback(format, job.Strict, func(format string) (*elevenlabs.Audio, error) {
+			return client.SpeechToSpeech(ctx, job.VoiceID, elevenlabs.STSRequest{
+				Audio:                 bytes.NewReader(clip.Bytes()),
+				Filename:              filepath.Base(job.Input),
+				ModelID:               job.Model,
+				VoiceSettings:         job.Settings,
+				RemoveBackgroundNoise: job.RemoveBackgroundNoise,
+				OutputFormat:          format,
+				Stream:                job.Stream,
+			})
+		})
+		if err != nil {
+			return res, fmt.Errorf("range %s: %w", r, err)
+		}
+		format = f
+		pcm, err := io.ReadAll(resp)
+		resp.Close()
+		if err != nil {
+			return res, fmt.Errorf("range %s: failed to read audio: %w", r, err)
+		}
+
+		af, err := audio.ParseFormat(f)
+		if err != nil {
+			return res, err
+		}
+		converted := audio.ResampleMono(pcm, af.SampleRate, wav.SampleRate, wav.Channels)
+		if n := end - start; int64(len(converted)) > n {
+			converted = converted[:n]
+		} else {
+			converted = append(converted, make([]byte, n-int64(len(converted)))...)
+		}
+		if _, err := w.Write(converted); err != nil {
+			return res, fmt.Errorf("failed to write output: %w", err)
+		}
+		pos = end
+
+		otel.Info("voice_change_range", map[string]any{
+			"range":      i + 1,
+			"start_ms":   r.Start.Milliseconds(),
+			"end_ms":     r.End.Milliseconds(),
+			"request_id": resp.RequestID,
+			"characters": resp.CharacterCount,
+		})
+		if resp.CharacterCount < 0 || res.Characters < 0 {
+			res.Characters = -1
+		} else {
+			res.Characters += resp.CharacterCount
+		}
+	}
+	if _, err := io.Copy(w, section(pos, size)); err != nil {
+		return res, fmt.Errorf("failed to write output: %w", err)
+	}
+	return res, nil
+}
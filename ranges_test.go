@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pink-tools/pink-elevenlabs/audio"
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+)
+
+func TestRangeFlag(t *testing.T) {
+	var f rangeFlag
+	if err := f.Set("1:10-1:12.5, 0:05-00:00:06"); err != nil {
+		t.Fatal(err)
+	}
+	want := rangeFlag{{70 * time.Second, 72500 * time.Millisecond}, {5 * time.Second, 6 * time.Second}}
+	if len(f) != 2 || f[0] != want[0] || f[1] != want[1] {
+		t.Errorf("ranges = %v, want %v", f, want)
+	}
+	if f.String() != "00:01:10.000-00:01:12.500,00:00:05.000-00:00:06.000" {
+		t.Errorf("String = %q", f.String())
+	}
+	for _, v := range []string{"5", "6-5", "1:2:3:4-5", "1.5:00-2:00", "a-b"} {
+		if err := new(rangeFlag).Set(v); err == nil {
+			t.Errorf("Set(%q) succeeded", v)
+		}
+	}
+}
+
+func TestSortRanges(t *testing.T) {
+	ranges := []timeRange{{3 * time.Second, 4 * time.Second}, {time.Second, 2 * time.Second}}
+	got, err := sortRanges(ranges, 10*time.Second)
+	if err != nil || got[0] != ranges[1] || got[1] != ranges[0] {
+		t.Errorf("sortRanges = %v, %v", got, err)
+	}
+	if _, err := sortRanges([]timeRange{{time.Second, 3 * time.Second}, {2 * time.Second, 4 * time.Second}}, 10*time.Second); err == nil {
+		t.Error("overlapping ranges were accepted")
+	}
+	if _, err := sortRanges([]timeRange{{10 * time.Second, 11 * time.Second}}, 10*time.Second); err == nil {
+		t.Error("a range past the end was accepted")
+	}
+}
+
+// TestVoiceChangeRanges splices converted ranges into a stereo 20 kHz WAV
+// whose sample values count frames, from a fake API returning 16 kHz mono.
+func TestVoiceChangeRanges(t *testing.T) {
+	const rate, frames, converted = 20000, 40000, 7000
+	var data []byte
+	for i := 0; i < frames; i++ {
+		data = binary.LittleEndian.AppendUint16(data, uint16(i%30000))
+		data = binary.LittleEndian.AppendUint16(data, uint16(i%30000))
+	}
+	var in bytes.Buffer
+	audio.WriteWAVHeader(&in, 2, rate, int64(len(data)))
+	in.Write(data)
+	input := filepath.Join(t.TempDir(), "in.wav")
+	if err := os.WriteFile(input, in.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var clips []time.Duration
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		i := bytes.Index(body, []byte("RIFF"))
+		if i < 0 || r.URL.Query().Get("output_format") != "pcm_16000" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		clip, err := audio.ReadWAVHeader(bytes.NewReader(body[i:]))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		clips = append(clips, clip.Duration())
+		mu.Unlock()
+		n := int(clip.Duration().Seconds() * 16000)
+		for j := 0; j < n; j++ {
+			binary.Write(w, binary.LittleEndian, int16(converted))
+		}
+	}))
+	defer srv.Close()
+	client := elevenlabs.NewClient("key", elevenlabs.WithBaseURL(srv.URL))
+
+	job := voiceJob{
+		Input:   input,
+		VoiceID: "voice",
+		Ranges:  []timeRange{{1500 * time.Millisecond, 2 * time.Second}, {250 * time.Millisecond, 500 * time.Millisecond}},
+	}
+	var out bytes.Buffer
+	res, err := voiceChangeRanges(context.Background(), client, job, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Format != wavFormat {
+		t.Errorf("format = %q", res.Format)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(clips) != 2 || clips[0] != 250*time.Millisecond || clips[1] != 500*time.Millisecond {
+		t.Errorf("sent clips of %v, want the ranges in order", clips)
+	}
+
+	wav, err := audio.ReadWAVHeader(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wav.Channels != 2 || wav.SampleRate != rate || wav.DataSize != int64(len(data)) || int(wav.DataOffset) != out.Len()-len(data) {
+		t.Fatalf("output header %+v for %d bytes", *wav, out.Len())
+	}
+	samples := out.Bytes()[wav.DataOffset:]
+	var wrong []string
+	for i := 0; i < frames; i++ {
+		want := int16(i % 30000)
+		if i >= 5000 && i < 10000 || i >= 30000 && i < 40000 {
+			want = converted
+		}
+		for c := 0; c < 2; c++ {
+			if got := int16(binary.LittleEndian.Uint16(samples[4*i+2*c:])); got != want && len(wrong) < 5 {
+				wrong = append(wrong, fmt.Sprintf("frame %d channel %d: %d, want %d", i, c, got, want))
+			}
+		}
+	}
+	if len(wrong) > 0 {
+		t.Errorf("wrong samples: %v", wrong)
+	}
+}
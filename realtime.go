@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pink-tools/pink-elevenlabs/audio"
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+	"github.com/pink-tools/pink-otel"
+)
+
+// cmdRealtime runs tts --realtime: text is forwarded as soon as it is read,
+// so piping token-by-token output starts speech within the first words.
+func cmdRealtime(out *outputFlags, settings *settingsFlags, api *clientFlags, voiceID, model, input string) {
+	// Realtime output is always streamed, so --play plays while saving.
+	*out.stream = true
+	out.check()
+
+	r := io.Reader(os.Stdin)
+	if input != "" && input != "-" {
+		f, err := os.Open(input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: failed to read input file: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	outputPath, generated := out.resolve(outputName{Prefix: "speech", Voice: voiceID, Input: input, Seed: voiceID + "\x00realtime"})
+
+	w, err := out.open(outputPath)
+	if err != nil {
+		fail("tts_failed", err)
+	}
+	job := ttsJob{
+		Model:    model,
+		Format:   *out.format,
+		Settings: settings.settings(),
+		Tags:     audio.Tags(out.tags),
+		Strict:   *out.strict,
+	}
+	chars, err := realtimeTTS(context.Background(), api.newClient(), voiceID, job, r, w)
+	if cerr := w.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("failed to write output: %w", cerr)
+	}
+	if err != nil {
+		if generated {
+			os.Remove(outputPath)
+		}
+		fail("tts_failed", err)
+	}
+	otel.Info("tts_complete", map[string]any{"output": outputPath, "realtime": true, "text_len": chars})
+	// The websocket endpoint doesn't report billed characters.
+	out.finish(outputPath, -1, "tts_play_failed")
+
+	out.report(outputPath, -1, nil)
+}
+
+// realtimeTTS sends text from r over a websocket session while writing the
+// audio to w. It returns the number of characters sent.
+func realtimeTTS(ctx context.Context, client *elevenlabs.Client, voiceID string, job ttsJob, r io.Reader, w io.Writer) (int, error) {
+	f, err := apiFormat(job.Format)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	otel.Info("tts_request", map[string]any{"voice_id": voiceID, "model": job.Model, "format": job.Format, "realtime": true})
+	settings := job.Settings
+	// Only a format rejected while connecting can fall back; once audio
+	// flows the format is fixed.
+	stream, f, err := withFormatFallback(f, job.Strict, func(format string) (*elevenlabs.RealtimeStream, error) {
+		return client.StreamTextToSpeech(ctx, voiceID, elevenlabs.RealtimeRequest{
+			ModelID:       job.Model,
+			VoiceSettings: &settings,
+			OutputFormat:  format,
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer stream.Close()
+
+	af, err := audio.ParseFormat(f)
+	if err != nil {
+		return 0, err
+	}
+	joiner, err := audio.NewJoiner(w, af, job.Tags)
+	if err != nil {
+		return 0, err
+	}
+
+	type sent struct {
+		chars int
+		err   error
+	}
+	sendDone := make(chan sent, 1)
+	go func() {
+		n, err := sendText(stream, r)
+		if err != nil {
+			cancel()
+		}
+		sendDone <- sent{n, err}
+	}()
+
+	// The received chunks are pieces of one stream, so they go through the
+	// joiner as a single part.
+	pr, pw := io.Pipe()
+	joinDone := make(chan error, 1)
+	go func() {
+		err := joiner.Append(pr)
+		pr.CloseWithError(err)
+		joinDone <- err
+	}()
+
+	var recvErr error
+	for {
+		chunk, err := stream.Recv()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				recvErr = err
+			}
+			break
+		}
+		if _, err := pw.Write(chunk); err != nil {
+			recvErr = fmt.Errorf("failed to write output: %w", err)
+			break
+		}
+	}
+	pw.Close()
+	joinErr := <-joinDone
+	if recvErr != nil {
+		// A failed send cancels the session, so prefer its error.
+		select {
+		case s := <-sendDone:
+			if s.err != nil {
+				return s.chars, s.err
+			}
+		default:
+		}
+		return 0, recvErr
+	}
+	s := <-sendDone
+	if s.err != nil {
+		return s.chars, s.err
+	}
+	if joinErr != nil {
+		return s.chars, fmt.Errorf("failed to write output: %w", joinErr)
+	}
+	if err := joiner.Close(); err != nil {
+		return s.chars, fmt.Errorf("failed to write output: %w", err)
+	}
+	return s.chars, nil
+}
+
+// sendText forwards text from r as it is read, holding back an incomplete
+// UTF-8 sequence at the end of a read, and ends the session's input at EOF.
+func sendText(stream *elevenlabs.RealtimeStream, r io.Reader) (int, error) {
+	buf := make([]byte, 4096)
+	var pending []byte
+	total := 0
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			pending = append(pending, buf[:n]...)
+			cut := len(pending)
+			if i := utf8RuneStart(pending); !utf8.FullRune(pending[i:]) {
+				cut = i
+			}
+			text := string(pending[:cut])
+			pending = append(pending[:0], pending[cut:]...)
+
+			if text != "" {
+				if err := stream.Send(text); err != nil {
+					return total, err
+				}
+				total += utf8.RuneCountInString(text)
+				if strings.Contains(text, "\n") {
+					if err := stream.Flush(); err != nil {
+						return total, err
+					}
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return total, fmt.Errorf("failed to read input: %w", err)
+		}
+	}
+	if len(pending) > 0 {
+		if err := stream.Send(string(pending)); err != nil {
+			return total, err
+		}
+		total += utf8.RuneCount(pending)
+	}
+	return total, stream.CloseSend()
+}
+
+// utf8RuneStart returns the index of the first byte of the last rune in b.
+func utf8RuneStart(b []byte) int {
+	i := len(b) - 1
+	for i > 0 && len(b)-i < utf8.UTFMax && !utf8.RuneStart(b[i]) {
+		i--
+	}
+	return i
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+	"github.com/pink-tools/pink-otel"
+)
+
+func cmdReport(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "ERROR: report subcommand required (voices)")
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "voices":
+		cmdReportVoices(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown report command: %s\n", args[0])
+		os.Exit(1)
+	}
+}
+
+// voiceUsage is what one voice was used for with one model in a period.
+type voiceUsage struct {
+	Period      string `json:"period,omitempty"`
+	VoiceID     string `json:"voice_id"`
+	VoiceName   string `json:"voice_name"`
+	Category    string `json:"category,omitempty"`
+	ModelID     string `json:"model_id"`
+	Generations int    `json:"generations"`
+	Characters  int    `json:"characters"`
+}
+
+type voiceReport struct {
+	Usage []voiceUsage `json:"usage"`
+	// Unused are the account's own voices, cloned or designed, that had no
+	// generations in the range.
+	Unused []elevenlabs.Voice `json:"unused"`
+}
+
+// reportPeriods format a generation's time as the period it is counted in.
+var reportPeriods = map[string]func(time.Time) string{
+	"day":   func(t time.Time) string { return t.Format("2006-01-02") },
+	"week":  func(t time.Time) string { y, w := t.ISOWeek(); return fmt.Sprintf("%d-W%02d", y, w) },
+	"month": func(t time.Time) string { return t.Format("2006-01") },
+	"all":   func(time.Time) string { return "" },
+}
+
+func cmdReportVoices(args []string) {
+	fs := flag.NewFlagSet("report voices", flag.ExitOnError)
+	since := fs.String("since", "90d", "Only generations after this date, time or duration ago (e.g. 2026-07-01, 30d)")
+	until := fs.String("until", "", "Only generations before this date, time or duration ago")
+	by := fs.String("by", "month", "Group by day, week, month or all")
+	voice := fs.String("voice", "", "Only this voice ID")
+	fs.StringVar(voice, "v", "", "Only this voice ID")
+	jsonOut := fs.Bool("json", false, "Print the report as JSON")
+	api := addClientFlags(fs)
+	parseArgs(fs, args)
+
+	period, ok := reportPeriods[*by]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "ERROR: invalid --by %q (use day, week, month or all)\n", *by)
+		os.Exit(1)
+	}
+	after, err := parseSince(*since)
+	if err == nil && after.IsZero() {
+		err = fmt.Errorf("--since is required")
+	}
+	var before time.Time
+	if err == nil {
+		before, err = parseSince(*until)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	client := api.newClient()
+	usage, err := historyVoiceUsage(ctx, client, *voice, after, before, period)
+	if err != nil {
+		fail("report_failed", err)
+	}
+	report := voiceReport{Usage: usage, Unused: []elevenlabs.Voice{}}
+
+	// Categories and unused voices are extras; the report stands without.
+	voices, err := client.ListVoices(ctx)
+	if err != nil {
+		warn("report_voices_unavailable", map[string]any{"error": err.Error()},
+			"failed to list voices, so categories and unused voices are missing: %v", err)
+	}
+	used := map[string]bool{}
+	for _, u := range usage {
+		used[u.VoiceID] = true
+	}
+	categories := map[string]string{}
+	for _, v := range voices {
+		categories[v.VoiceID] = v.Category
+		if v.Category != "premade" && !used[v.VoiceID] && (*voice == "" || v.VoiceID == *voice) {
+			report.Unused = append(report.Unused, v)
+		}
+	}
+	for i := range report.Usage {
+		report.Usage[i].Category = categories[report.Usage[i].VoiceID]
+	}
+	otel.Info("report_voices", map[string]any{"rows": len(report.Usage), "unused": len(report.Unused)})
+
+	if *jsonOut {
+		printJSON(report)
+		return
+	}
+	if len(report.Usage) == 0 {
+		fmt.Fprintln(os.Stderr, "No generations in this range")
+	} else {
+		fmt.Printf("%-10s %-24s %-12s %-24s %11s %11s\n", "Period", "Voice", "Category", "Model", "Generations", "Characters")
+		for _, u := range report.Usage {
+			fmt.Printf("%-10s %-24s %-12s %-24s %11d %11d\n", u.Period, u.VoiceName, u.Category, u.ModelID, u.Generations, u.Characters)
+		}
+	}
+	if len(report.Unused) > 0 {
+		fmt.Println("\nUnused voices:")
+		for _, v := range report.Unused {
+			fmt.Printf("%s\t%s\t%s\n", v.VoiceID, v.Name, v.Category)
+		}
+	}
+}
+
+// historyVoiceUsage totals the history per period, voice and model.
+func historyVoiceUsage(ctx context.Context, client *elevenlabs.Client, voiceID string, after, before time.Time, period func(time.Time) string) ([]voiceUsage, error) {
+	type key struct{ period, voiceID, modelID string }
+	totals := map[key]*voiceUsage{}
+	q := elevenlabs.HistoryQuery{PageSize: 1000, VoiceID: voiceID}
+	for {
+		page, err := client.History(ctx, q)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list history: %w", err)
+		}
+		done := !page.HasMore || len(page.History) == 0
+		for _, item := range page.History {
+			t := time.Unix(item.DateUnix, 0)
+			// History is newest first.
+			if t.Before(after) {
+				done = true
+				break
+			}
+			if !before.IsZero() && !t.Before(before) {
+				continue
+			}
+			k := key{period(t.Local()), item.VoiceID, item.ModelID}
+			u := totals[k]
+			if u == nil {
+				u = &voiceUsage{Period: k.period, VoiceID: item.VoiceID, VoiceName: item.VoiceName, ModelID: item.ModelID}
+				totals[k] = u
+			}
+			u.Generations++
+			u.Characters += max(item.CharacterCountChangeTo-item.CharacterCountChangeFrom, 0)
+		}
+		if done {
+			break
+		}
+		q.StartAfter = page.LastHistoryItemID
+	}
+
+	usage := make([]voiceUsage, 0, len(totals))
+	for _, u := range totals {
+		usage = append(usage, *u)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		a, b := usage[i], usage[j]
+		if a.Period != b.Period {
+			return a.Period < b.Period
+		}
+		if a.Characters != b.Characters {
+			return a.Characters > b.Characters
+		}
+		return a.VoiceName+a.ModelID < b.VoiceName+b.ModelID
+	})
+	return usage, nil
+}
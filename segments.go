@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// segment is one entry of a --segments file. Pause inserts silence before
+// the text and PauseAfter after it; a segment may be a pause alone.
+type segment struct {
+	Text       string   `json:"text,omitempty"`
+	Voice      string   `json:"voice,omitempty"`
+	Pause      duration `json:"pause,omitempty"`
+	PauseAfter duration `json:"pause_after,omitempty"`
+}
+
+// parseSegments accepts either {"segments": [...]} or a bare array.
+func parseSegments(b []byte) ([]segment, error) {
+	var doc struct {
+		Segments []segment `json:"segments"`
+	}
+	if err := json.Unmarshal(b, &doc); err == nil && doc.Segments != nil {
+		return doc.Segments, nil
+	}
+	var segs []segment
+	if err := json.Unmarshal(b, &segs); err != nil {
+		return nil, fmt.Errorf("invalid segments file: %w", err)
+	}
+	return segs, nil
+}
+
+func segmentsNeedDefaultVoice(segs []segment) bool {
+	for _, s := range segs {
+		if s.Text != "" && s.Voice == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// duration unmarshals from a Go duration string ("1.5s", "500ms") or a
+// number of seconds.
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(b []byte) error {
+	var secs float64
+	if err := json.Unmarshal(b, &secs); err == nil {
+		*d = duration(secs * float64(time.Second))
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("invalid duration: %s", b)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration: %s", s)
+	}
+	*d = duration(v)
+	return nil
+}
+
+func (d duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
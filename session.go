@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+)
+
+const (
+	// sessionMaxAge is how long a session continues; after a longer pause
+	// the next run starts afresh.
+	sessionMaxAge = 2 * time.Hour
+	// sessionContextChars is how much of the session's latest text is sent
+	// as the previous text of the next run.
+	sessionContextChars = 500
+)
+
+var sessionName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ttsSession is the state tts --session keeps between runs, so that
+// separately synthesized messages continue each other's prosody.
+type ttsSession struct {
+	path         string
+	lock         *fileLock
+	Name         string    `json:"name"`
+	VoiceID      string    `json:"voice_id"`
+	Model        string    `json:"model"`
+	RequestIDs   []string  `json:"request_ids,omitempty"`
+	PreviousText string    `json:"previous_text,omitempty"`
+	Updated      time.Time `json:"updated"`
+}
+
+// sessionDir is where sessions are kept: ELEVENLABS_SESSION_DIR, or a
+// directory in the user's cache.
+func sessionDir() string {
+	loadEnv()
+	if dir := os.Getenv("ELEVENLABS_SESSION_DIR"); dir != "" {
+		return dir
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, serviceName, "sessions")
+}
+
+// loadSession locks the named session and reads or starts it.
+func loadSession(ctx context.Context, name string) (*ttsSession, error) {
+	if !sessionName.MatchString(name) {
+		return nil, fmt.Errorf("invalid session name %q (use letters, digits, '.', '-' and '_')", name)
+	}
+	s := &ttsSession{path: filepath.Join(sessionDir(), name+".json"), Name: name}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create session directory: %w", err)
+	}
+	lock, err := lockFile(ctx, s.path, true)
+	if err != nil {
+		return nil, err
+	}
+	s.lock = lock
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		s.release()
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+	if err := json.Unmarshal(b, s); err != nil {
+		s.release()
+		return nil, fmt.Errorf("invalid session %s: %w", s.path, err)
+	}
+	if time.Since(s.Updated) > sessionMaxAge {
+		s.RequestIDs, s.PreviousText = nil, ""
+	}
+	return s, nil
+}
+
+// apply makes the first part of a run continue the session.
+func (s *ttsSession) apply(parts []ttsPart, model string) {
+	for i := range parts {
+		p := &parts[i]
+		if p.Text == "" {
+			continue
+		}
+		if p.PreviousText == "" {
+			p.PreviousText = s.PreviousText
+		}
+		info := elevenlabs.Model(model)
+		if p.VoiceID == s.VoiceID && info.ID == s.Model && info.Stitching {
+			p.PreviousRequestIDs = s.RequestIDs
+		}
+		return
+	}
+}
+
+// record adds a finished run to the session. Its request IDs only carry
+// over while the voice and model stay the same.
+func (s *ttsSession) record(parts []ttsPart, model string, requestIDs []string) {
+	var voiceID string
+	text := s.PreviousText
+	for _, p := range parts {
+		if p.Text != "" {
+			voiceID = p.VoiceID
+			text += " " + p.Text
+		}
+	}
+	model = elevenlabs.Model(model).ID
+	if voiceID != s.VoiceID || model != s.Model {
+		s.RequestIDs = nil
+	}
+	for _, id := range requestIDs {
+		// Audio reused from history has no request ID.
+		if id != "" {
+			s.RequestIDs = append(s.RequestIDs, id)
+		}
+	}
+	if n := len(s.RequestIDs); n > elevenlabs.MaxPreviousRequests {
+		s.RequestIDs = s.RequestIDs[n-elevenlabs.MaxPreviousRequests:]
+	}
+	s.VoiceID, s.Model = voiceID, model
+	s.PreviousText = textTail(strings.Join(strings.Fields(text), " "), sessionContextChars)
+	s.Updated = time.Now().UTC()
+}
+
+// textTail returns the end of text, at most n runes long, starting at a
+// word.
+func textTail(text string, n int) string {
+	r := []rune(text)
+	if len(r) <= n {
+		return text
+	}
+	tail := string(r[len(r)-n:])
+	if i := strings.IndexByte(tail, ' '); i >= 0 {
+		tail = tail[i+1:]
+	}
+	return tail
+}
+
+// release unlocks the session for the next run.
+func (s *ttsSession) release() {
+	s.lock.unlock()
+}
+
+// save writes the session through a temporary file, so an interrupted run
+// never leaves it truncated.
+func (s *ttsSession) save() error {
+	if err := s.lock.check(); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+	"github.com/pink-tools/pink-otel"
+)
+
+const defaultSignedURLListen = "127.0.0.1:8787"
+
+func cmdAgentsSignedURL(args []string) {
+	fs := flag.NewFlagSet("agents signed-url", flag.ExitOnError)
+	jsonOut := fs.Bool("json", false, "Print the URL as JSON")
+	api := addClientFlags(fs)
+	parseArgs(fs, args)
+
+	if fs.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "ERROR: agent ID argument required")
+		os.Exit(1)
+	}
+	agentID := fs.Arg(0)
+
+	u, err := api.newClient().SignedURL(context.Background(), agentID)
+	if err != nil {
+		fail("signed_url_failed", err)
+	}
+	otel.Info("signed_url_issued", map[string]any{"agent_id": agentID})
+
+	if *jsonOut {
+		printJSON(map[string]string{"agent_id": agentID, "signed_url": u})
+		return
+	}
+	fmt.Fprintln(stdout, u)
+}
+
+// signedURLServer issues signed URLs over HTTP for the allowed agents, so
+// browser clients never see the API key.
+type signedURLServer struct {
+	client  *elevenlabs.Client
+	agents  []string
+	origins []string
+}
+
+func (s *signedURLServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if origin := r.Header.Get("Origin"); origin != "" && (slices.Contains(s.origins, origin) || slices.Contains(s.origins, "*")) {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Vary", "Origin")
+	}
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Methods", "GET")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// With a single agent the query parameter is optional.
+	agentID := r.URL.Query().Get("agent_id")
+	if agentID == "" && len(s.agents) == 1 {
+		agentID = s.agents[0]
+	}
+	if !slices.Contains(s.agents, agentID) {
+		otel.Warn("signed_url_refused", map[string]any{"agent_id": agentID, "remote": r.RemoteAddr})
+		http.Error(w, "unknown agent", http.StatusForbidden)
+		return
+	}
+
+	u, err := s.client.SignedURL(r.Context(), agentID)
+	if err != nil {
+		otel.Error("signed_url_failed", map[string]any{"agent_id": agentID, "error": err.Error()})
+		http.Error(w, "failed to get signed URL", http.StatusBadGateway)
+		return
+	}
+	otel.Info("signed_url_issued", map[string]any{"agent_id": agentID, "remote": r.RemoteAddr})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]string{"signed_url": u})
+}
+
+func cmdAgentsServe(args []string) {
+	fs := flag.NewFlagSet("agents serve", flag.ExitOnError)
+	listen := fs.String("listen", defaultSignedURLListen, "Address to listen on")
+	var agents, origins listFlag
+	fs.Var(&agents, "agent", "Agent ID to issue signed URLs for, repeatable (required)")
+	fs.Var(&origins, "allow-origin", "Origin allowed by CORS, repeatable (* for any)")
+	api := addClientFlags(fs)
+	parseArgs(fs, args)
+
+	if len(agents) == 0 {
+		fmt.Fprintln(os.Stderr, "ERROR: --agent required")
+		os.Exit(1)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/signed-url", &signedURLServer{client: api.newClient(), agents: agents, origins: origins})
+	srv := &http.Server{Addr: *listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+
+	otel.Info("signed_url_server_start", map[string]any{"listen": *listen, "agents": len(agents)})
+	fmt.Fprintf(os.Stderr, "Serving signed URLs on http://%s/signed-url\n", *listen)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+}
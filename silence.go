@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pink-tools/pink-elevenlabs/audio"
+	"github.com/pink-tools/pink-otel"
+)
+
+func cmdSilence(args []string) {
+	fs := flag.NewFlagSet("silence", flag.ExitOnError)
+
+	dur := fs.Duration("duration", time.Second, "Length of the silence")
+	fs.DurationVar(dur, "t", time.Second, "Length of the silence")
+
+	output := fs.String("output", "", "Output file path")
+	fs.StringVar(output, "o", "", "Output file path")
+
+	outputDir := fs.String("output-dir", "", "Directory for default output names")
+	fs.StringVar(outputDir, "d", "", "Directory for default output names")
+
+	format := fs.String("format", "opus", "Output format: opus, mp3, pcm, ulaw, alaw or an API format")
+	fs.StringVar(format, "f", "opus", "Output format")
+	sampleRate := fs.Int("sample-rate", 0, "Sample rate in Hz (default: the format's)")
+	bitrate := fs.Int("bitrate", 0, "Bitrate in kbps for mp3 and opus (default: the format's)")
+
+	parseArgs(fs, args)
+
+	if *dur <= 0 {
+		fmt.Fprintln(os.Stderr, "ERROR: --duration must be positive")
+		os.Exit(1)
+	}
+	f, err := resolveFormat(*format, *sampleRate, *bitrate)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	af, err := audio.ParseFormat(f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	outputPath, generated := resolveOutput(*output, *outputDir, outputName{Prefix: "silence", Format: f, Ext: formatExtension(f), Seed: f + dur.String()})
+
+	outFile, err := createOutput(outputPath)
+	if err == nil {
+		err = audio.WriteSilence(outFile, af, *dur)
+		if cerr := outFile.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		if generated {
+			os.Remove(outputPath)
+		}
+		fail("silence_failed", err)
+	}
+
+	otel.Info("silence_complete", map[string]any{"output": outputPath, "duration_ms": dur.Milliseconds()})
+	printPath(outputPath)
+}
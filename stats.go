@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+)
+
+// speechCharsPerSecond is a rough narration pace (about 150 words per
+// minute) used to estimate audio duration at speed 1.0.
+const speechCharsPerSecond = 15.0
+
+type modelStats struct {
+	Model    string  `json:"model_id"`
+	MaxChars int     `json:"max_chars"`
+	Chunks   int     `json:"chunks"`
+	Credits  float64 `json:"credits"`
+}
+
+type textStats struct {
+	RawChars          int          `json:"raw_chars"`
+	Chars             int          `json:"chars"`
+	Words             int          `json:"words"`
+	Paragraphs        int          `json:"paragraphs"`
+	EstimatedDuration float64      `json:"estimated_duration_sec"`
+	Models            []modelStats `json:"models"`
+}
+
+func computeStats(raw string, modelIDs []string, speed float64) textStats {
+	norm := elevenlabs.NormalizeText(raw)
+	st := textStats{
+		RawChars: utf8.RuneCountInString(raw),
+		Chars:    utf8.RuneCountInString(norm),
+		Words:    len(strings.Fields(norm)),
+	}
+	for _, p := range strings.Split(norm, "\n\n") {
+		if strings.TrimSpace(p) != "" {
+			st.Paragraphs++
+		}
+	}
+	if speed <= 0 {
+		speed = defaultSpeed
+	}
+	st.EstimatedDuration = math.Round(float64(st.Chars) / speechCharsPerSecond / speed)
+
+	for _, id := range modelIDs {
+		m := elevenlabs.Model(id)
+		st.Models = append(st.Models, modelStats{
+			Model:    m.ID,
+			MaxChars: m.MaxChars,
+			Chunks:   len(elevenlabs.SplitText(norm, m.MaxChars)),
+			Credits:  math.Ceil(float64(st.Chars) * m.CreditsPerChar),
+		})
+	}
+	return st
+}
+
+func cmdStats(args []string) {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+
+	model := fs.String("model", "", "Only report this model (default: all known models)")
+	fs.StringVar(model, "m", "", "Only report this model")
+	speed := fs.Float64("speed", defaultSpeed, "Speech speed for the duration estimate")
+	jsonOut := fs.Bool("json", false, "Print stats as JSON")
+
+	parseArgs(fs, args)
+
+	if fs.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "ERROR: Input file argument required (- for stdin)")
+		os.Exit(1)
+	}
+	b, err := readInputFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	var ids []string
+	if *model != "" {
+		ids = []string{*model}
+	} else {
+		for _, m := range elevenlabs.KnownModels() {
+			ids = append(ids, m.ID)
+		}
+	}
+
+	st := computeStats(string(b), ids, *speed)
+
+	if *jsonOut {
+		printJSON(st)
+		return
+	}
+
+	fmt.Printf("Characters:  %d (raw %d)\n", st.Chars, st.RawChars)
+	fmt.Printf("Words:       %d\n", st.Words)
+	fmt.Printf("Paragraphs:  %d\n", st.Paragraphs)
+	fmt.Printf("Duration:    ~%s at speed %.2g\n\n", time.Duration(st.EstimatedDuration)*time.Second, *speed)
+	fmt.Printf("%-24s %8s %7s %10s\n", "Model", "Limit", "Chunks", "Credits")
+	for _, m := range st.Models {
+		fmt.Printf("%-24s %8d %7d %10.0f\n", m.Model, m.MaxChars, m.Chunks, m.Credits)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// stdout is the real standard output, reserved in machine modes.
+var stdout = os.Stdout
+
+// claimStdout reserves stdout for machine-readable output by pointing
+// os.Stdout, and so child processes, at stderr.
+func claimStdout() {
+	os.Stdout = os.Stderr
+}
+
+// machineMode reports whether fs selected --json or -o -.
+func machineMode(fs *flag.FlagSet) bool {
+	if f := fs.Lookup("json"); f != nil && f.Value.String() == "true" {
+		return true
+	}
+	if f := fs.Lookup("output"); f != nil && f.Value.String() == "-" {
+		return true
+	}
+	return false
+}
+
+// printJSON prints v indented. An object gets a "warnings" array when
+// warnings were reported while producing it; for anything else they are
+// printed on stderr as a {"warnings": [...]} line.
+func printJSON(v any) {
+	out, _ := json.Marshal(v)
+	if ws := takeWarnings(); len(ws) > 0 {
+		list, _ := json.Marshal(ws)
+		if bytes.HasPrefix(out, []byte("{")) {
+			out = out[:len(out)-1]
+			if len(out) > 1 {
+				out = append(out, ',')
+			}
+			out = append(append(append(out, `"warnings":`...), list...), '}')
+		} else {
+			fmt.Fprintf(os.Stderr, "{\"warnings\":%s}\n", list)
+		}
+	}
+	var buf bytes.Buffer
+	json.Indent(&buf, out, "", "  ")
+	fmt.Fprintln(stdout, buf.String())
+}
+
+// printPath prints the path of a written output file, unless the output went
+// to stdout itself.
+func printPath(path string) {
+	if path != "-" {
+		fmt.Fprintln(stdout, path)
+	}
+}
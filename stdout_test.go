@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var fakeAudio = []byte("ID3\x04fake mp3 audio")
+
+// fakeTTS serves text to speech with fakeAudio.
+func fakeTTS(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		if !strings.HasPrefix(r.URL.Path, "/text-to-speech/") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "audio/mpeg")
+		w.Header().Set("x-character-count", "5")
+		w.Write(fakeAudio)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func ttsEnv(srv *httptest.Server) []string {
+	return []string{
+		"ELEVENLABS_API_KEY=key",
+		"ELEVENLABS_TTS_VOICE_ID=voice",
+		"ELEVENLABS_BASE_URL=" + srv.URL,
+	}
+}
+
+func TestTTSJSONStdout(t *testing.T) {
+	srv := fakeTTS(t)
+	out := filepath.Join(t.TempDir(), "out.mp3")
+	stdout, stderr, code := runMain(t, ttsEnv(srv), "tts", "-f", "mp3", "--speed", "5", "--json", "-o", out, "Hello")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+
+	var report struct {
+		Output   string    `json:"output"`
+		Warnings []warning `json:"warnings"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(stdout))
+	if err := dec.Decode(&report); err != nil {
+		t.Fatalf("stdout isn't JSON: %v\n%s", err, stdout)
+	}
+	if rest, _ := io.ReadAll(dec.Buffered()); len(bytes.TrimSpace(rest)) > 0 {
+		t.Errorf("stdout has more than the JSON: %q", rest)
+	}
+	if report.Output != out {
+		t.Errorf("output = %q, want %q", report.Output, out)
+	}
+	if len(report.Warnings) != 1 || report.Warnings[0].Code != "setting_clamped" {
+		t.Errorf("warnings = %+v, want setting_clamped", report.Warnings)
+	}
+	if !bytes.Contains(stderr, []byte("WARNING: --speed 5")) {
+		t.Errorf("warning isn't on stderr: %s", stderr)
+	}
+}
+
+func TestTTSAudioStdout(t *testing.T) {
+	srv := fakeTTS(t)
+	stdout, stderr, code := runMain(t, ttsEnv(srv), "tts", "-f", "mp3", "--speed", "5", "-o", "-", "Hello")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if !bytes.Equal(stdout, fakeAudio) {
+		t.Errorf("stdout = %q, want only the audio %q", stdout, fakeAudio)
+	}
+	if !bytes.Contains(stderr, []byte("WARNING:")) {
+		t.Errorf("warning isn't on stderr: %s", stderr)
+	}
+}
+
+func TestSilenceAudioStdout(t *testing.T) {
+	stdout, stderr, code := runMain(t, nil, "silence", "-t", "100ms", "-f", "pcm_16000", "-o", "-")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	// 100ms of 16-bit mono at 16kHz.
+	if len(stdout) != 3200 || bytes.Count(stdout, []byte{0}) != 3200 {
+		t.Errorf("stdout is %d bytes, want 3200 bytes of silence", len(stdout))
+	}
+}
+
+// captureStdio runs f with os.Stdout, os.Stderr and stdout redirected, and
+// returns what was written to the real stdout and to stderr.
+func captureStdio(t *testing.T, f func()) (string, string) {
+	t.Helper()
+	outR, outW, _ := os.Pipe()
+	errR, errW, _ := os.Pipe()
+	origStdout, origStderr, origSaved := os.Stdout, os.Stderr, stdout
+	os.Stdout, os.Stderr, stdout = outW, errW, outW
+	defer func() { os.Stdout, os.Stderr, stdout = origStdout, origStderr, origSaved }()
+
+	f()
+	outW.Close()
+	errW.Close()
+	o, _ := io.ReadAll(outR)
+	e, _ := io.ReadAll(errR)
+	return string(o), string(e)
+}
+
+func TestClaimStdout(t *testing.T) {
+	o, e := captureStdio(t, func() {
+		claimStdout()
+		fmt.Println("chatter")
+		printJSON(map[string]int{"n": 1})
+	})
+	if o != "{\n  \"n\": 1\n}\n" {
+		t.Errorf("stdout = %q, want only the JSON", o)
+	}
+	if e != "chatter\n" {
+		t.Errorf("stderr = %q, want the chatter", e)
+	}
+}
+
+func TestPrintJSONWarnings(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+		warn bool
+		want string
+		// stderr is the warnings line printed for a non-object.
+		stderr bool
+	}{
+		{"object", map[string]int{"n": 1}, true,
+			`{"n":1,"warnings":[{"code":"test","message":"careful"}]}`, false},
+		{"empty object", struct{}{}, true,
+			`{"warnings":[{"code":"test","message":"careful"}]}`, false},
+		{"object without warnings", map[string]int{"n": 1}, false, `{"n":1}`, false},
+		{"array", []int{1, 2}, true, `[1,2]`, true},
+		{"array without warnings", []int{1, 2}, false, `[1,2]`, false},
+		{"string", "s", true, `"s"`, true},
+		{"null", nil, true, `null`, true},
+	}
+	keepWarnings = true
+	defer func() { keepWarnings = false }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			takeWarnings()
+			o, e := captureStdio(t, func() {
+				if tt.warn {
+					warn("test", nil, "careful")
+				}
+				printJSON(tt.v)
+			})
+			var got, want any
+			if err := json.Unmarshal([]byte(o), &got); err != nil {
+				t.Fatalf("invalid JSON %q: %v", o, err)
+			}
+			json.Unmarshal([]byte(tt.want), &want)
+			if g, w := fmt.Sprint(got), fmt.Sprint(want); g != w {
+				t.Errorf("printJSON = %s, want %s", o, tt.want)
+			}
+			line := `{"warnings":[{"code":"test","message":"careful"}]}` + "\n"
+			if strings.Contains(e, line) != tt.stderr {
+				t.Errorf("stderr = %q, want the warnings line: %v", e, tt.stderr)
+			}
+			if left := takeWarnings(); len(left) > 0 {
+				t.Errorf("%d warnings left after printJSON", len(left))
+			}
+		})
+	}
+}
+
+func TestWarningsKeptOnlyForJSON(t *testing.T) {
+	captureStdio(t, func() {
+		warn("test", nil, "careful")
+	})
+	if ws := takeWarnings(); len(ws) > 0 {
+		t.Errorf("kept %d warnings without --json", len(ws))
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pink-tools/pink-elevenlabs/audio"
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+)
+
+// stemPath names the stem of voiceID beside the mixed output:
+// dialogue.mp3 gets dialogue-<voice>.mp3.
+func stemPath(output, voiceID string) string {
+	ext := filepath.Ext(output)
+	return strings.TrimSuffix(output, ext) + "-" + safeName(voiceID, maxNamePart) + ext
+}
+
+// partVoices returns the distinct voices of parts in order of appearance.
+func partVoices(parts []ttsPart) []string {
+	var voices []string
+	seen := map[string]bool{}
+	for _, p := range parts {
+		if p.Text != "" && !seen[p.VoiceID] {
+			seen[p.VoiceID] = true
+			voices = append(voices, p.VoiceID)
+		}
+	}
+	return voices
+}
+
+// stemFiles holds the open stem files of a --stems run.
+type stemFiles map[string]*os.File
+
+// createStems creates one stem file per voice of parts.
+func createStems(output string, parts []ttsPart) (stemFiles, error) {
+	files := stemFiles{}
+	generated := recordedOutputs(filepath.Dir(output))[filepath.Base(output)]
+	for _, v := range partVoices(parts) {
+		path := stemPath(output, v)
+		f, err := createOutput(path)
+		if err != nil {
+			files.close()
+			files.remove()
+			return nil, err
+		}
+		files[v] = f
+		if generated {
+			recordOutput(path)
+		}
+	}
+	return files, nil
+}
+
+func (s stemFiles) writers() map[string]io.Writer {
+	w := make(map[string]io.Writer, len(s))
+	for v, f := range s {
+		w[v] = f
+	}
+	return w
+}
+
+func (s stemFiles) close() error {
+	var first error
+	for _, f := range s {
+		if err := f.Close(); err != nil && first == nil {
+			first = fmt.Errorf("failed to write stem: %w", err)
+		}
+	}
+	return first
+}
+
+func (s stemFiles) remove() {
+	for _, f := range s {
+		os.Remove(f.Name())
+	}
+}
+
+// stems writes one stream per voice, aligned with the mix.
+type stems struct {
+	format  audio.Format
+	joiners map[string]audio.Joiner
+}
+
+func newStems(ws map[string]io.Writer, f audio.Format, tags audio.Tags) (*stems, error) {
+	s := &stems{format: f, joiners: map[string]audio.Joiner{}}
+	for v, w := range ws {
+		j, err := audio.NewJoiner(w, f, tags)
+		if err != nil {
+			return nil, err
+		}
+		s.joiners[v] = j
+	}
+	return s, nil
+}
+
+func (s *stems) silence(d time.Duration) error {
+	for _, j := range s.joiners {
+		if err := j.AppendSilence(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chunk appends the audio of voiceID, which must be complete, to the mix
+// and to its stem, and as much silence to the other stems.
+func (s *stems) chunk(mix audio.Joiner, voiceID string, resp *elevenlabs.Audio) error {
+	defer resp.Close()
+	b, err := io.ReadAll(resp)
+	if err != nil {
+		return fmt.Errorf("failed to read audio: %w", err)
+	}
+	d, err := audio.Duration(s.format, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("failed to measure chunk: %w", err)
+	}
+	if err := mix.Append(bytes.NewReader(b)); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	for v, j := range s.joiners {
+		if v == voiceID {
+			err = j.Append(bytes.NewReader(b))
+		} else {
+			err = j.AppendSilence(d)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write stem: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *stems) close() error {
+	for _, j := range s.joiners {
+		if err := j.Close(); err != nil {
+			return fmt.Errorf("failed to write stem: %w", err)
+		}
+	}
+	return nil
+}
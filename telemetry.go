@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pink-tools/pink-otel"
+)
+
+var telemetryOnce sync.Once
+
+// initTelemetry starts otel once, adding the profile's and attrs' resource
+// attributes.
+func initTelemetry(attrs map[string]string) {
+	telemetryOnce.Do(func() {
+		loadEnv()
+		merged := parseResourceAttributes(os.Getenv("OTEL_RESOURCE_ATTRIBUTES"))
+		if activeProfile != nil {
+			for k, v := range activeProfile.OtelAttributes {
+				merged[k] = v
+			}
+		}
+		for k, v := range attrs {
+			merged[k] = v
+		}
+		if len(merged) > 0 {
+			os.Setenv("OTEL_RESOURCE_ATTRIBUTES", formatResourceAttributes(merged))
+		}
+		otel.Init(serviceName)
+	})
+}
+
+// parseResourceAttributes parses the W3C Baggage-like "k1=v1,k2=v2" syntax
+// of OTEL_RESOURCE_ATTRIBUTES, whose values are percent-encoded.
+func parseResourceAttributes(s string) map[string]string {
+	attrs := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			continue
+		}
+		v = strings.TrimSpace(v)
+		if dec, err := url.PathUnescape(v); err == nil {
+			v = dec
+		}
+		attrs[k] = v
+	}
+	return attrs
+}
+
+func formatResourceAttributes(attrs map[string]string) string {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + url.PathEscape(attrs[k])
+	}
+	return strings.Join(pairs, ",")
+}
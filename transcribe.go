@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pink-tools/pink-elevenlabs/audio"
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+	"github.com/pink-tools/pink-otel"
+)
+
+// transcribePreflight is what a transcription will cost before the audio is
+// uploaded.
+type transcribePreflight struct {
+	Input               string  `json:"input"`
+	Model               string  `json:"model_id"`
+	DurationSec         float64 `json:"duration_sec"`
+	BilledMinutes       int     `json:"billed_minutes"`
+	Credits             float64 `json:"credits"`
+	EstimatedProcessing float64 `json:"estimated_processing_sec"`
+}
+
+func newTranscribePreflight(input, model string, d time.Duration) transcribePreflight {
+	m := elevenlabs.STTModel(model)
+	minutes := int((d + time.Minute - 1) / time.Minute)
+	return transcribePreflight{
+		Input:               input,
+		Model:               m.ID,
+		DurationSec:         math.Round(d.Seconds()*10) / 10,
+		BilledMinutes:       minutes,
+		Credits:             math.Ceil(float64(minutes) * m.CreditsPerMinute),
+		EstimatedProcessing: math.Ceil(d.Seconds() / m.Speed),
+	}
+}
+
+func (p transcribePreflight) print(w io.Writer) {
+	fmt.Fprintf(w, "Input:       %s\n", p.Input)
+	fmt.Fprintf(w, "Duration:    %s (%d billed minutes)\n", time.Duration(p.DurationSec*float64(time.Second)).Round(time.Second), p.BilledMinutes)
+	fmt.Fprintf(w, "Model:       %s\n", p.Model)
+	fmt.Fprintf(w, "Credits:     ~%.0f\n", p.Credits)
+	fmt.Fprintf(w, "Processing:  ~%s\n", time.Duration(p.EstimatedProcessing)*time.Second)
+}
+
+// probeDuration measures the playing time of an audio or video file: WAV,
+// MP3 and Ogg Opus directly, anything else with ffprobe.
+func probeDuration(path string) (time.Duration, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open input file: %w", err)
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".wav":
+		if wav, err := audio.ReadWAVHeader(f); err == nil {
+			return wav.Duration(), nil
+		}
+	case ".mp3":
+		if d, err := audio.Duration(audio.Format{Codec: audio.MP3}, f); err == nil && d > 0 {
+			return d, nil
+		}
+	case ".ogg", ".opus":
+		if d, err := audio.Duration(audio.Format{Codec: audio.Opus}, f); err == nil {
+			return d, nil
+		}
+	}
+
+	ffprobe, err := exec.LookPath("ffprobe")
+	if err != nil {
+		return 0, fmt.Errorf("can't measure %s without ffprobe", filepath.Base(path))
+	}
+	out, err := exec.Command(ffprobe, "-v", "error", "-show_entries", "format=duration", "-of", "default=nw=1:nk=1", path).Output()
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe failed on %s: %w", path, err)
+	}
+	secs, err := strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe reported no duration for %s", path)
+	}
+	return time.Duration(secs * float64(time.Second)), nil
+}
+
+func cmdTranscribe(args []string) {
+	fs := flag.NewFlagSet("transcribe", flag.ExitOnError)
+
+	output := fs.String("output", "", "Write the transcript here instead of stdout")
+	fs.StringVar(output, "o", "", "Write the transcript here")
+	outputDir := fs.String("output-dir", "", "Directory for default output names")
+	fs.StringVar(outputDir, "d", "", "Directory for default output names")
+
+	model := fs.String("model", "", "Model ID (default: "+elevenlabs.DefaultSTTModel+")")
+	fs.StringVar(model, "m", "", "Model ID")
+	language := fs.String("language", "", "Language code (default: detect)")
+	fs.StringVar(language, "l", "", "Language code")
+	diarize := fs.Bool("diarize", false, "Label words with their speaker")
+	tagEvents := fs.Bool("tag-audio-events", false, "Mark sounds such as (laughter) in the text")
+
+	dryRun := fs.Bool("dry-run", false, "Only report duration, credits and processing time")
+	maxDuration := fs.Duration("max-duration", 0, "Refuse inputs longer than this (0: no limit)")
+	jsonOut := fs.Bool("json", false, "Print the full transcript (or the --dry-run report) as JSON")
+
+	api := addClientFlags(fs)
+
+	parseArgs(fs, args)
+
+	if fs.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "ERROR: Input file argument required")
+		os.Exit(1)
+	}
+	input := fs.Arg(0)
+
+	d, err := probeDuration(input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	pre := newTranscribePreflight(input, *model, d)
+	switch {
+	case *dryRun && *jsonOut:
+		printJSON(pre)
+	case *dryRun:
+		pre.print(os.Stdout)
+	default:
+		pre.print(os.Stderr)
+	}
+	if *maxDuration > 0 && d > *maxDuration {
+		fmt.Fprintf(os.Stderr, "ERROR: %s is %s long, over --max-duration %s\n", input, d.Round(time.Second), *maxDuration)
+		os.Exit(1)
+	}
+	if *dryRun {
+		return
+	}
+
+	f, err := os.Open(input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: Input file not found: %s\n", input)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	otel.Info("transcribe_request", map[string]any{
+		"input":        input,
+		"model":        pre.Model,
+		"duration_sec": pre.DurationSec,
+	})
+	t, err := api.newClient().SpeechToText(context.Background(), elevenlabs.STTRequest{
+		Audio:          f,
+		Filename:       filepath.Base(input),
+		ModelID:        *model,
+		LanguageCode:   *language,
+		Diarize:        *diarize,
+		TagAudioEvents: *tagEvents,
+	})
+	if err != nil {
+		fail("transcribe_failed", err)
+	}
+	otel.Info("transcribe_complete", map[string]any{"input": input, "language": t.LanguageCode, "text_len": len(t.Text)})
+
+	if *output == "" && *outputDir == "" {
+		if *jsonOut {
+			printJSON(t)
+		} else {
+			fmt.Fprintln(stdout, t.Text)
+		}
+		return
+	}
+
+	body, ext := []byte(t.Text+"\n"), ".txt"
+	if *jsonOut {
+		body, _ = json.MarshalIndent(t, "", "  ")
+		ext = ".json"
+	}
+	path, generated := resolveOutput(*output, *outputDir, outputName{Prefix: "transcript", Input: input, Ext: ext, Seed: input})
+	if err := writeOutput(path, bytes.NewReader(body)); err != nil {
+		if generated {
+			os.Remove(path)
+		}
+		fail("transcribe_failed", err)
+	}
+	printPath(path)
+}
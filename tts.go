@@ -0,0 +1,561 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pink-tools/pink-elevenlabs/audio"
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+	"github.com/pink-tools/pink-otel"
+)
+
+func cmdTTS(args []string) {
+	fs := flag.NewFlagSet("tts", flag.ExitOnError)
+
+	out := addOutputFlags(fs)
+
+	input := fs.String("input", "", "Read text from file (- for stdin)")
+	fs.StringVar(input, "i", "", "Read text from file")
+	segmentsFile := fs.String("segments", "", "Read JSON segments with pauses from file (- for stdin)")
+	fromURL := fs.String("from-url", "", "Read the article of a web page")
+	clipboard := fs.Bool("clipboard", false, "Read the text from the system clipboard and play it")
+
+	voice := fs.String("voice", "", "Voice ID")
+	fs.StringVar(voice, "v", "", "Voice ID")
+
+	model := fs.String("model", "", "Model ID (default: "+elevenlabs.DefaultTTSModel+", realtime: "+elevenlabs.DefaultRealtimeModel+")")
+	fs.StringVar(model, "m", "", "Model ID")
+
+	settings := addSettingsFlags(fs)
+
+	chunkSize := fs.Int("chunk-size", 0, "Max characters per request (default: model limit)")
+	continuity := fs.Bool("continuity", false, "Send neighbouring chunk text for smoother prosody")
+	realtime := fs.Bool("realtime", false, "Stream text from stdin (or --input) over a websocket as it arrives")
+	progressMode := fs.String("progress", "", "Report each finished chunk on stderr: text or jsonl")
+	stems := fs.Bool("stems", false, "Also write one file per voice, aligned with the mixed output")
+	keepPartial := fs.Bool("keep-partial", false, "Keep finished chunks and a resume manifest if the run is cancelled or fails")
+	resume := fs.String("resume", "", "Resume a run kept with --keep-partial from its .partial directory")
+	reuseHistory := fs.Bool("reuse-history", false, "Download identical text/voice/model generations from history instead of synthesizing")
+	sessionFlag := fs.String("session", "", "Continue the prosody of earlier runs in this named session")
+
+	api := addClientFlags(fs)
+	addJobFlag(fs)
+
+	parseArgs(fs, args)
+
+	if err := checkProgressMode(*progressMode); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		exit(1)
+	}
+
+	if *realtime {
+		if fs.NArg() > 0 || *segmentsFile != "" || *fromURL != "" || *clipboard || *chunkSize > 0 || *continuity || *reuseHistory || *progressMode != "" || *keepPartial || *resume != "" || *stems || *sessionFlag != "" {
+			fmt.Fprintln(os.Stderr, "ERROR: --realtime reads text from stdin or --input and can't be combined with --segments, --from-url, --clipboard, --chunk-size, --continuity, --reuse-history, --progress, --keep-partial, --resume, --stems or --session")
+			exit(1)
+		}
+		voiceID := *voice
+		if voiceID == "" {
+			voiceID = getTTSVoiceID()
+		}
+		cmdRealtime(out, settings, api, voiceID, *model, *input)
+		return
+	}
+
+	if (*keepPartial || *resume != "" || *stems) && *out.output == "-" {
+		fmt.Fprintln(os.Stderr, "ERROR: --keep-partial, --resume and --stems need an output file, not -o -")
+		exit(1)
+	}
+
+	var job ttsJob
+	var voiceID string
+	// Cancelling stops the article fetch or the current chunk; with
+	// --keep-partial the finished chunks are kept for --resume.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *resume != "" {
+		if fs.NArg() > 0 || *input != "" || *segmentsFile != "" || *fromURL != "" || *clipboard {
+			fmt.Fprintln(os.Stderr, "ERROR: --resume takes its text from the manifest, not a text argument, --input, --segments, --from-url or --clipboard")
+			exit(1)
+		}
+		run, err := loadPartialRun(*resume)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			exit(1)
+		}
+		job = run.job()
+		*out.format = job.Format
+		if *out.output == "" {
+			*out.output = run.manifest.Output
+		}
+		out.check()
+	} else {
+		segments, err := readTTSInput(ctx, fs, ttsSources{input: *input, segments: *segmentsFile, url: *fromURL, clipboard: *clipboard})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			exit(1)
+		}
+
+		// The clipboard is for listening right away: play while streaming
+		// unless the audio is being saved somewhere.
+		if *clipboard && *out.output == "" && *out.outputDir == "" {
+			*out.play, *out.stream = true, true
+		}
+
+		voiceID = *voice
+		if voiceID == "" && segmentsNeedDefaultVoice(segments) {
+			voiceID = getTTSVoiceID()
+		}
+
+		out.check()
+
+		limit := *chunkSize
+		if limit <= 0 {
+			limit = elevenlabs.MaxChars(*model)
+		}
+		parts := buildParts(stripAudioTags(segments, *model), voiceID, limit, *continuity)
+		if len(parts) == 0 {
+			fmt.Fprintln(os.Stderr, "ERROR: Text is empty")
+			exit(1)
+		}
+
+		job = ttsJob{
+			Parts:    parts,
+			Model:    *model,
+			Format:   *out.format,
+			Settings: settings.settings(),
+			Tags:     audio.Tags(out.tags),
+		}
+	}
+	job.Stream = *out.stream
+	job.Strict = *out.strict
+	job.Progress = *progressMode
+
+	client := api.newClient()
+	var err error
+	var session *ttsSession
+	if *sessionFlag != "" {
+		if session, err = loadSession(ctx, *sessionFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			exit(1)
+		}
+		session.apply(job.Parts, job.Model)
+	}
+	if *reuseHistory {
+		if job.History, err = loadHistoryIndex(ctx, client); err != nil {
+			fail("tts_failed", err)
+		}
+	}
+
+	outputPath, generated := out.resolve(outputName{Prefix: "speech", Voice: voiceID, Input: inputName(*input, *fromURL), Seed: job.seed()})
+	if *keepPartial && job.Partial == nil {
+		if job.Partial, err = newPartialRun(outputPath, job); err != nil {
+			fail("tts_failed", err)
+		}
+	}
+
+	w, err := out.open(outputPath)
+	if err != nil {
+		fail("tts_failed", err)
+	}
+	var stemOut stemFiles
+	if *stems {
+		if stemOut, err = createStems(outputPath, job.Parts); err != nil {
+			w.Close()
+			fail("tts_failed", err)
+		}
+		job.Stems = stemOut.writers()
+	}
+	res, err := textToSpeech(ctx, client, job, w)
+	if cerr := w.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("failed to write output: %w", cerr)
+	}
+	if cerr := stemOut.close(); err == nil && cerr != nil {
+		err = cerr
+	}
+	if err != nil {
+		if generated {
+			os.Remove(outputPath)
+		}
+		stemOut.remove()
+		if job.Partial != nil {
+			job.Partial.abandon()
+		}
+		fail("tts_failed", err)
+	}
+	if job.Partial != nil {
+		os.RemoveAll(job.Partial.dir)
+	}
+	if session != nil {
+		session.record(job.Parts, job.Model, res.RequestIDs)
+		if err := session.save(); err != nil {
+			warn("tts_session_save_failed", map[string]any{"session": session.Name, "error": err.Error()}, "%v", err)
+		}
+		session.release()
+	}
+	otel.Info("tts_complete", map[string]any{"output": outputPath, "characters": res.Characters, "format": res.Format})
+	*out.format = res.Format
+	out.finish(outputPath, res.Characters, "tts_play_failed")
+
+	var stemPaths []string
+	if *stems {
+		for _, v := range partVoices(job.Parts) {
+			stemPaths = append(stemPaths, stemPath(outputPath, v))
+		}
+	}
+	out.report(outputPath, res.Characters, stemPaths)
+}
+
+// ttsSources are the flags naming where the text comes from, at most one
+// of which may be set, or else the text argument.
+type ttsSources struct {
+	input     string
+	segments  string
+	url       string
+	clipboard bool
+}
+
+func readTTSInput(ctx context.Context, fs *flag.FlagSet, src ttsSources) ([]segment, error) {
+	sources := 0
+	for _, set := range []bool{src.input != "", src.segments != "", src.url != "", src.clipboard, fs.NArg() > 0} {
+		if set {
+			sources++
+		}
+	}
+	switch {
+	case sources > 1:
+		return nil, fmt.Errorf("use only one of a text argument, --input, --segments, --from-url or --clipboard")
+	case src.segments != "":
+		b, err := readInputFile(src.segments)
+		if err != nil {
+			return nil, err
+		}
+		return parseSegments(b)
+	case src.url != "":
+		a, err := fetchArticle(ctx, src.url)
+		if err != nil {
+			return nil, err
+		}
+		text := a.Text()
+		otel.Info("tts_article", map[string]any{"url": src.url, "title": a.Title, "paragraphs": len(a.Paragraphs), "characters": len(text)})
+		fmt.Fprintf(os.Stderr, "Article: %s (%d paragraphs, %d characters)\n", a.Title, len(a.Paragraphs), len(text))
+		return []segment{{Text: text}}, nil
+	case src.clipboard:
+		text, err := readClipboard()
+		if err != nil {
+			return nil, err
+		}
+		return []segment{{Text: text}}, nil
+	case src.input != "":
+		b, err := readInputFile(src.input)
+		if err != nil {
+			return nil, err
+		}
+		return []segment{{Text: string(b)}}, nil
+	case fs.NArg() > 0:
+		return []segment{{Text: fs.Arg(0)}}, nil
+	default:
+		return nil, fmt.Errorf("Text argument, --input, --segments, --from-url or --clipboard required")
+	}
+}
+
+// readInputFile reads a text file, or stdin for "-", as UTF-8.
+func readInputFile(path string) ([]byte, error) {
+	if path == "-" {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stdin: %w", err)
+		}
+		return decodeText(b, "stdin"), nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input file: %w", err)
+	}
+	return decodeText(b, path), nil
+}
+
+// ttsPart is one request to synthesize, or a pause when Text is empty.
+type ttsPart struct {
+	Text         string
+	VoiceID      string
+	PreviousText string
+	NextText     string
+	// PreviousRequestIDs are set on the first part of a --session run.
+	PreviousRequestIDs []string
+	Pause              time.Duration
+}
+
+type ttsJob struct {
+	Parts    []ttsPart
+	Model    string
+	Format   string
+	Settings elevenlabs.VoiceSettings
+	Tags     audio.Tags
+	Stream   bool
+	// Strict fails instead of falling back to a lower format.
+	Strict bool
+	// History, if set, supplies earlier identical generations.
+	History historyIndex
+	// Progress is the --progress mode, empty for none.
+	Progress string
+	// Partial, if set, keeps finished chunks for --resume.
+	Partial *partialRun
+	// Stems, if set, receive one stream per voice alongside the mix.
+	Stems map[string]io.Writer
+}
+
+// seed identifies the job's content for default output naming.
+func (j ttsJob) seed() string {
+	var b strings.Builder
+	for _, p := range j.Parts {
+		fmt.Fprintf(&b, "%s\x00%s\x00%s\x00", p.VoiceID, p.Text, p.Pause)
+	}
+	return b.String()
+}
+
+// audioTag matches a bracketed audio tag such as [laughs] or [long pause],
+// with the space before it.
+var audioTag = regexp.MustCompile(`\s*\[[a-z]+(?: [a-z]+)*\]`)
+
+// stripAudioTags removes audio tags from the segments when model would read
+// them out, with a warning.
+func stripAudioTags(segments []segment, model string) []segment {
+	info := elevenlabs.Model(model)
+	if info.AudioTags {
+		return segments
+	}
+	stripped := 0
+	for i := range segments {
+		n := len(audioTag.FindAllStringIndex(segments[i].Text, -1))
+		if n > 0 {
+			segments[i].Text = strings.TrimSpace(audioTag.ReplaceAllString(segments[i].Text, ""))
+			stripped += n
+		}
+	}
+	if stripped > 0 {
+		warn("audio_tags_stripped", map[string]any{"model": info.ID, "count": stripped},
+			"removed %d audio tags, which %s doesn't support", stripped, info.ID)
+	}
+	return segments
+}
+
+// buildParts splits each segment's text into request-sized chunks. With
+// continuity, each chunk carries its neighbours within the same segment.
+func buildParts(segments []segment, defaultVoice string, limit int, continuity bool) []ttsPart {
+	var parts []ttsPart
+	for _, seg := range segments {
+		if seg.Pause > 0 {
+			parts = append(parts, ttsPart{Pause: time.Duration(seg.Pause)})
+		}
+
+		voiceID := seg.Voice
+		if voiceID == "" {
+			voiceID = defaultVoice
+		}
+		chunks := elevenlabs.SplitText(seg.Text, limit)
+		for i, chunk := range chunks {
+			part := ttsPart{Text: chunk, VoiceID: voiceID}
+			if continuity {
+				if i > 0 {
+					part.PreviousText = chunks[i-1]
+				}
+				if i < len(chunks)-1 {
+					part.NextText = chunks[i+1]
+				}
+			}
+			parts = append(parts, part)
+		}
+
+		if seg.PauseAfter > 0 {
+			parts = append(parts, ttsPart{Pause: time.Duration(seg.PauseAfter)})
+		}
+	}
+	return parts
+}
+
+type ttsResult struct {
+	// Characters is the total billed, or -1 if any response didn't say.
+	Characters int
+	RequestIDs []string
+	// Format is the API format used, after any fallback.
+	Format string
+}
+
+// textToSpeech synthesizes every part and writes a single stream to w.
+func textToSpeech(ctx context.Context, client *elevenlabs.Client, job ttsJob, w io.Writer) (ttsResult, error) {
+	var res ttsResult
+	f, err := apiFormat(job.Format)
+	if err != nil {
+		return res, err
+	}
+
+	textLen, chars, chunks := 0, 0, 0
+	for _, p := range job.Parts {
+		if p.Text != "" {
+			textLen += len(p.Text)
+			chars += utf8.RuneCountInString(p.Text)
+			chunks++
+		}
+	}
+	prog := newProgress(job.Progress, chunks, chars)
+	otel.Info("tts_request", map[string]any{
+		"model":    job.Model,
+		"format":   job.Format,
+		"text_len": textLen,
+		"chunks":   chunks,
+	})
+
+	// The joiner starts with the first response, whose format may have
+	// fallen back; pauses before it are held until then.
+	var joiner audio.Joiner
+	var st *stems
+	var pending []time.Duration
+	pause := func(d time.Duration) error {
+		if err := joiner.AppendSilence(d); err != nil || st == nil {
+			return err
+		}
+		return st.silence(d)
+	}
+	start := func() error {
+		af, err := audio.ParseFormat(f)
+		if err == nil {
+			joiner, err = audio.NewJoiner(w, af, job.Tags)
+		}
+		if err == nil && job.Stems != nil {
+			st, err = newStems(job.Stems, af, job.Tags)
+		}
+		for _, d := range pending {
+			if err != nil {
+				break
+			}
+			err = pause(d)
+		}
+		return err
+	}
+
+	n := 0
+	for i, part := range job.Parts {
+		if part.Text == "" {
+			if joiner == nil {
+				pending = append(pending, part.Pause)
+			} else if err := pause(part.Pause); err != nil {
+				return res, fmt.Errorf("failed to write output: %w", err)
+			}
+			continue
+		}
+		n++
+
+		req := elevenlabs.TTSRequest{
+			Text:               part.Text,
+			ModelID:            job.Model,
+			VoiceSettings:      &job.Settings,
+			PreviousText:       part.PreviousText,
+			NextText:           part.NextText,
+			PreviousRequestIDs: part.PreviousRequestIDs,
+			OutputFormat:       f,
+			Stream:             job.Stream,
+		}
+		otel.Info("tts_chunk", map[string]any{
+			"voice_id": part.VoiceID,
+			"chunk":    n,
+			"text_len": len(part.Text),
+		})
+		var resp *elevenlabs.Audio
+		saved := false
+		if job.Partial != nil {
+			if resp, err = job.Partial.saved(i); err != nil {
+				return res, err
+			}
+			saved = resp != nil
+		}
+		if resp == nil && job.History != nil {
+			resp = job.History.reuse(ctx, client, part, job.Model, f)
+		}
+		switch {
+		case resp != nil:
+			if joiner == nil {
+				if err = start(); err != nil {
+					resp.Close()
+					return res, fmt.Errorf("failed to write output: %w", err)
+				}
+			}
+		case joiner == nil:
+			resp, f, err = withFormatFallback(f, job.Strict, func(format string) (*elevenlabs.Audio, error) {
+				req.OutputFormat = format
+				return client.TextToSpeech(ctx, part.VoiceID, req)
+			})
+			if err == nil {
+				if err = start(); err != nil {
+					resp.Close()
+					return res, fmt.Errorf("failed to write output: %w", err)
+				}
+			}
+		default:
+			resp, err = client.TextToSpeech(ctx, part.VoiceID, req)
+		}
+		keep := job.Partial != nil && !saved
+		if err == nil && keep {
+			resp, err = job.Partial.keep(i, resp, f)
+		}
+		if err == nil {
+			if st != nil {
+				err = st.chunk(joiner, part.VoiceID, resp)
+			} else {
+				err = appendChunk(joiner, resp)
+			}
+		}
+		if err == nil && keep {
+			err = job.Partial.done(i, f)
+		}
+		if err != nil {
+			if chunks > 1 {
+				return res, fmt.Errorf("chunk %d/%d: %w", n, chunks, err)
+			}
+			return res, err
+		}
+		otel.Info("tts_chunk_complete", map[string]any{
+			"chunk":      n,
+			"request_id": resp.RequestID,
+			"characters": resp.CharacterCount,
+		})
+		prog.chunkDone(utf8.RuneCountInString(part.Text))
+		res.RequestIDs = append(res.RequestIDs, resp.RequestID)
+		if resp.CharacterCount < 0 || res.Characters < 0 {
+			res.Characters = -1
+		} else {
+			res.Characters += resp.CharacterCount
+		}
+	}
+
+	if joiner == nil {
+		if err := start(); err != nil {
+			return res, fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+	res.Format = f
+	if err := joiner.Close(); err != nil {
+		return res, fmt.Errorf("failed to write output: %w", err)
+	}
+	if st != nil {
+		return res, st.close()
+	}
+	return res, nil
+}
+
+func appendChunk(joiner audio.Joiner, resp *elevenlabs.Audio) error {
+	defer resp.Close()
+	if err := joiner.Append(resp); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
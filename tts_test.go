@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReadTTSInputURLCancel(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := readTTSInput(ctx, flag.NewFlagSet("tts", flag.ContinueOnError), ttsSources{url: srv.URL})
+	if err == nil || time.Since(start) > 5*time.Second {
+		t.Errorf("err = %v after %v, want cancellation", err, time.Since(start))
+	}
+}
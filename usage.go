@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+type usageReport struct {
+	Tier           string    `json:"tier"`
+	Status         string    `json:"status"`
+	CharacterCount int       `json:"character_count"`
+	CharacterLimit int       `json:"character_limit"`
+	Remaining      int       `json:"characters_remaining"`
+	ResetAt        time.Time `json:"reset_at"`
+}
+
+func cmdUsage(args []string) {
+	fs := flag.NewFlagSet("usage", flag.ExitOnError)
+	jsonOut := fs.Bool("json", false, "Print usage as JSON")
+	api := addClientFlags(fs)
+	parseArgs(fs, args)
+
+	sub, err := api.newClient().Subscription(context.Background())
+	if err != nil {
+		fail("usage_failed", err)
+	}
+
+	r := usageReport{
+		Tier:           sub.Tier,
+		Status:         sub.Status,
+		CharacterCount: sub.CharacterCount,
+		CharacterLimit: sub.CharacterLimit,
+		Remaining:      max(sub.CharacterLimit-sub.CharacterCount, 0),
+		ResetAt:        sub.NextReset().UTC(),
+	}
+
+	if *jsonOut {
+		printJSON(r)
+		return
+	}
+
+	pct := 0.0
+	if r.CharacterLimit > 0 {
+		pct = 100 * float64(r.CharacterCount) / float64(r.CharacterLimit)
+	}
+	fmt.Printf("Tier:       %s (%s)\n", r.Tier, r.Status)
+	fmt.Printf("Characters: %d / %d (%.1f%%)\n", r.CharacterCount, r.CharacterLimit, pct)
+	fmt.Printf("Remaining:  %d\n", r.Remaining)
+	fmt.Printf("Resets:     %s\n", sub.NextReset().Local().Format("2006-01-02 15:04 MST"))
+}
+
+// reportUsage prints characters billed by one command to stderr, keeping
+// stdout for the output path.
+func reportUsage(characters int) {
+	if characters < 0 {
+		fmt.Fprintln(os.Stderr, "Characters used: unknown")
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Characters used: %d\n", characters)
+}
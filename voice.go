@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pink-tools/pink-elevenlabs/audio"
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+	"github.com/pink-tools/pink-otel"
+)
+
+func cmdVoice(args []string) {
+	fs := flag.NewFlagSet("voice", flag.ExitOnError)
+
+	out := addOutputFlags(fs)
+
+	voice := fs.String("voice", "", "Target voice ID")
+	fs.StringVar(voice, "v", "", "Target voice ID")
+
+	model := fs.String("model", "", "Model ID (default: "+elevenlabs.DefaultSTSModel+")")
+	fs.StringVar(model, "m", "", "Model ID")
+
+	settings := addSettingsFlags(fs)
+	removeNoise := fs.Bool("remove-background-noise", false, "Remove background noise from the input")
+	var ranges rangeFlag
+	fs.Var(&ranges, "range", "Convert only this span of a WAV input, e.g. 00:01:10-00:02:30, repeatable")
+	monitor := fs.Bool("monitor", false, "Convert the microphone live and play it, until interrupted")
+	chunk := fs.Duration("chunk", defaultMonitorChunk, "Length of each microphone chunk with --monitor")
+	mic := fs.String("mic", "", "Capture device for --monitor, by index or name (default: ELEVENLABS_MIC or the system's)")
+	gate := fs.Float64("gate", defaultMonitorGate, "With --monitor, skip chunks quieter than this level in dBFS")
+
+	api := addClientFlags(fs)
+	addJobFlag(fs)
+
+	parseArgs(fs, args)
+
+	if *monitor {
+		if fs.NArg() > 0 || len(ranges) > 0 || *out.output != "" || *out.outputDir != "" || len(out.tags) > 0 || *out.qc.enabled || *out.json {
+			fmt.Fprintln(os.Stderr, "ERROR: --monitor converts the microphone and plays it; it can't be combined with an input file, --range, -o, -d, --tag, --qc or --json")
+			os.Exit(1)
+		}
+		if *chunk < 250*time.Millisecond || *chunk > 10*time.Second {
+			fmt.Fprintln(os.Stderr, "ERROR: --chunk must be between 250ms and 10s")
+			os.Exit(1)
+		}
+		voiceID := *voice
+		if voiceID == "" {
+			voiceID = getVoiceChangeID()
+		}
+		if *mic == "" {
+			loadEnv()
+			*mic = os.Getenv("ELEVENLABS_MIC")
+		}
+		device, err := resolveCaptureDevice(*mic)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		*mic = device
+		job := monitorJob{
+			VoiceID:               voiceID,
+			Model:                 *model,
+			RemoveBackgroundNoise: *removeNoise,
+			Chunk:                 *chunk,
+			Gate:                  *gate,
+		}
+		if settings.changed() {
+			s := settings.settings()
+			job.Settings = &s
+		}
+		cmdVoiceMonitor(out, api, job, *mic)
+		return
+	}
+
+	if fs.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "ERROR: Input file argument required")
+		os.Exit(1)
+	}
+
+	inputPath := fs.Arg(0)
+	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "ERROR: Input file not found: %s\n", inputPath)
+		os.Exit(1)
+	}
+
+	voiceID := *voice
+	if voiceID == "" {
+		voiceID = getVoiceChangeID()
+	}
+
+	if len(ranges) > 0 {
+		// The spliced output is WAV like the input, whatever -f says.
+		if len(out.tags) > 0 {
+			fmt.Fprintln(os.Stderr, "ERROR: --tag is not supported with --range, which writes WAV")
+			os.Exit(1)
+		}
+		*out.format = wavFormat
+		out.checkOutput()
+	} else {
+		out.check()
+	}
+
+	job := voiceJob{
+		Input:                 inputPath,
+		VoiceID:               voiceID,
+		Model:                 *model,
+		Format:                *out.format,
+		Tags:                  audio.Tags(out.tags),
+		RemoveBackgroundNoise: *removeNoise,
+		Stream:                *out.stream,
+		Strict:                *out.strict,
+		Ranges:                ranges,
+	}
+	// Without explicit settings the voice's own stored settings apply.
+	if settings.changed() {
+		s := settings.settings()
+		job.Settings = &s
+	}
+
+	name := outputName{Prefix: "voice", Voice: voiceID, Input: inputPath, Seed: voiceID + "\x00" + inputPath}
+	var outputPath string
+	var generated bool
+	if len(ranges) > 0 {
+		name.Format, name.Ext = wavFormat, ".wav"
+		name.Seed += "\x00" + ranges.String()
+		outputPath, generated = resolveOutput(*out.output, *out.outputDir, name)
+	} else {
+		outputPath, generated = out.resolve(name)
+	}
+
+	w, err := out.open(outputPath)
+	if err != nil {
+		fail("voice_change_failed", err)
+	}
+	change := voiceChange
+	if len(ranges) > 0 {
+		change = voiceChangeRanges
+	}
+	res, err := change(context.Background(), api.newClient(), job, w)
+	if cerr := w.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("failed to write output: %w", cerr)
+	}
+	if err != nil {
+		if generated {
+			os.Remove(outputPath)
+		}
+		fail("voice_change_failed", err)
+	}
+	otel.Info("voice_change_complete", map[string]any{"output": outputPath, "characters": res.Characters, "format": res.Format})
+	*out.format = res.Format
+	out.finish(outputPath, res.Characters, "voice_play_failed")
+
+	out.report(outputPath, res.Characters, nil)
+}
+
+type voiceJob struct {
+	Input                 string
+	VoiceID               string
+	Model                 string
+	Format                string
+	Settings              *elevenlabs.VoiceSettings
+	Tags                  audio.Tags
+	RemoveBackgroundNoise bool
+	Stream                bool
+	Strict                bool
+	// Ranges, if set, are the only spans converted; see voiceChangeRanges.
+	Ranges []timeRange
+}
+
+type voiceResult struct {
+	// Characters is the number billed, or -1 if the API didn't report it.
+	Characters int
+	// Format is the API format used, after any fallback.
+	Format string
+}
+
+// voiceChange converts the job's input and writes it to w.
+func voiceChange(ctx context.Context, client *elevenlabs.Client, job voiceJob, w io.Writer) (voiceResult, error) {
+	var res voiceResult
+	f, err := apiFormat(job.Format)
+	if err != nil {
+		return res, err
+	}
+
+	inputFile, err := os.Open(job.Input)
+	if err != nil {
+		return res, fmt.Errorf("failed to open input file: %w", err)
+	}
+	defer inputFile.Close()
+
+	otel.Info("voice_change_request", map[string]any{
+		"voice_id": job.VoiceID,
+		"model":    job.Model,
+		"format":   job.Format,
+		"input":    job.Input,
+		"stream":   job.Stream,
+	})
+
+	resp, f, err := withFormatFallback(f, job.Strict, func(format string) (*elevenlabs.Audio, error) {
+		if _, err := inputFile.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to read input file: %w", err)
+		}
+		return client.SpeechToSpeech(ctx, job.VoiceID, elevenlabs.STSRequest{
+			Audio:                 inputFile,
+			Filename:              filepath.Base(job.Input),
+			ModelID:               job.Model,
+			VoiceSettings:         job.Settings,
+			RemoveBackgroundNoise: job.RemoveBackgroundNoise,
+			OutputFormat:          format,
+			Stream:                job.Stream,
+		})
+	})
+	if err != nil {
+		return res, err
+	}
+	defer resp.Close()
+
+	af, err := audio.ParseFormat(f)
+	if err != nil {
+		return res, err
+	}
+	// The joiner writes tags the same way tts does; with one part it
+	// otherwise passes the audio through.
+	joiner, err := audio.NewJoiner(w, af, job.Tags)
+	if err != nil {
+		return res, err
+	}
+	if err := joiner.Append(resp); err != nil {
+		return res, fmt.Errorf("failed to write output: %w", err)
+	}
+	if err := joiner.Close(); err != nil {
+		return res, fmt.Errorf("failed to write output: %w", err)
+	}
+
+	otel.Info("voice_change_response", map[string]any{
+		"request_id": resp.RequestID,
+		"characters": resp.CharacterCount,
+	})
+	return voiceResult{Characters: resp.CharacterCount, Format: f}, nil
+}
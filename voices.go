@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pink-tools/pink-elevenlabs/elevenlabs"
+	"github.com/pink-tools/pink-otel"
+)
+
+func cmdVoices(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "ERROR: voices subcommand required (list, add, edit, delete)")
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "list":
+		cmdVoicesList(args[1:])
+	case "add":
+		cmdVoicesAdd(args[1:])
+	case "edit":
+		cmdVoicesEdit(args[1:])
+	case "delete":
+		cmdVoicesDelete(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown voices command: %s\n", args[0])
+		os.Exit(1)
+	}
+}
+
+func cmdVoicesList(args []string) {
+	fs := flag.NewFlagSet("voices list", flag.ExitOnError)
+	jsonOut := fs.Bool("json", false, "Print voices as JSON")
+	api := addClientFlags(fs)
+	parseArgs(fs, args)
+
+	voices, err := api.newClient().ListVoices(context.Background())
+	if err != nil {
+		fail("voices_list_failed", err)
+	}
+
+	if *jsonOut {
+		printJSON(voices)
+		return
+	}
+	for _, v := range voices {
+		fmt.Printf("%s\t%s\t%s\n", v.VoiceID, v.Name, v.Category)
+	}
+}
+
+type voiceFlags struct {
+	name        *string
+	description *string
+	labels      keyValueFlag
+	removeNoise *bool
+	api         *clientFlags
+}
+
+func addVoiceFlags(fs *flag.FlagSet) *voiceFlags {
+	f := &voiceFlags{
+		name:        fs.String("name", "", "Voice name"),
+		description: fs.String("description", "", "Voice description"),
+		labels:      keyValueFlag{},
+		removeNoise: fs.Bool("remove-background-noise", false, "Clean up samples before cloning"),
+		api:         addClientFlags(fs),
+	}
+	fs.Var(f.labels, "labels", "Labels as key=value, comma-separated or repeated")
+	return f
+}
+
+// request opens the sample files and builds the request. The returned
+// function closes the files.
+func (f *voiceFlags) request(samples []string) (elevenlabs.VoiceRequest, func()) {
+	req := elevenlabs.VoiceRequest{
+		Name:                  *f.name,
+		Description:           *f.description,
+		Labels:                f.labels,
+		RemoveBackgroundNoise: *f.removeNoise,
+	}
+	var files []*os.File
+	closeAll := func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}
+	for _, path := range samples {
+		file, err := os.Open(path)
+		if err != nil {
+			closeAll()
+			fmt.Fprintf(os.Stderr, "ERROR: Sample file not found: %s\n", path)
+			os.Exit(1)
+		}
+		files = append(files, file)
+		req.Samples = append(req.Samples, elevenlabs.VoiceSample{
+			Filename: filepath.Base(path),
+			Audio:    file,
+		})
+	}
+	return req, closeAll
+}
+
+func cmdVoicesAdd(args []string) {
+	fs := flag.NewFlagSet("voices add", flag.ExitOnError)
+	vf := addVoiceFlags(fs)
+	parseArgs(fs, args)
+
+	if fs.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "ERROR: At least one sample file required")
+		os.Exit(1)
+	}
+	if *vf.name == "" {
+		fmt.Fprintln(os.Stderr, "ERROR: --name required")
+		os.Exit(1)
+	}
+
+	req, closeFiles := vf.request(fs.Args())
+	defer closeFiles()
+
+	otel.Info("voice_add_request", map[string]any{"name": req.Name, "samples": len(req.Samples)})
+
+	id, err := vf.api.newClient().AddVoice(context.Background(), req)
+	if err != nil {
+		fail("voice_add_failed", err)
+	}
+
+	otel.Info("voice_added", map[string]any{"voice_id": id})
+	fmt.Println(id)
+}
+
+func cmdVoicesEdit(args []string) {
+	fs := flag.NewFlagSet("voices edit", flag.ExitOnError)
+	vf := addVoiceFlags(fs)
+	parseArgs(fs, args)
+
+	if fs.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "ERROR: Voice ID argument required")
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	client := vf.api.newClient()
+	id := fs.Arg(0)
+
+	req, closeFiles := vf.request(fs.Args()[1:])
+	defer closeFiles()
+
+	// The API requires a name on every edit; keep the current one by default.
+	if req.Name == "" {
+		v, err := client.GetVoice(ctx, id)
+		if err != nil {
+			fail("voice_edit_failed", err)
+		}
+		req.Name = v.Name
+	}
+
+	if err := client.EditVoice(ctx, id, req); err != nil {
+		fail("voice_edit_failed", err)
+	}
+
+	otel.Info("voice_edited", map[string]any{"voice_id": id, "samples": len(req.Samples)})
+	fmt.Println(id)
+}
+
+func cmdVoicesDelete(args []string) {
+	fs := flag.NewFlagSet("voices delete", flag.ExitOnError)
+	api := addClientFlags(fs)
+	parseArgs(fs, args)
+
+	if fs.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "ERROR: Voice ID argument required")
+		os.Exit(1)
+	}
+
+	client := api.newClient()
+	for _, id := range fs.Args() {
+		if err := client.DeleteVoice(context.Background(), id); err != nil {
+			fail("voice_delete_failed", err)
+		}
+		otel.Info("voice_deleted", map[string]any{"voice_id": id})
+		fmt.Println(id)
+	}
+}
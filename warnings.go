@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/pink-tools/pink-otel"
+)
+
+// warning is a degradation a command worked around instead of failing.
+type warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+var (
+	warningsMu sync.Mutex
+	warnings   []warning
+	// keepWarnings is set with --json, whose output lists them.
+	keepWarnings bool
+)
+
+// warn reports a warning. code is also the telemetry event; fields are
+// logged with it.
+func warn(code string, fields map[string]any, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	warningsMu.Lock()
+	if keepWarnings {
+		warnings = append(warnings, warning{Code: code, Message: msg})
+	}
+	warningsMu.Unlock()
+
+	if fields == nil {
+		fields = map[string]any{}
+	}
+	fields["message"] = msg
+	otel.Warn(code, fields)
+	fmt.Fprintf(os.Stderr, "WARNING: %s\n", msg)
+}
+
+// takeWarnings returns the warnings reported so far and forgets them, so
+// each JSON document lists only its own.
+func takeWarnings() []warning {
+	warningsMu.Lock()
+	defer warningsMu.Unlock()
+	w := warnings
+	warnings = nil
+	return w
+}